@@ -0,0 +1,53 @@
+package block_engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"google.golang.org/grpc"
+)
+
+// BackrunBundle creates a BundleBuilder backrunning the target transaction: the target is sent first and
+// unmodified, followed by the transactions added to the builder and the tip paid by feePayer.
+// When building, the target must be fully signed, its blockhash still valid, and the added transactions
+// must reference at least one of its writable accounts.
+func (c *SearcherClient) BackrunBundle(
+	target *pkg.BackrunTarget,
+	feePayer solana.PublicKey,
+	tipLamports uint64,
+) *BundleBuilder {
+	builder := c.NewBundleBuilder(feePayer, tipLamports)
+	builder.target = target
+	return builder
+}
+
+// checkBackrunTarget checks that the backrun target is signed and not expired,
+// and that the bundle transactions touch its writable accounts.
+func (b *BundleBuilder) checkBackrunTarget(
+	ctx context.Context,
+	transactions []*solana.Transaction,
+	opts ...grpc.CallOption,
+) error {
+	if err := b.target.VerifySignatures(); err != nil {
+		return err
+	}
+
+	blockhash := b.target.Transaction.Message.RecentBlockhash
+	valid, err := b.client.RPCConn.IsBlockhashValid(ctx, blockhash, rpc.CommitmentProcessed)
+	if err != nil {
+		return fmt.Errorf("could not check backrun target blockhash: %w", err)
+	}
+	if !valid.Value {
+		return fmt.Errorf("%w: %s", pkg.ErrBackrunTargetExpired, blockhash)
+	}
+
+	// Tipping the same tip account as the target does not make a backrun
+	tipAccounts, err := b.client.TipAccounts.Accounts(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	return b.target.CheckOverlap(transactions, tipAccounts...)
+}
@@ -0,0 +1,123 @@
+package block_engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+)
+
+// blockhashValid returns an isBlockhashValid handler reporting every blockhash as valid or not.
+func blockhashValid(valid bool) fakeRPCHandler {
+	return func([]json.RawMessage) (interface{}, error) {
+		return map[string]interface{}{"context": map[string]interface{}{"slot": 1}, "value": valid}, nil
+	}
+}
+
+// newTestTarget returns a target transaction signed by a fresh key, transferring to the recipient.
+func newTestTarget(t *testing.T, recipient solana.PublicKey) *solana.Transaction {
+	t.Helper()
+	signer := newTestSigner(t)
+
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{system.NewTransferInstruction(1, signer.PublicKey(), recipient).Build()},
+		solana.Hash{9},
+		solana.TransactionPayer(signer.PublicKey()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = pkg.PartialSignTransaction(tx, []pkg.Signer{signer}); err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+func TestBackrunBundle(t *testing.T) {
+	tipAccount := solana.NewWallet().PublicKey()
+	shared := solana.NewWallet().PublicKey()
+	payer := newTestSigner(t)
+	backrun := solana.NewInstruction(
+		solana.SystemProgramID,
+		solana.AccountMetaSlice{solana.Meta(payer.PublicKey()).SIGNER().WRITE(), solana.Meta(shared)},
+		[]byte{2, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0},
+	)
+
+	tests := []struct {
+		name   string
+		target func() *solana.Transaction
+		valid  bool
+		want   error
+	}{
+		{name: "backrun", target: func() *solana.Transaction { return newTestTarget(t, shared) }, valid: true},
+		{name: "expired target", target: func() *solana.Transaction { return newTestTarget(t, shared) }, want: pkg.ErrBackrunTargetExpired},
+		{
+			name: "unsigned target",
+			target: func() *solana.Transaction {
+				tx := newTestTarget(t, shared)
+				tx.Signatures[0] = solana.Signature{}
+				return tx
+			},
+			valid: true,
+			want:  pkg.ErrBackrunTargetNotSigned,
+		},
+		{
+			name:   "unrelated target",
+			target: func() *solana.Transaction { return newTestTarget(t, solana.NewWallet().PublicKey()) },
+			valid:  true,
+			want:   pkg.ErrBackrunNoWritableOverlap,
+		},
+		{
+			// Only sharing the tip account with the target is not a backrun
+			name:   "target tipping the same account",
+			target: func() *solana.Transaction { return newTestTarget(t, tipAccount) },
+			valid:  true,
+			want:   pkg.ErrBackrunNoWritableOverlap,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestSearcher(t, tipAccount)
+			client.rpc.handle("isBlockhashValid", blockhashValid(tt.valid))
+			target := tt.target()
+			data, err := target.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			backrunTarget, err := pkg.NewBackrunTargetFromBytes(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			txs, err := client.BackrunBundle(backrunTarget, payer.PublicKey(), 10_000).
+				AddInstructions(backrun).
+				WithSigners(payer).
+				WithRecentBlockhash(solana.Hash{1}).
+				Build(context.Background())
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Build() = %v, want %v", err, tt.want)
+			}
+			if tt.want != nil {
+				return
+			}
+
+			if len(txs) != 2 {
+				t.Fatalf("got %d transactions, want the target and the backrun", len(txs))
+			}
+			sent, err := txs[0].MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(sent) != string(data) {
+				t.Error("target modified in the bundle")
+			}
+			if got := pkg.ExtractTipLamports(txs[1:], []solana.PublicKey{tipAccount}); got != 10_000 {
+				t.Errorf("backrun tips %d lamports, want 10000", got)
+			}
+		})
+	}
+}
@@ -2,18 +2,21 @@ package block_engine
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
 	"time"
 
 	bundle_pb "github.com/Prophet-Solutions/block-engine-protos/bundle"
 	jito_pb "github.com/Prophet-Solutions/block-engine-protos/searcher"
 	"github.com/Prophet-Solutions/jito-go/pkg"
 	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-// Constants for retry and timeout configurations
+// Constants for the default retry and timeout configurations of DefaultConfirmationPolicy
 const (
 	CheckBundleRetries               = 5                // Number of times to retry checking bundle status
 	CheckBundleRetryDelay            = 5 * time.Second  // Delay between retries for checking bundle status
@@ -22,109 +25,246 @@ const (
 )
 
 // SendBundleWithConfirmation sends a bundle of transactions and waits for confirmation of signatures.
-// It attempts to send the bundle, then continuously checks for the result of the bundle and validates
-// the signatures of the transactions.
+// It uses DefaultConfirmationPolicy, see SendBundleWithConfirmationPolicy.
 func (c *SearcherClient) SendBundleWithConfirmation(
 	ctx context.Context,
 	transactions []*solana.Transaction,
 	opts ...grpc.CallOption,
+) (*BundleResponse, error) {
+	return c.SendBundleWithConfirmationPolicy(ctx, transactions, DefaultConfirmationPolicy(), opts...)
+}
+
+// SendBundleWithConfirmationPolicy sends a bundle of transactions and waits for confirmation of signatures.
+// It attempts to send the bundle, then continuously checks for the result of the bundle and validates
+// the signatures of the transactions as configured by the policy.
+func (c *SearcherClient) SendBundleWithConfirmationPolicy(
+	ctx context.Context,
+	transactions []*solana.Transaction,
+	policy ConfirmationPolicy,
+	opts ...grpc.CallOption,
 ) (*BundleResponse, error) {
 	// Send the bundle of transactions
-	resp, err := c.SendBundle(transactions, opts...)
+	resp, err := c.SendBundle(ctx, transactions, opts...)
 	if err != nil {
 		return nil, err
 	}
 
+	return c.confirmBundle(ctx, resp, transactions, policy)
+}
+
+// confirmBundle checks for the results of a sent bundle and waits for the signatures of its transactions
+// to reach the policy commitment. Zero fields of the policy are set from DefaultConfirmationPolicy.
+func (c *SearcherClient) confirmBundle(
+	ctx context.Context,
+	resp *jito_pb.SendBundleResponse,
+	transactions []*solana.Transaction,
+	policy ConfirmationPolicy,
+) (*BundleResponse, error) {
+	policy = policy.withDefaults()
+	bundleResponse := &BundleResponse{
+		BundleResponse: resp,
+		Signatures:     pkg.BatchExtractSigFromTx(transactions),
+	}
+
+	// Register for the results of this bundle; results that arrived before registration are buffered
+	uuid := resp.GetUuid()
+	results := c.BundleResultDispatcher.Subscribe(uuid)
+	defer c.BundleResultDispatcher.Unsubscribe(uuid)
+
+	// Statuses of the last check, reported to the hooks with the error ending the confirmation
+	var statuses *rpc.GetSignatureStatusesResult
+
 	// Retry checking the bundle result up to a configured number of times
-	for i := 0; i < CheckBundleRetries; i++ {
+	for i := 0; i < policy.Retries; i++ {
 		select {
-		case <-c.AuthenticationService.GRPCCtx.Done():
-			// If the GRPC context is done, return the error
-			return nil, c.AuthenticationService.GRPCCtx.Err()
-		default:
-			// Wait for a configured delay before retrying
-			time.Sleep(CheckBundleRetryDelay)
-
-			// Attempt to receive the bundle result
-			bundleResult, err := c.receiveBundleResult()
-			if err != nil {
-				log.Println("error while receiving bundle result:", err)
-			} else {
-				// Handle the received bundle result
-				if err = c.handleBundleResult(bundleResult); err != nil {
-					return nil, err
-				}
-
-				log.Println("Bundle was sent.")
+		case <-ctx.Done():
+			c.notifyBundleConfirmed(uuid, bundleResponse.Signatures, statuses, ctx.Err())
+			return nil, ctx.Err()
+		case bundleResult, ok := <-results:
+			if !ok {
+				// The stream terminated, its error is published on Events, rely on signature statuses
+				results = nil
+				break
 			}
 
-			// Wait for the statuses of the transaction signatures
-			statuses, err := c.waitForSignatureStatuses(ctx, transactions)
-			if err != nil {
-				continue
+			// Handle the received bundle result
+			if err := c.handleBundleResult(bundleResponse, bundleResult); err != nil {
+				c.notifyBundleConfirmed(uuid, bundleResponse.Signatures, statuses, err)
+				return nil, err
 			}
+		case <-time.After(policy.RetryDelay):
+			// No result for this bundle within the configured delay
+		}
 
-			// Validate the received signature statuses
-			if err = pkg.ValidateSignatureStatuses(statuses); err != nil {
-				continue
+		// Wait for the statuses of the transaction signatures to reach the target commitment
+		var err error
+		statuses, err = waitForSignatureStatuses(ctx, c.RPCConn, transactions, policy)
+		if err != nil {
+			if errors.Is(err, ErrBlockHeightExceeded) {
+				c.BundleTracker.Expired(uuid)
 			}
 
-			// Return the successful bundle response with extracted signatures
-			return &BundleResponse{
-				BundleResponse: resp,
-				Signatures:     pkg.BatchExtractSigFromTx(transactions),
-			}, nil
+			// Failed or expired transactions will not land on a later check
+			if errors.Is(err, pkg.ErrTransactionFailed) ||
+				errors.Is(err, ErrBlockHeightExceeded) ||
+				ctx.Err() != nil {
+				c.notifyBundleConfirmed(uuid, bundleResponse.Signatures, statuses, err)
+				return nil, err
+			}
+			continue
 		}
+		c.BundleTracker.ObserveSignatureStatuses(uuid, statuses)
+		c.notifyBundleConfirmed(uuid, bundleResponse.Signatures, statuses, nil)
+
+		// Return the successful bundle response with extracted signatures
+		return bundleResponse, nil
 	}
 
 	// If the retries are exhausted, return an error
-	return nil, fmt.Errorf("BroadcastBundleWithConfirmation error: max retries (%d) exceeded", CheckBundleRetries)
+	err := fmt.Errorf("BroadcastBundleWithConfirmation error: max retries (%d) exceeded", policy.Retries)
+	c.notifyBundleConfirmed(uuid, bundleResponse.Signatures, statuses, err)
+	return nil, err
 }
 
 // SendBundle creates and sends a bundle of transactions to the Searcher service.
-// It converts transactions to a protobuf packet and sends it using the SearcherService.
+// It validates the bundle, converts transactions to a protobuf packet and sends it using the SearcherService.
+// It refuses to send the bundle when validation reports an error-level finding.
 func (c *SearcherClient) SendBundle(
+	ctx context.Context,
 	transactions []*solana.Transaction,
 	opts ...grpc.CallOption,
 ) (*jito_pb.SendBundleResponse, error) {
+	// Run the pre-flight checks on the bundle
+	findings, locks, err := c.validateBundle(ctx, transactions, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err = findings.Err(); err != nil {
+		return nil, err
+	}
+
 	// Create a new bundle from the transactions
-	bundle, err := c.NewBundle(transactions)
+	bundle, bundleID, err := c.NewBundle(transactions)
+	if err != nil {
+		return nil, err
+	}
+	c.BundleTracker.Created(transactions)
+
+	// Wait for the rate limit, bundles paying higher tips first
+	tipAccounts, err := c.TipAccounts.Accounts(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
+	tipLamports := pkg.ExtractTipLamports(transactions, tipAccounts)
+	if err = c.throttle(ctx, "SendBundle", tipLamports); err != nil {
+		return nil, err
+	}
 
 	// Send the bundle request to the Searcher service
-	return c.SearcherService.SendBundle(
-		c.AuthenticationService.GRPCCtx,
+	resp, err := c.SearcherService.SendBundle(
+		ctx,
 		&jito_pb.SendBundleRequest{
 			Bundle: bundle,
 		},
 		opts...,
 	)
+	if err != nil {
+		if c.RateLimiter != nil && status.Code(err) == codes.ResourceExhausted {
+			c.RateLimiter.Penalize(c.Region, "SendBundle")
+		}
+		return nil, err
+	}
+	c.BundleTracker.Submitted(transactions, resp.GetUuid())
+	c.notifyBundleSent(transactions, bundleID, resp.GetUuid(), tipLamports)
+	if locks != nil {
+		c.AccountLocks.Track(resp.GetUuid(), locks)
+	}
+
+	return resp, nil
+}
+
+// ValidateBundle runs the pre-flight checks on a bundle of transactions against the cached tip accounts,
+// and the account lock analysis when AccountLocks is set.
+// It returns the typed findings of the validation, or an error if the tip accounts or lookup tables
+// could not be retrieved.
+func (c *SearcherClient) ValidateBundle(
+	ctx context.Context,
+	transactions []*solana.Transaction,
+	opts ...grpc.CallOption,
+) (pkg.BundleFindings, error) {
+	findings, _, err := c.validateBundle(ctx, transactions, opts...)
+	return findings, err
+}
+
+// validateBundle runs the pre-flight checks on a bundle and returns the accounts it locks,
+// nil if the account lock analysis is disabled.
+func (c *SearcherClient) validateBundle(
+	ctx context.Context,
+	transactions []*solana.Transaction,
+	opts ...grpc.CallOption,
+) (pkg.BundleFindings, *pkg.BundleAccountLocks, error) {
+	tipAccounts, err := c.TipAccounts.Accounts(ctx, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	findings := pkg.ValidateBundle(transactions, tipAccounts)
+	if c.AccountLocks == nil {
+		return findings, nil, nil
+	}
+
+	// Every bundle writes a tip account, which is not a contention worth reporting
+	locks, lockFindings, err := c.AccountLocks.ExcludeAccounts(tipAccounts...).Analyze(ctx, transactions)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(findings, lockFindings...), locks, nil
+}
+
+// releaseAccountLocks releases the account locks of a bundle once it is no longer in flight.
+func (c *SearcherClient) releaseAccountLocks(result *bundle_pb.BundleResult) {
+	if c.AccountLocks == nil {
+		return
+	}
+
+	switch result.Result.(type) {
+	case *bundle_pb.BundleResult_Rejected,
+		*bundle_pb.BundleResult_Processed,
+		*bundle_pb.BundleResult_Finalized,
+		*bundle_pb.BundleResult_Dropped:
+		c.AccountLocks.Release(result.GetBundleId())
+	}
 }
 
 // NewBundle creates a new bundle protobuf object from a slice of transactions.
 // It converts the transactions into protobuf packets and includes them in the bundle.
-func (c *SearcherClient) NewBundle(transactions []*solana.Transaction) (*bundle_pb.Bundle, error) {
+// It also returns the bundle ID the block engine will assign to the bundle, see pkg.DeriveBundleID.
+func (c *SearcherClient) NewBundle(transactions []*solana.Transaction) (*bundle_pb.Bundle, string, error) {
+	// Derive the bundle ID from the transaction signatures
+	bundleID, err := pkg.DeriveBundleIDFromTransactions(transactions)
+	if err != nil {
+		return nil, "", err
+	}
+
 	// Convert the transactions to protobuf packets
 	packets, err := pkg.ConvertBatchTransactionToProtobufPacket(transactions)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	// Create and return the bundle with the converted packets
 	return &bundle_pb.Bundle{
 		Packets: packets,
 		Header:  nil,
-	}, nil
+	}, bundleID, nil
 }
 
 // NewBundleSubscriptionResults subscribes to bundle result updates from the Searcher service.
 // It uses the provided gRPC call options to set up the subscription.
-func (c *SearcherClient) NewBundleSubscriptionResults(opts ...grpc.CallOption) (jito_pb.SearcherService_SubscribeBundleResultsClient, error) {
+func (c *SearcherClient) NewBundleSubscriptionResults(ctx context.Context, opts ...grpc.CallOption) (jito_pb.SearcherService_SubscribeBundleResultsClient, error) {
 	// Subscribe to bundle results from the Searcher service
 	return c.SearcherService.SubscribeBundleResults(
-		c.AuthenticationService.GRPCCtx,
+		ctx,
 		&jito_pb.SubscribeBundleResultsRequest{},
 		opts...,
 	)
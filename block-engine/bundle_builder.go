@@ -0,0 +1,231 @@
+package block_engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	bundle_pb "github.com/Prophet-Solutions/block-engine-protos/bundle"
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+	"google.golang.org/grpc"
+)
+
+// BundleBuilder assembles a bundle from instructions and transactions and appends the Jito tip.
+// The tip is placed in the final transaction when it fits, otherwise in its own tip transaction.
+type BundleBuilder struct {
+	client      *SearcherClient    // Searcher client used for tip accounts and blockhashes
+	entries     []bundleEntry      // Bundle transactions in order
+	tipLamports uint64             // Tip amount in lamports
+	tipAccount  *solana.PublicKey  // Tip account, selected by the client's TipAccounts if nil
+	feePayer    solana.PublicKey   // Fee payer and tip sender
+	signers     []pkg.Signer       // Signers of the bundle transactions
+	blockhash   *solana.Hash       // Recent blockhash, the latest one is used if nil
+	target      *pkg.BackrunTarget // Transaction backrun by the bundle, sent first unmodified, if any
+}
+
+// bundleEntry is a bundle transaction, either built from instructions or provided pre-built.
+type bundleEntry struct {
+	instructions []solana.Instruction
+	transaction  *solana.Transaction
+}
+
+// NewBundleBuilder creates a BundleBuilder that pays fees and the given tip from feePayer.
+func (c *SearcherClient) NewBundleBuilder(feePayer solana.PublicKey, tipLamports uint64) *BundleBuilder {
+	return &BundleBuilder{
+		client:      c,
+		feePayer:    feePayer,
+		tipLamports: tipLamports,
+	}
+}
+
+// AddInstructions adds a transaction built from the given instructions to the bundle.
+func (b *BundleBuilder) AddInstructions(instructions ...solana.Instruction) *BundleBuilder {
+	b.entries = append(b.entries, bundleEntry{instructions: instructions})
+	return b
+}
+
+// AddTransactions adds pre-built transactions to the bundle.
+// They are re-signed with the builder's signers, other signatures are kept.
+func (b *BundleBuilder) AddTransactions(transactions ...*solana.Transaction) *BundleBuilder {
+	for _, tx := range transactions {
+		b.entries = append(b.entries, bundleEntry{transaction: tx})
+	}
+	return b
+}
+
+// WithTip sets the tip amount in lamports.
+func (b *BundleBuilder) WithTip(lamports uint64) *BundleBuilder {
+	b.tipLamports = lamports
+	return b
+}
+
+// WithTipAccount sets the tip account instead of picking a random one.
+func (b *BundleBuilder) WithTipAccount(tipAccount solana.PublicKey) *BundleBuilder {
+	b.tipAccount = &tipAccount
+	return b
+}
+
+// WithFeePayer sets the fee payer, which also sends the tip.
+func (b *BundleBuilder) WithFeePayer(feePayer solana.PublicKey) *BundleBuilder {
+	b.feePayer = feePayer
+	return b
+}
+
+// WithSigners adds the signers of the bundle transactions.
+func (b *BundleBuilder) WithSigners(signers ...pkg.Signer) *BundleBuilder {
+	b.signers = append(b.signers, signers...)
+	return b
+}
+
+// WithRecentBlockhash sets the blockhash of the transactions built from instructions.
+func (b *BundleBuilder) WithRecentBlockhash(blockhash solana.Hash) *BundleBuilder {
+	b.blockhash = &blockhash
+	return b
+}
+
+// Build assembles, tips, signs and validates the bundle transactions.
+// It fetches a random tip account and the latest blockhash when they were not set.
+func (b *BundleBuilder) Build(ctx context.Context, opts ...grpc.CallOption) ([]*solana.Transaction, error) {
+	if len(b.entries) == 0 {
+		return nil, errors.New("bundle builder has no transactions")
+	}
+	if b.feePayer.IsZero() {
+		return nil, errors.New("bundle builder has no fee payer")
+	}
+
+	blockhash, err := b.recentBlockhash(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		tipInstruction solana.Instruction
+		tipAccounts    []solana.PublicKey
+	)
+	if b.tipLamports > 0 {
+		tipAccount, err := b.resolveTipAccount(ctx, opts...)
+		if err != nil {
+			return nil, err
+		}
+		tipAccounts = append(tipAccounts, tipAccount)
+		tipInstruction = system.NewTransferInstruction(b.tipLamports, b.feePayer, tipAccount).Build()
+	}
+
+	transactions := make([]*solana.Transaction, 0, len(b.entries)+1)
+	for i, entry := range b.entries {
+		if entry.transaction != nil {
+			transactions = append(transactions, entry.transaction)
+			continue
+		}
+
+		// Try to place the tip in the final transaction
+		if tipInstruction != nil && i == len(b.entries)-1 {
+			instructions := append(append([]solana.Instruction{}, entry.instructions...), tipInstruction)
+			tx, fits, err := b.newTransactionIfFits(instructions, blockhash)
+			if err != nil {
+				return nil, err
+			}
+			if fits {
+				transactions = append(transactions, tx)
+				tipInstruction = nil
+				continue
+			}
+		}
+
+		tx, err := solana.NewTransaction(entry.instructions, blockhash, solana.TransactionPayer(b.feePayer))
+		if err != nil {
+			return nil, fmt.Errorf("could not build transaction %d: %w", i, err)
+		}
+		transactions = append(transactions, tx)
+	}
+
+	// The final transaction is full or pre-built, send the tip in its own transaction
+	if tipInstruction != nil {
+		tx, err := solana.NewTransaction([]solana.Instruction{tipInstruction}, blockhash, solana.TransactionPayer(b.feePayer))
+		if err != nil {
+			return nil, fmt.Errorf("could not build tip transaction: %w", err)
+		}
+		transactions = append(transactions, tx)
+	}
+
+	// Re-sign and validate every transaction of the bundle
+	for i, tx := range transactions {
+		if err = pkg.PartialSignTransaction(tx, b.signers); err != nil {
+			return nil, fmt.Errorf("could not sign transaction %d: %w", i, err)
+		}
+	}
+	// Put the backrun target first, once checked against the bundle transactions
+	if b.target != nil {
+		if err = b.checkBackrunTarget(ctx, transactions, opts...); err != nil {
+			return nil, err
+		}
+		transactions = append([]*solana.Transaction{b.target.Transaction}, transactions...)
+	}
+
+	if err = pkg.ValidateBundle(transactions, tipAccounts).Err(); err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// BuildBundle builds the bundle transactions and converts them into a bundle protobuf object.
+// It also returns the bundle ID the block engine will assign to the bundle.
+func (b *BundleBuilder) BuildBundle(ctx context.Context, opts ...grpc.CallOption) (*bundle_pb.Bundle, string, error) {
+	transactions, err := b.Build(ctx, opts...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return b.client.NewBundle(transactions)
+}
+
+// newTransactionIfFits builds a transaction from the instructions and reports whether it fits the size limit.
+func (b *BundleBuilder) newTransactionIfFits(
+	instructions []solana.Instruction,
+	blockhash solana.Hash,
+) (*solana.Transaction, bool, error) {
+	tx, err := solana.NewTransaction(instructions, blockhash, solana.TransactionPayer(b.feePayer))
+	if err != nil {
+		return nil, false, err
+	}
+
+	size, err := pkg.SerializedTransactionSize(tx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return tx, size <= pkg.MaxTransactionSize, nil
+}
+
+// recentBlockhash returns the configured blockhash or fetches the latest one.
+func (b *BundleBuilder) recentBlockhash(ctx context.Context) (solana.Hash, error) {
+	if b.blockhash != nil {
+		return *b.blockhash, nil
+	}
+
+	resp, err := b.client.RPCConn.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return solana.Hash{}, fmt.Errorf("could not get latest blockhash: %w", err)
+	}
+
+	return resp.Value.Blockhash, nil
+}
+
+// resolveTipAccount returns the configured tip account or selects one with the client's tip account strategy.
+func (b *BundleBuilder) resolveTipAccount(ctx context.Context, opts ...grpc.CallOption) (solana.PublicKey, error) {
+	if b.tipAccount != nil {
+		b.client.TipAccounts.MarkUsed(*b.tipAccount)
+		return *b.tipAccount, nil
+	}
+
+	account, err := b.client.TipAccounts.Next(ctx, opts...)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("could not select tip account: %w", err)
+	}
+
+	return account, nil
+}
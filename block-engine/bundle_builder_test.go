@@ -0,0 +1,143 @@
+package block_engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+)
+
+// newTestSigner returns a signer holding a fresh random key.
+func newTestSigner(t *testing.T) *pkg.MemorySigner {
+	t.Helper()
+	key, err := solana.NewRandomPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkg.NewMemorySigner(key)
+}
+
+func transferInstruction(from solana.PublicKey, lamports uint64) solana.Instruction {
+	return system.NewTransferInstruction(lamports, from, solana.NewWallet().PublicKey()).Build()
+}
+
+func TestBundleBuilderTipsFinalTransaction(t *testing.T) {
+	payer := newTestSigner(t)
+	tipAccount := solana.NewWallet().PublicKey()
+	client := newTestSearcher(t, tipAccount)
+
+	txs, err := client.NewBundleBuilder(payer.PublicKey(), 10_000).
+		AddInstructions(transferInstruction(payer.PublicKey(), 1)).
+		AddInstructions(transferInstruction(payer.PublicKey(), 2)).
+		WithSigners(payer).
+		WithRecentBlockhash(solana.Hash{1}).
+		Build(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(txs) != 2 {
+		t.Fatalf("got %d transactions, want 2", len(txs))
+	}
+	if got := pkg.ExtractTipLamports(txs[1:], []solana.PublicKey{tipAccount}); got != 10_000 {
+		t.Errorf("final transaction tips %d lamports, want 10000", got)
+	}
+	if got := pkg.ExtractTipLamports(txs[:1], []solana.PublicKey{tipAccount}); got != 0 {
+		t.Errorf("first transaction tips %d lamports, want 0", got)
+	}
+	if findings := pkg.ValidateBundle(txs, []solana.PublicKey{tipAccount}); len(findings) != 0 {
+		t.Errorf("built bundle has findings: %v", findings)
+	}
+}
+
+func TestBundleBuilderAddsTipTransaction(t *testing.T) {
+	payer := newTestSigner(t)
+	tipAccount := solana.NewWallet().PublicKey()
+	programID := solana.NewWallet().PublicKey()
+
+	prebuilt, err := solana.NewTransaction(
+		[]solana.Instruction{transferInstruction(payer.PublicKey(), 1)},
+		solana.Hash{1},
+		solana.TransactionPayer(payer.PublicKey()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An instruction leaving no room for the tip transfer
+	large := solana.NewInstruction(programID, solana.AccountMetaSlice{solana.Meta(payer.PublicKey()).SIGNER().WRITE()}, make([]byte, 1000))
+
+	tests := []struct {
+		name    string
+		builder func(*BundleBuilder) *BundleBuilder
+	}{
+		{"pre-built final transaction", func(b *BundleBuilder) *BundleBuilder { return b.AddTransactions(prebuilt) }},
+		{"full final transaction", func(b *BundleBuilder) *BundleBuilder { return b.AddInstructions(large) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestSearcher(t, tipAccount)
+			builder := client.NewBundleBuilder(payer.PublicKey(), 5_000).
+				WithSigners(payer).
+				WithRecentBlockhash(solana.Hash{1})
+
+			txs, err := tt.builder(builder).Build(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(txs) != 2 {
+				t.Fatalf("got %d transactions, want 2", len(txs))
+			}
+			if got := pkg.ExtractTipLamports(txs[1:], []solana.PublicKey{tipAccount}); got != 5_000 {
+				t.Errorf("tip transaction tips %d lamports, want 5000", got)
+			}
+			if len(txs[1].Message.Instructions) != 1 {
+				t.Errorf("tip transaction has %d instructions, want 1", len(txs[1].Message.Instructions))
+			}
+		})
+	}
+}
+
+func TestBundleBuilderWithTipAccount(t *testing.T) {
+	payer := newTestSigner(t)
+	tipAccounts := []solana.PublicKey{solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()}
+	client := newTestSearcher(t, tipAccounts...)
+
+	txs, err := client.NewBundleBuilder(payer.PublicKey(), 1_000).
+		AddInstructions(transferInstruction(payer.PublicKey(), 1)).
+		WithTipAccount(tipAccounts[1]).
+		WithSigners(payer).
+		WithRecentBlockhash(solana.Hash{1}).
+		Build(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := pkg.ExtractTipLamports(txs, tipAccounts[1:]); got != 1_000 {
+		t.Errorf("bundle tips %d lamports to the chosen tip account, want 1000", got)
+	}
+}
+
+func TestBundleBuilderErrors(t *testing.T) {
+	payer := newTestSigner(t)
+	client := newTestSearcher(t, solana.NewWallet().PublicKey())
+
+	tests := []struct {
+		name    string
+		builder *BundleBuilder
+	}{
+		{"no transactions", client.NewBundleBuilder(payer.PublicKey(), 1_000)},
+		{"no fee payer", client.NewBundleBuilder(solana.PublicKey{}, 1_000).
+			AddInstructions(transferInstruction(payer.PublicKey(), 1))},
+		{"unsigned", client.NewBundleBuilder(payer.PublicKey(), 1_000).
+			AddInstructions(transferInstruction(payer.PublicKey(), 1)).
+			WithRecentBlockhash(solana.Hash{1})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.builder.Build(context.Background()); err == nil {
+				t.Error("Build succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package block_engine
+
+import (
+	"testing"
+
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestSearcherClientNewBundle(t *testing.T) {
+	tipAccount := solana.NewWallet().PublicKey()
+	client := newTestSearcher(t, tipAccount)
+	txs := newTestBundle(t, tipAccount)
+
+	bundle, bundleID, err := client.NewBundle(txs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bundle.Packets) != len(txs) {
+		t.Errorf("got %d packets, want %d", len(bundle.Packets), len(txs))
+	}
+	if want := pkg.DeriveBundleID([]solana.Signature{txs[0].Signatures[0]}); bundleID != want {
+		t.Errorf("bundle ID = %s, want %s", bundleID, want)
+	}
+
+	unsigned := &solana.Transaction{Message: txs[0].Message}
+	if _, _, err = client.NewBundle([]*solana.Transaction{unsigned}); err == nil {
+		t.Error("unsigned transaction accepted")
+	}
+}
@@ -0,0 +1,192 @@
+package block_engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// DefaultComputeUnitMargin is the default safety margin over the simulated compute units.
+const DefaultComputeUnitMargin = 0.1
+
+// TransactionSigner signs a transaction again after it was rewritten.
+type TransactionSigner func(tx *solana.Transaction) error
+
+// ComputeBudgetOpts configures TuneComputeBudget.
+type ComputeBudgetOpts struct {
+	Margin           float64           // Safety margin over the simulated units, e.g. 0.1 for 10%, DefaultComputeUnitMargin if zero
+	MinUnits         uint32            // Minimum compute unit limit set
+	UnitPrice        *uint64           // Compute unit price in micro-lamports to set, the current one is kept if nil
+	SimulateTogether bool              // Whether to simulate the transactions as a bundle with simulateBundle on the Jito RPC connection
+	Sign             TransactionSigner // Signs each rewritten transaction, required
+}
+
+// ComputeUnitsTuning is the compute budget change of a transaction.
+type ComputeUnitsTuning struct {
+	UnitsConsumed uint64 // Compute units consumed in simulation
+	PreviousLimit uint32 // Compute unit limit the transaction requested before tuning
+	Limit         uint32 // Compute unit limit set
+	Saved         int64  // Compute units saved, negative if the limit was raised
+}
+
+// ComputeBudgetResult is the outcome of TuneComputeBudget.
+type ComputeBudgetResult struct {
+	Transactions []ComputeUnitsTuning // Tuning of each transaction, in bundle order
+	TotalSaved   int64                // Compute units saved over the bundle
+}
+
+// TuneComputeBudget simulates the transactions to measure the compute units they consume, then sets their
+// SetComputeUnitLimit to the consumed units plus the safety margin, and their SetComputeUnitPrice when a price
+// is given, inserting the instructions when missing. The transactions are rewritten in place and signed again
+// with the signer. Transactions are simulated one by one on the RPC connection unless SimulateTogether is set,
+// which lets later transactions see the effects of earlier ones.
+func (c *SearcherClient) TuneComputeBudget(
+	ctx context.Context,
+	transactions []*solana.Transaction,
+	opts ComputeBudgetOpts,
+) (*ComputeBudgetResult, error) {
+	if opts.Sign == nil {
+		return nil, errors.New("compute budget tuning requires a transaction signer")
+	}
+	if opts.Margin <= 0 {
+		opts.Margin = DefaultComputeUnitMargin
+	}
+
+	// Simulate copies requesting the maximum limit, so no transaction runs out of compute units
+	copies := make([]*solana.Transaction, 0, len(transactions))
+	for i, tx := range transactions {
+		simulated, err := copyTransaction(tx)
+		if err != nil {
+			return nil, fmt.Errorf("could not copy transaction %d: %w", i, err)
+		}
+		if err = pkg.SetComputeUnitLimit(simulated, pkg.MaxComputeUnitLimit); err != nil {
+			return nil, fmt.Errorf("could not set compute unit limit of transaction %d: %w", i, err)
+		}
+		if opts.UnitPrice != nil {
+			if err = pkg.SetComputeUnitPrice(simulated, *opts.UnitPrice); err != nil {
+				return nil, fmt.Errorf("could not set compute unit price of transaction %d: %w", i, err)
+			}
+		}
+		copies = append(copies, simulated)
+	}
+
+	consumed, err := c.simulateUnitsConsumed(ctx, copies, opts.SimulateTogether)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ComputeBudgetResult{Transactions: make([]ComputeUnitsTuning, 0, len(transactions))}
+	for i, tx := range transactions {
+		tuning := ComputeUnitsTuning{
+			UnitsConsumed: consumed[i],
+			PreviousLimit: pkg.RequestedComputeUnitLimit(tx),
+			Limit:         computeUnitLimit(consumed[i], opts.Margin, opts.MinUnits),
+		}
+		tuning.Saved = int64(tuning.PreviousLimit) - int64(tuning.Limit)
+
+		if err = pkg.SetComputeUnitLimit(tx, tuning.Limit); err != nil {
+			return nil, fmt.Errorf("could not set compute unit limit of transaction %d: %w", i, err)
+		}
+		if opts.UnitPrice != nil {
+			if err = pkg.SetComputeUnitPrice(tx, *opts.UnitPrice); err != nil {
+				return nil, fmt.Errorf("could not set compute unit price of transaction %d: %w", i, err)
+			}
+		}
+		if err = opts.Sign(tx); err != nil {
+			return nil, fmt.Errorf("could not sign transaction %d: %w", i, err)
+		}
+
+		result.Transactions = append(result.Transactions, tuning)
+		result.TotalSaved += tuning.Saved
+	}
+
+	return result, nil
+}
+
+// simulateUnitsConsumed returns the compute units consumed by each transaction in simulation.
+// Signatures are not verified and blockhashes are replaced, as the simulated transactions are unsigned copies.
+func (c *SearcherClient) simulateUnitsConsumed(
+	ctx context.Context,
+	transactions []*solana.Transaction,
+	together bool,
+) ([]uint64, error) {
+	consumed := make([]uint64, 0, len(transactions))
+
+	if together {
+		result, err := c.SimulateBundle(ctx, transactions, &SimulateBundleOpts{
+			SkipSigVerify:          true,
+			ReplaceRecentBlockhash: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err = result.Err(); err != nil {
+			return nil, err
+		}
+		for i, txResult := range result.TransactionResults {
+			if txResult.UnitsConsumed == nil {
+				return nil, fmt.Errorf("simulation of transaction %d reported no compute units", i)
+			}
+			consumed = append(consumed, *txResult.UnitsConsumed)
+		}
+		if len(consumed) != len(transactions) {
+			return nil, fmt.Errorf("simulation returned %d results for %d transactions", len(consumed), len(transactions))
+		}
+		return consumed, nil
+	}
+
+	for i, tx := range transactions {
+		resp, err := c.RPCConn.SimulateTransactionWithOpts(ctx, tx, &rpc.SimulateTransactionOpts{
+			SigVerify:              false,
+			Commitment:             rpc.CommitmentProcessed,
+			ReplaceRecentBlockhash: true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not simulate transaction %d: %w", i, err)
+		}
+		if resp.Value.Err != nil {
+			return nil, &SimulationFailureError{
+				TxSignature: pkg.ExtractSigFromTx(tx).String(),
+				Message:     fmt.Sprint(resp.Value.Err),
+			}
+		}
+		if resp.Value.UnitsConsumed == nil {
+			return nil, fmt.Errorf("simulation of transaction %d reported no compute units", i)
+		}
+		consumed = append(consumed, *resp.Value.UnitsConsumed)
+	}
+
+	return consumed, nil
+}
+
+// computeUnitLimit returns the consumed units plus the margin, within MinUnits and MaxComputeUnitLimit.
+func computeUnitLimit(consumed uint64, margin float64, minUnits uint32) uint32 {
+	units := math.Ceil(float64(consumed) * (1 + margin))
+	if units > pkg.MaxComputeUnitLimit {
+		units = pkg.MaxComputeUnitLimit
+	}
+	if uint32(units) < minUnits {
+		return minUnits
+	}
+	return uint32(units)
+}
+
+// copyTransaction returns a deep copy of the transaction through its wire format.
+func copyTransaction(tx *solana.Transaction) (*solana.Transaction, error) {
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	copied := &solana.Transaction{}
+	if err = copied.UnmarshalWithDecoder(bin.NewBorshDecoder(data)); err != nil {
+		return nil, err
+	}
+	return copied, nil
+}
@@ -0,0 +1,153 @@
+package block_engine
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestTuneComputeBudget(t *testing.T) {
+	tipAccount := solana.NewWallet().PublicKey()
+	price := uint64(1_000)
+
+	tests := []struct {
+		name     string
+		together bool
+	}{
+		{name: "one by one"},
+		{name: "as a bundle", together: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestSearcher(t, tipAccount)
+			payer := newTestSigner(t)
+			txs, err := client.NewBundleBuilder(payer.PublicKey(), 10_000).
+				AddInstructions(transferInstruction(payer.PublicKey(), 1)).
+				WithSigners(payer).
+				WithRecentBlockhash(solana.Hash{1}).
+				Build(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Simulated copies request the maximum limit
+			var simulatedLimits []uint32
+			client.rpc.handle("simulateTransaction", func(params []json.RawMessage) (interface{}, error) {
+				simulatedLimits = append(simulatedLimits, decodeSimulatedLimits(t, params[0])...)
+				return map[string]interface{}{
+					"context": map[string]interface{}{"slot": 1},
+					"value":   map[string]interface{}{"err": nil, "logs": []string{}, "unitsConsumed": 1_000},
+				}, nil
+			})
+			client.rpc.handle("simulateBundle", func(params []json.RawMessage) (interface{}, error) {
+				var encoded struct {
+					EncodedTransactions []string `json:"encodedTransactions"`
+				}
+				if err := json.Unmarshal(params[0], &encoded); err != nil {
+					return nil, err
+				}
+				for _, tx := range encoded.EncodedTransactions {
+					data, _ := json.Marshal(tx)
+					simulatedLimits = append(simulatedLimits, decodeSimulatedLimits(t, data)...)
+				}
+				return map[string]interface{}{
+					"context": map[string]interface{}{"slot": 1},
+					"value": map[string]interface{}{
+						"summary":            "succeeded",
+						"transactionResults": []interface{}{map[string]interface{}{"err": nil, "logs": []string{}, "unitsConsumed": 1_000}},
+					},
+				}, nil
+			})
+
+			signed := 0
+			result, err := client.TuneComputeBudget(context.Background(), txs, ComputeBudgetOpts{
+				UnitPrice:        &price,
+				SimulateTogether: tt.together,
+				Sign: func(tx *solana.Transaction) error {
+					signed++
+					return pkg.PartialSignTransaction(tx, []pkg.Signer{payer})
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(simulatedLimits) != 1 || simulatedLimits[0] != pkg.MaxComputeUnitLimit {
+				t.Errorf("simulated limits = %v, want [%d]", simulatedLimits, pkg.MaxComputeUnitLimit)
+			}
+			if len(result.Transactions) != 1 || signed != 1 {
+				t.Fatalf("tuned %d transactions, signed %d, want 1", len(result.Transactions), signed)
+			}
+			tuning := result.Transactions[0]
+			want := ComputeUnitsTuning{
+				UnitsConsumed: 1_000,
+				PreviousLimit: 2 * pkg.DefaultInstructionComputeUnitLimit,
+				Limit:         1_100,
+				Saved:         2*pkg.DefaultInstructionComputeUnitLimit - 1_100,
+			}
+			if tuning != want || result.TotalSaved != want.Saved {
+				t.Errorf("tuning = %+v, total %d, want %+v", tuning, result.TotalSaved, want)
+			}
+
+			if units, _ := pkg.GetComputeUnitLimit(txs[0]); units != 1_100 {
+				t.Errorf("compute unit limit = %d, want 1100", units)
+			}
+			if got, _ := pkg.GetComputeUnitPrice(txs[0]); got != price {
+				t.Errorf("compute unit price = %d, want %d", got, price)
+			}
+			if err = txs[0].VerifySignatures(); err != nil {
+				t.Errorf("tuned transaction not signed again: %v", err)
+			}
+		})
+	}
+}
+
+func TestTuneComputeBudgetRequiresSigner(t *testing.T) {
+	tipAccount := solana.NewWallet().PublicKey()
+	client := newTestSearcher(t, tipAccount)
+
+	if _, err := client.TuneComputeBudget(context.Background(), newTestBundle(t, tipAccount), ComputeBudgetOpts{}); err == nil {
+		t.Error("tuning without a signer succeeded")
+	}
+}
+
+func TestComputeUnitLimit(t *testing.T) {
+	tests := []struct {
+		consumed uint64
+		margin   float64
+		minUnits uint32
+		want     uint32
+	}{
+		{consumed: 1_000, margin: 0.1, want: 1_100},
+		{consumed: 1_001, margin: 0.1, want: 1_102},
+		{consumed: 1_000, margin: 0.1, minUnits: 5_000, want: 5_000},
+		{consumed: pkg.MaxComputeUnitLimit, margin: 0.5, want: pkg.MaxComputeUnitLimit},
+	}
+	for _, tt := range tests {
+		if got := computeUnitLimit(tt.consumed, tt.margin, tt.minUnits); got != tt.want {
+			t.Errorf("computeUnitLimit(%d, %v, %d) = %d, want %d", tt.consumed, tt.margin, tt.minUnits, got, tt.want)
+		}
+	}
+}
+
+// decodeSimulatedLimits decodes a base64 transaction sent for simulation and returns its compute unit limit.
+func decodeSimulatedLimits(t *testing.T, param json.RawMessage) []uint32 {
+	t.Helper()
+
+	var encoded string
+	if err := json.Unmarshal(param, &encoded); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := solana.TransactionFromBase64(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	units, ok := pkg.GetComputeUnitLimit(tx)
+	if !ok {
+		return nil
+	}
+	return []uint32{units}
+}
@@ -0,0 +1,59 @@
+package block_engine
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// ErrBlockHeightExceeded is returned when the block height passed the last valid block height of the
+// transactions before they were confirmed, so they can no longer land.
+var ErrBlockHeightExceeded = errors.New("block height exceeded the last valid block height of the transactions")
+
+// ConfirmationPolicy configures how SendBundleWithConfirmationPolicy waits for a bundle to land.
+// Zero fields take their value from DefaultConfirmationPolicy, FailOnTransactionError and
+// LastValidBlockHeight excepted.
+type ConfirmationPolicy struct {
+	Commitment             rpc.ConfirmationStatusType // Confirmation status every transaction must reach
+	Retries                int                        // Number of times to check the bundle result and statuses
+	RetryDelay             time.Duration              // Maximum wait for a bundle result on each check
+	ConfirmationTimeout    time.Duration              // Wall-clock timeout of each signature status wait
+	PollInterval           time.Duration              // Delay between signature status polls
+	FailOnTransactionError bool                       // Whether a transaction error in the status meta fails the bundle
+	LastValidBlockHeight   uint64                     // If set, waits end when the block height passes it instead of on ConfirmationTimeout
+}
+
+// DefaultConfirmationPolicy returns the policy used by SendBundleWithConfirmation.
+// It waits for the processed commitment and ignores transaction errors.
+func DefaultConfirmationPolicy() ConfirmationPolicy {
+	return ConfirmationPolicy{
+		Commitment:          rpc.ConfirmationStatusProcessed,
+		Retries:             CheckBundleRetries,
+		RetryDelay:          CheckBundleRetryDelay,
+		ConfirmationTimeout: SignaturesConfirmationTimeout,
+		PollInterval:        SignaturesConfirmationRetryDelay,
+	}
+}
+
+// withDefaults returns the policy with its zero fields set from DefaultConfirmationPolicy, so a partially
+// filled policy neither gives up before its first check nor polls the signature statuses without delay.
+func (p ConfirmationPolicy) withDefaults() ConfirmationPolicy {
+	defaults := DefaultConfirmationPolicy()
+	if p.Commitment == "" {
+		p.Commitment = defaults.Commitment
+	}
+	if p.Retries <= 0 {
+		p.Retries = defaults.Retries
+	}
+	if p.RetryDelay <= 0 {
+		p.RetryDelay = defaults.RetryDelay
+	}
+	if p.ConfirmationTimeout <= 0 {
+		p.ConfirmationTimeout = defaults.ConfirmationTimeout
+	}
+	if p.PollInterval <= 0 {
+		p.PollInterval = defaults.PollInterval
+	}
+	return p
+}
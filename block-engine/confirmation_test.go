@@ -0,0 +1,55 @@
+package block_engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func TestConfirmationPolicyWithDefaults(t *testing.T) {
+	if got := (ConfirmationPolicy{}).withDefaults(); got != DefaultConfirmationPolicy() {
+		t.Errorf("zero policy = %+v, want %+v", got, DefaultConfirmationPolicy())
+	}
+
+	partial := ConfirmationPolicy{
+		Commitment:             rpc.ConfirmationStatusFinalized,
+		Retries:                -1,
+		PollInterval:           time.Millisecond,
+		FailOnTransactionError: true,
+		LastValidBlockHeight:   42,
+	}
+	got := partial.withDefaults()
+	want := DefaultConfirmationPolicy()
+	want.Commitment = rpc.ConfirmationStatusFinalized
+	want.PollInterval = time.Millisecond
+	want.FailOnTransactionError = true
+	want.LastValidBlockHeight = 42
+	if got != want {
+		t.Errorf("partial policy = %+v, want %+v", got, want)
+	}
+}
+
+func TestSendBundleWithConfirmationPolicyZeroPolicy(t *testing.T) {
+	tipAccount := solana.NewWallet().PublicKey()
+	client := newTestSearcher(t, tipAccount)
+	client.rpc.handle("getSignatureStatuses", signatureStatuses(rpc.ConfirmationStatusProcessed, nil))
+	client.service.uuid = func(int) string { return "uuid" }
+	client.stream.results <- acceptedResult("uuid")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := client.SendBundleWithConfirmationPolicy(ctx, newTestBundle(t, tipAccount), ConfirmationPolicy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Accepted == nil {
+		t.Error("accepted result not recorded on the response")
+	}
+	if got := client.rpc.count("getSignatureStatuses"); got != 1 {
+		t.Errorf("polled the signature statuses %d times, want 1", got)
+	}
+}
@@ -0,0 +1,213 @@
+package block_engine
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	bundle_pb "github.com/Prophet-Solutions/block-engine-protos/bundle"
+	jito_pb "github.com/Prophet-Solutions/block-engine-protos/searcher"
+	"github.com/Prophet-Solutions/jito-go/pkg"
+)
+
+// Constants for bundle result dispatching
+const (
+	BundleResultBufferTTL      = 30 * time.Second // How long results without a waiter are kept
+	BundleResultWaiterCapacity = 16               // Number of results buffered per waiter channel
+)
+
+// ErrBundleResultStreamClosed is returned when the bundle results stream terminated without an error.
+var ErrBundleResultStreamClosed = errors.New("bundle result stream closed")
+
+// BundleResultDispatcher owns the bundle results stream and routes every received
+// BundleResult to the waiter registered for its bundle UUID.
+type BundleResultDispatcher struct {
+	stream    jito_pb.SearcherService_SubscribeBundleResultsClient // Bundle results stream
+	bufferTTL time.Duration                                        // Retention of results received before their waiter
+	waiters   map[string]chan *bundle_pb.BundleResult              // Waiter channels indexed by bundle UUID
+	pending   map[string][]pendingBundleResult                     // Buffered results indexed by bundle UUID
+	listeners []func(*bundle_pb.BundleResult)                      // Listeners receiving every result
+	done      chan struct{}                                        // Closed when the stream terminates
+	err       error                                                // Error that terminated the stream
+	events    *pkg.EventBus                                        // Event bus the dropped results are published on
+	mu        sync.Mutex                                           // Mutex for synchronizing waiters and buffers
+}
+
+// pendingBundleResult is a bundle result received before a waiter registered for it.
+type pendingBundleResult struct {
+	result     *bundle_pb.BundleResult
+	receivedAt time.Time
+}
+
+// NewBundleResultDispatcher creates a dispatcher for the given bundle results stream and starts
+// receiving from it. Results arriving before their waiter registers are buffered for bufferTTL.
+// Results dropped because their waiter is not draining are published on events as pkg.EventResultDropped.
+func NewBundleResultDispatcher(
+	stream jito_pb.SearcherService_SubscribeBundleResultsClient,
+	bufferTTL time.Duration,
+	events *pkg.EventBus,
+) *BundleResultDispatcher {
+	d := &BundleResultDispatcher{
+		stream:    stream,
+		bufferTTL: bufferTTL,
+		events:    events,
+		waiters:   make(map[string]chan *bundle_pb.BundleResult),
+		pending:   make(map[string][]pendingBundleResult),
+		done:      make(chan struct{}),
+	}
+
+	go d.run()
+
+	return d
+}
+
+// Subscribe registers a waiter for the bundle with the given UUID and returns the channel its results
+// are delivered on. Buffered results for the bundle are delivered immediately. The channel is closed
+// when the waiter is unsubscribed or the stream terminates.
+func (d *BundleResultDispatcher) Subscribe(uuid string) <-chan *bundle_pb.BundleResult {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if ch, ok := d.waiters[uuid]; ok {
+		return ch
+	}
+
+	ch := make(chan *bundle_pb.BundleResult, BundleResultWaiterCapacity)
+
+	// Return a closed channel if the stream already terminated
+	if d.err != nil {
+		close(ch)
+		return ch
+	}
+
+	// Flush the results that arrived before the waiter registered
+	d.pruneExpired(time.Now())
+	for _, pending := range d.pending[uuid] {
+		d.deliver(ch, pending.result)
+	}
+	delete(d.pending, uuid)
+
+	d.waiters[uuid] = ch
+	return ch
+}
+
+// Unsubscribe removes the waiter for the bundle with the given UUID and closes its channel.
+func (d *BundleResultDispatcher) Unsubscribe(uuid string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if ch, ok := d.waiters[uuid]; ok {
+		delete(d.waiters, uuid)
+		close(ch)
+	}
+}
+
+// AddListener registers a function called with every bundle result received, whether or not a waiter
+// is registered for it. Listeners are called from the stream goroutine and must not block.
+func (d *BundleResultDispatcher) AddListener(listener func(*bundle_pb.BundleResult)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.listeners = append(d.listeners, listener)
+}
+
+// Done returns a channel that is closed when the bundle results stream terminates.
+func (d *BundleResultDispatcher) Done() <-chan struct{} {
+	return d.done
+}
+
+// Err returns the error that terminated the bundle results stream, or nil while it is running.
+func (d *BundleResultDispatcher) Err() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.err
+}
+
+// run receives bundle results from the stream until it fails and dispatches them to their waiters.
+func (d *BundleResultDispatcher) run() {
+	for {
+		result, err := d.stream.Recv()
+		if err != nil {
+			d.terminate(err)
+			return
+		}
+
+		d.dispatch(result)
+	}
+}
+
+// dispatch notifies the listeners and routes a bundle result to its waiter,
+// or buffers it until a waiter registers.
+func (d *BundleResultDispatcher) dispatch(result *bundle_pb.BundleResult) {
+	d.mu.Lock()
+	listeners := d.listeners
+	d.mu.Unlock()
+
+	for _, listener := range listeners {
+		listener(result)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	d.pruneExpired(now)
+
+	uuid := result.GetBundleId()
+	if ch, ok := d.waiters[uuid]; ok {
+		d.deliver(ch, result)
+		return
+	}
+
+	d.pending[uuid] = append(d.pending[uuid], pendingBundleResult{
+		result:     result,
+		receivedAt: now,
+	})
+}
+
+// deliver sends a result on a waiter channel without blocking the stream.
+func (d *BundleResultDispatcher) deliver(ch chan *bundle_pb.BundleResult, result *bundle_pb.BundleResult) {
+	select {
+	case ch <- result:
+	default:
+		d.events.Publish(pkg.Event{Kind: pkg.EventResultDropped, Source: "SubscribeBundleResults", Payload: result})
+	}
+}
+
+// pruneExpired removes buffered results older than the buffer TTL.
+func (d *BundleResultDispatcher) pruneExpired(now time.Time) {
+	for uuid, results := range d.pending {
+		kept := results[:0]
+		for _, pending := range results {
+			if now.Sub(pending.receivedAt) < d.bufferTTL {
+				kept = append(kept, pending)
+			}
+		}
+
+		if len(kept) == 0 {
+			delete(d.pending, uuid)
+		} else {
+			d.pending[uuid] = kept
+		}
+	}
+}
+
+// terminate records the stream error and closes every waiter channel.
+func (d *BundleResultDispatcher) terminate(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err == nil {
+		err = ErrBundleResultStreamClosed
+	}
+	d.err = err
+
+	for uuid, ch := range d.waiters {
+		delete(d.waiters, uuid)
+		close(ch)
+	}
+	d.pending = make(map[string][]pendingBundleResult)
+
+	close(d.done)
+}
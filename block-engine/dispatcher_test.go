@@ -0,0 +1,176 @@
+package block_engine
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	bundle_pb "github.com/Prophet-Solutions/block-engine-protos/bundle"
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	"google.golang.org/grpc"
+)
+
+// fakeBundleResultStream is a bundle results stream fed from a channel.
+// Closing the channel ends the stream with io.EOF.
+type fakeBundleResultStream struct {
+	grpc.ClientStream
+	results chan *bundle_pb.BundleResult
+	err     error
+	once    sync.Once
+}
+
+func newFakeBundleResultStream() *fakeBundleResultStream {
+	return &fakeBundleResultStream{
+		results: make(chan *bundle_pb.BundleResult, 16),
+		err:     io.EOF,
+	}
+}
+
+func (s *fakeBundleResultStream) Recv() (*bundle_pb.BundleResult, error) {
+	result, ok := <-s.results
+	if !ok {
+		return nil, s.err
+	}
+	return result, nil
+}
+
+// close ends the stream.
+func (s *fakeBundleResultStream) close() {
+	s.once.Do(func() { close(s.results) })
+}
+
+func acceptedResult(uuid string) *bundle_pb.BundleResult {
+	return &bundle_pb.BundleResult{
+		BundleId: uuid,
+		Result: &bundle_pb.BundleResult_Accepted{
+			Accepted: &bundle_pb.Accepted{Slot: 1, ValidatorIdentity: "validator"},
+		},
+	}
+}
+
+func receiveResult(t *testing.T, ch <-chan *bundle_pb.BundleResult) *bundle_pb.BundleResult {
+	t.Helper()
+	select {
+	case result, ok := <-ch:
+		if !ok {
+			t.Fatal("waiter channel closed")
+		}
+		return result
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for bundle result")
+		return nil
+	}
+}
+
+func TestBundleResultDispatcherRoutesByUUID(t *testing.T) {
+	stream := newFakeBundleResultStream()
+	d := NewBundleResultDispatcher(stream, BundleResultBufferTTL, nil)
+
+	first := d.Subscribe("first")
+	second := d.Subscribe("second")
+
+	stream.results <- acceptedResult("second")
+	stream.results <- acceptedResult("first")
+
+	if got := receiveResult(t, first).GetBundleId(); got != "first" {
+		t.Errorf("first waiter got result for %q", got)
+	}
+	if got := receiveResult(t, second).GetBundleId(); got != "second" {
+		t.Errorf("second waiter got result for %q", got)
+	}
+}
+
+func TestBundleResultDispatcherBuffersEarlyResults(t *testing.T) {
+	stream := newFakeBundleResultStream()
+	d := NewBundleResultDispatcher(stream, BundleResultBufferTTL, nil)
+
+	seen := make(chan string, 1)
+	d.AddListener(func(result *bundle_pb.BundleResult) { seen <- result.GetBundleId() })
+
+	stream.results <- acceptedResult("early")
+	<-seen
+
+	if got := receiveResult(t, d.Subscribe("early")).GetBundleId(); got != "early" {
+		t.Errorf("got buffered result for %q", got)
+	}
+}
+
+func TestBundleResultDispatcherExpiresBufferedResults(t *testing.T) {
+	stream := newFakeBundleResultStream()
+	d := NewBundleResultDispatcher(stream, 10*time.Millisecond, nil)
+
+	seen := make(chan string, 1)
+	d.AddListener(func(result *bundle_pb.BundleResult) { seen <- result.GetBundleId() })
+
+	stream.results <- acceptedResult("late")
+	<-seen
+	time.Sleep(20 * time.Millisecond)
+
+	select {
+	case result := <-d.Subscribe("late"):
+		t.Errorf("got expired result for %q", result.GetBundleId())
+	default:
+	}
+}
+
+func TestBundleResultDispatcherPublishesDroppedResults(t *testing.T) {
+	stream := newFakeBundleResultStream()
+	bus := pkg.NewEventBus()
+	events := bus.Subscribe(1, pkg.EventResultDropped)
+	d := NewBundleResultDispatcher(stream, BundleResultBufferTTL, bus)
+
+	// The waiter does not drain its channel, the result past its capacity is dropped
+	d.Subscribe("uuid")
+	for i := 0; i <= BundleResultWaiterCapacity; i++ {
+		stream.results <- acceptedResult("uuid")
+	}
+
+	select {
+	case event := <-events.C:
+		if result, ok := event.Payload.(*bundle_pb.BundleResult); !ok || result.GetBundleId() != "uuid" {
+			t.Errorf("dropped result event = %v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("dropped result not published")
+	}
+}
+
+func TestBundleResultDispatcherUnsubscribe(t *testing.T) {
+	d := NewBundleResultDispatcher(newFakeBundleResultStream(), BundleResultBufferTTL, nil)
+
+	ch := d.Subscribe("uuid")
+	if again := d.Subscribe("uuid"); again != ch {
+		t.Error("second Subscribe returned a different channel")
+	}
+
+	d.Unsubscribe("uuid")
+	if _, ok := <-ch; ok {
+		t.Error("channel not closed by Unsubscribe")
+	}
+}
+
+func TestBundleResultDispatcherTermination(t *testing.T) {
+	stream := newFakeBundleResultStream()
+	stream.err = errors.New("stream reset")
+	d := NewBundleResultDispatcher(stream, BundleResultBufferTTL, nil)
+
+	ch := d.Subscribe("uuid")
+	stream.close()
+
+	select {
+	case <-d.Done():
+	case <-time.After(time.Second):
+		t.Fatal("dispatcher not done after the stream ended")
+	}
+	if _, ok := <-ch; ok {
+		t.Error("waiter channel not closed on termination")
+	}
+	if err := d.Err(); err != stream.err {
+		t.Errorf("Err() = %v, want %v", err, stream.err)
+	}
+	if _, ok := <-d.Subscribe("other"); ok {
+		t.Error("Subscribe after termination returned an open channel")
+	}
+}
@@ -0,0 +1,299 @@
+package block_engine
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	bundle_pb "github.com/Prophet-Solutions/block-engine-protos/bundle"
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	journal_pkg "github.com/Prophet-Solutions/jito-go/pkg/journal"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// SentBundle describes a bundle sent to the block engine.
+type SentBundle struct {
+	UUID         string                // Bundle UUID returned by the block engine
+	BundleID     string                // Bundle ID derived from the signatures
+	Region       string                // Location code of the block engine, if known
+	TipLamports  uint64                // Tip paid by the bundle
+	Transactions []*solana.Transaction // Bundle transactions
+	SentAt       time.Time             // Time the bundle was sent
+}
+
+// BundleHook observes the bundles sent by a SearcherClient. Hooks are called synchronously,
+// OnBundleResult from the bundle results stream goroutine, and must not block.
+type BundleHook interface {
+	// OnBundleSent is called once a bundle was sent.
+	OnBundleSent(bundle *SentBundle)
+	// OnBundleResult is called with every bundle result received.
+	OnBundleResult(result *bundle_pb.BundleResult)
+	// OnBundleConfirmed is called once the confirmation of a bundle ended, with the final signature statuses
+	// if they were retrieved and the error that ended the confirmation, nil if the bundle landed.
+	OnBundleConfirmed(uuid string, signatures []solana.Signature, statuses *rpc.GetSignatureStatusesResult, err error)
+}
+
+// AddBundleHook registers a hook observing the bundles sent. Hooks must be added before sending bundles.
+func (c *SearcherClient) AddBundleHook(hook BundleHook) {
+	c.BundleHooks = append(c.BundleHooks, hook)
+}
+
+// notifyBundleSent calls the hooks with a bundle that was sent.
+func (c *SearcherClient) notifyBundleSent(transactions []*solana.Transaction, bundleID, uuid string, tipLamports uint64) {
+	if len(c.BundleHooks) == 0 {
+		return
+	}
+
+	bundle := &SentBundle{
+		UUID:         uuid,
+		BundleID:     bundleID,
+		Region:       c.Region,
+		TipLamports:  tipLamports,
+		Transactions: transactions,
+		SentAt:       time.Now(),
+	}
+	for _, hook := range c.BundleHooks {
+		hook.OnBundleSent(bundle)
+	}
+}
+
+// notifyBundleResult calls the hooks with a bundle result received.
+func (c *SearcherClient) notifyBundleResult(result *bundle_pb.BundleResult) {
+	for _, hook := range c.BundleHooks {
+		hook.OnBundleResult(result)
+	}
+}
+
+// notifyBundleConfirmed calls the hooks once the confirmation of a bundle ended.
+func (c *SearcherClient) notifyBundleConfirmed(
+	uuid string,
+	signatures []solana.Signature,
+	statuses *rpc.GetSignatureStatusesResult,
+	err error,
+) {
+	for _, hook := range c.BundleHooks {
+		hook.OnBundleConfirmed(uuid, signatures, statuses, err)
+	}
+}
+
+// Constants for the JournalHook queue
+const (
+	JournalHookQueueCapacity = 1024            // Number of events a JournalHook buffers before dropping new ones
+	JournalHookSendTimeout   = 5 * time.Second // Time OnBundleSent waits for room in a full queue
+)
+
+// ErrJournalQueueFull is reported when a bundle event is dropped because the journal writer fell behind.
+var ErrJournalQueueFull = errors.New("journal queue full")
+
+// JournalHook is a BundleHook recording every bundle event in a journal. Events are queued and appended
+// by a writer goroutine, so that journal writes and syncs never block the bundle results stream.
+// When the queue is full, OnBundleSent waits up to SendTimeout for room while results and confirmations
+// are dropped at once. Dropped events are counted by Dropped and reported to the ErrorHandler.
+// A JournalHook built without NewJournalHook appends synchronously.
+//
+// The hook is not durable on its own: queued events are lost if the process exits before Close, and
+// appended ones are only flushed to disk on every append if the journal was opened with SyncWrites.
+type JournalHook struct {
+	Journal      *journal_pkg.Journal     // Journal the events are appended to
+	ErrorHandler func(error)              // Called when an event could not be journaled, nil to ignore
+	SendTimeout  time.Duration            // Time OnBundleSent waits for room in a full queue, 0 to drop at once
+	records      chan *journal_pkg.Record // Events waiting to be appended
+	done         chan struct{}            // Closed once the writer goroutine returned
+	dropped      atomic.Uint64            // Events dropped because the queue was full or closed
+	closed       bool                     // Whether the hook was closed
+	mu           sync.RWMutex             // Mutex for synchronizing the queue closing
+}
+
+// NewJournalHook creates a JournalHook appending to the journal and starts its writer goroutine.
+// Close must be called to flush the queued events before closing the journal.
+func NewJournalHook(journal *journal_pkg.Journal, errorHandler func(error)) *JournalHook {
+	h := &JournalHook{
+		Journal:      journal,
+		ErrorHandler: errorHandler,
+		SendTimeout:  JournalHookSendTimeout,
+		records:      make(chan *journal_pkg.Record, JournalHookQueueCapacity),
+		done:         make(chan struct{}),
+	}
+	go h.write()
+	return h
+}
+
+// Close stops the hook once the queued events were appended. Events observed afterwards are dropped.
+// It does not close the journal.
+func (h *JournalHook) Close() {
+	h.mu.Lock()
+	if h.records == nil || h.closed {
+		h.mu.Unlock()
+		return
+	}
+	h.closed = true
+	close(h.records)
+	h.mu.Unlock()
+
+	<-h.done
+}
+
+// Dropped returns the number of events dropped because the queue was full or the hook was closed.
+func (h *JournalHook) Dropped() uint64 {
+	return h.dropped.Load()
+}
+
+// OnBundleSent records the bundle with its raw transactions.
+func (h *JournalHook) OnBundleSent(bundle *SentBundle) {
+	record, err := journal_pkg.NewSentRecord(
+		bundle.UUID,
+		bundle.BundleID,
+		bundle.Region,
+		bundle.TipLamports,
+		bundle.Transactions,
+		bundle.SentAt,
+	)
+	if err != nil {
+		h.handleError(err)
+		return
+	}
+	h.append(record, h.SendTimeout)
+}
+
+// OnBundleResult records the bundle result.
+func (h *JournalHook) OnBundleResult(result *bundle_pb.BundleResult) {
+	record := &journal_pkg.Record{
+		Kind: journal_pkg.RecordResult,
+		UUID: result.GetBundleId(),
+	}
+	journaled := &journal_pkg.Result{At: time.Now()}
+
+	switch r := result.Result.(type) {
+	case *bundle_pb.BundleResult_Accepted:
+		journaled.State = string(BundleStateAccepted)
+		journaled.Slot = r.Accepted.GetSlot()
+		journaled.Validator = r.Accepted.GetValidatorIdentity()
+	case *bundle_pb.BundleResult_Rejected:
+		journaled.State = string(BundleStateRejected)
+		journaled.Reason = NewBundleRejectionError(result.GetBundleId(), r.Rejected).Error()
+		record.Outcome = journal_pkg.OutcomeRejected
+	case *bundle_pb.BundleResult_Processed:
+		journaled.State = string(BundleStateProcessed)
+		journaled.Slot = r.Processed.GetSlot()
+		journaled.Validator = r.Processed.GetValidatorIdentity()
+	case *bundle_pb.BundleResult_Finalized:
+		journaled.State = string(BundleStateFinalized)
+		record.Outcome = journal_pkg.OutcomeLanded
+	case *bundle_pb.BundleResult_Dropped:
+		journaled.State = string(BundleStateDropped)
+		journaled.Reason = r.Dropped.GetReason().String()
+		record.Outcome = journal_pkg.OutcomeDropped
+	default:
+		return
+	}
+
+	record.Time = journaled.At
+	record.Result = journaled
+	h.append(record, 0)
+}
+
+// OnBundleConfirmed records the final signature statuses of the bundle and its outcome.
+func (h *JournalHook) OnBundleConfirmed(
+	uuid string,
+	signatures []solana.Signature,
+	statuses *rpc.GetSignatureStatusesResult,
+	err error,
+) {
+	record := &journal_pkg.Record{
+		Kind: journal_pkg.RecordConfirmed,
+		UUID: uuid,
+	}
+
+	switch {
+	case err == nil:
+		record.Outcome = journal_pkg.OutcomeLanded
+	case errors.Is(err, ErrDroppedBundle):
+		record.Outcome = journal_pkg.OutcomeDropped
+	case errors.Is(err, ErrBundleRejected):
+		record.Outcome = journal_pkg.OutcomeRejected
+	case errors.Is(err, pkg.ErrTransactionFailed):
+		record.Outcome = journal_pkg.OutcomeFailed
+	case errors.Is(err, ErrBlockHeightExceeded):
+		record.Outcome = journal_pkg.OutcomeExpired
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	if statuses != nil {
+		for i, status := range statuses.Value {
+			journaled := journal_pkg.SignatureStatus{}
+			if i < len(signatures) {
+				journaled.Signature = signatures[i].String()
+			}
+			if status != nil {
+				journaled.Slot = status.Slot
+				journaled.ConfirmationStatus = string(status.ConfirmationStatus)
+				if status.Err != nil {
+					journaled.Err = fmt.Sprint(status.Err)
+				}
+			}
+			record.Statuses = append(record.Statuses, journaled)
+		}
+	}
+
+	h.append(record, 0)
+}
+
+// append queues a record for the writer goroutine, waiting up to timeout for room in a full queue,
+// or appends it to the journal without one.
+func (h *JournalHook) append(record *journal_pkg.Record, timeout time.Duration) {
+	if h.records == nil {
+		if err := h.Journal.Append(record); err != nil {
+			h.handleError(err)
+		}
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if h.closed {
+		h.dropped.Add(1)
+		h.handleError(journal_pkg.ErrJournalClosed)
+		return
+	}
+	select {
+	case h.records <- record:
+		return
+	default:
+	}
+
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case h.records <- record:
+			return
+		case <-timer.C:
+		}
+	}
+	h.dropped.Add(1)
+	h.handleError(ErrJournalQueueFull)
+}
+
+// write appends the queued records until the queue is closed.
+func (h *JournalHook) write() {
+	defer close(h.done)
+
+	for record := range h.records {
+		if err := h.Journal.Append(record); err != nil {
+			h.handleError(err)
+		}
+	}
+}
+
+// handleError reports an event that could not be journaled.
+func (h *JournalHook) handleError(err error) {
+	if h.ErrorHandler != nil {
+		h.ErrorHandler(fmt.Errorf("could not journal bundle event: %w", err))
+	}
+}
@@ -0,0 +1,223 @@
+package block_engine
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	bundle_pb "github.com/Prophet-Solutions/block-engine-protos/bundle"
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	journal_pkg "github.com/Prophet-Solutions/jito-go/pkg/journal"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// confirmedHook is a BundleHook recording the errors that ended the confirmations.
+type confirmedHook struct {
+	onSent func() // Called when a bundle was sent, if set
+	errs   map[string]error
+	mu     sync.Mutex
+}
+
+func (h *confirmedHook) OnBundleSent(*SentBundle) {
+	if h.onSent != nil {
+		h.onSent()
+	}
+}
+
+func (h *confirmedHook) OnBundleResult(*bundle_pb.BundleResult) {}
+
+func (h *confirmedHook) OnBundleConfirmed(uuid string, _ []solana.Signature, _ *rpc.GetSignatureStatusesResult, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.errs == nil {
+		h.errs = make(map[string]error)
+	}
+	h.errs[uuid] = err
+}
+
+// confirmed returns the error that ended the confirmation of the bundle, and whether it ended.
+func (h *confirmedHook) confirmed(uuid string) (error, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	err, ok := h.errs[uuid]
+	return err, ok
+}
+
+func TestConfirmBundleNotifiesEveryExit(t *testing.T) {
+	tipAccount := solana.NewWallet().PublicKey()
+	tests := []struct {
+		name   string
+		setup  func(client *testSearcher)
+		cancel bool // Whether the context is cancelled once the bundle was sent
+		target error
+	}{
+		{
+			name: "rejected",
+			setup: func(client *testSearcher) {
+				client.stream.results <- &bundle_pb.BundleResult{BundleId: "uuid", Result: &bundle_pb.BundleResult_Rejected{
+					Rejected: &bundle_pb.Rejected{Reason: &bundle_pb.Rejected_InternalError{
+						InternalError: &bundle_pb.InternalError{Msg: "boom"},
+					}},
+				}}
+			},
+			target: ErrInternalError,
+		},
+		{
+			name: "dropped",
+			setup: func(client *testSearcher) {
+				client.stream.results <- &bundle_pb.BundleResult{BundleId: "uuid", Result: &bundle_pb.BundleResult_Dropped{
+					Dropped: &bundle_pb.Dropped{Reason: bundle_pb.DroppedReason_BlockhashExpired},
+				}}
+			},
+			target: ErrDroppedBundle,
+		},
+		{
+			name: "retries exhausted",
+			setup: func(client *testSearcher) {
+				client.rpc.handle("getSignatureStatuses", signatureStatuses(rpc.ConfirmationStatusProcessed, nil))
+			},
+		},
+		{
+			name:   "context done",
+			setup:  func(*testSearcher) {},
+			cancel: true,
+			target: context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestSearcher(t, tipAccount)
+			client.service.uuid = func(int) string { return "uuid" }
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			hook := &confirmedHook{}
+			if tt.cancel {
+				hook.onSent = cancel
+			}
+			client.AddBundleHook(hook)
+			tt.setup(client)
+
+			// Results and cancellation end the confirmation before the first retry delay
+			policy := fastConfirmationPolicy()
+			policy.RetryDelay = time.Second
+			if tt.target == nil {
+				policy.RetryDelay = time.Millisecond
+			}
+			_, sendErr := client.SendBundleWithConfirmationPolicy(ctx, newTestBundle(t, tipAccount), policy)
+			if sendErr == nil {
+				t.Fatal("confirmation succeeded")
+			}
+
+			err, ok := hook.confirmed("uuid")
+			if !ok {
+				t.Fatal("hooks not notified of the end of the confirmation")
+			}
+			if err != sendErr {
+				t.Errorf("hook error = %v, want %v", err, sendErr)
+			}
+			if tt.target != nil && !errors.Is(err, tt.target) {
+				t.Errorf("hook error = %v, want %v", err, tt.target)
+			}
+		})
+	}
+}
+
+func TestJournalHook(t *testing.T) {
+	journal, err := journal_pkg.NewJournal(journal_pkg.Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	tipAccount := solana.NewWallet().PublicKey()
+	txs := newTestBundle(t, tipAccount)
+	var handled []error
+	hook := NewJournalHook(journal, func(err error) { handled = append(handled, err) })
+
+	hook.OnBundleSent(&SentBundle{
+		UUID:         "uuid",
+		BundleID:     "bundle",
+		Region:       "ams",
+		TipLamports:  10_000,
+		Transactions: txs,
+		SentAt:       time.Now(),
+	})
+	hook.OnBundleResult(acceptedResult("uuid"))
+	dropped := &bundle_pb.BundleResult{BundleId: "uuid", Result: &bundle_pb.BundleResult_Dropped{
+		Dropped: &bundle_pb.Dropped{Reason: bundle_pb.DroppedReason_BlockhashExpired},
+	}}
+	hook.OnBundleResult(dropped)
+	hook.OnBundleConfirmed("uuid", pkg.BatchExtractSigFromTx(txs), nil, &DroppedBundleError{BundleID: "uuid", Message: "expired"})
+	hook.Close()
+
+	if len(handled) != 0 {
+		t.Fatalf("errors reported: %v", handled)
+	}
+	entry, ok, err := journal.Get("uuid")
+	if err != nil || !ok {
+		t.Fatalf("Get() = %v, %v", ok, err)
+	}
+	if entry.Outcome != journal_pkg.OutcomeDropped || entry.Region != "ams" || entry.TipLamports != 10_000 {
+		t.Errorf("entry = %+v", entry)
+	}
+	if len(entry.Results) != 2 || entry.Results[0].State != string(BundleStateAccepted) {
+		t.Errorf("results = %+v", entry.Results)
+	}
+	decoded, err := entry.DecodeTransactions()
+	if err != nil || len(decoded) != len(txs) || decoded[0].Signatures[0] != txs[0].Signatures[0] {
+		t.Errorf("DecodeTransactions() = %d transactions, %v", len(decoded), err)
+	}
+
+	// Events observed after Close are dropped and reported
+	hook.OnBundleResult(acceptedResult("late"))
+	if len(handled) != 1 || !errors.Is(handled[0], journal_pkg.ErrJournalClosed) || hook.Dropped() != 1 {
+		t.Errorf("errors after Close = %v, %d dropped, want ErrJournalClosed", handled, hook.Dropped())
+	}
+}
+
+func TestJournalHookQueueFull(t *testing.T) {
+	journal, err := journal_pkg.NewJournal(journal_pkg.Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	// A hook without a writer goroutine running keeps its queue full
+	var handled []error
+	hook := &JournalHook{
+		Journal:      journal,
+		ErrorHandler: func(err error) { handled = append(handled, err) },
+		records:      make(chan *journal_pkg.Record, 1),
+		done:         make(chan struct{}),
+	}
+	hook.OnBundleResult(acceptedResult("first"))
+	hook.OnBundleResult(acceptedResult("second"))
+
+	if len(handled) != 1 || !errors.Is(handled[0], ErrJournalQueueFull) || hook.Dropped() != 1 {
+		t.Errorf("errors = %v, %d dropped, want ErrJournalQueueFull", handled, hook.Dropped())
+	}
+
+	// Sent bundles wait for room in the queue, up to the send timeout
+	sent := &SentBundle{UUID: "sent", Transactions: newTestBundle(t, solana.NewWallet().PublicKey()), SentAt: time.Now()}
+	hook.SendTimeout = 5 * time.Second
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		<-hook.records
+	}()
+	hook.OnBundleSent(sent)
+	if len(handled) != 1 || hook.Dropped() != 1 {
+		t.Errorf("errors = %v, %d dropped, want the sent bundle queued", handled, hook.Dropped())
+	}
+
+	hook.SendTimeout = 20 * time.Millisecond
+	hook.OnBundleSent(sent)
+	if len(handled) != 2 || !errors.Is(handled[1], ErrJournalQueueFull) || hook.Dropped() != 2 {
+		t.Errorf("errors = %v, %d dropped, want the sent bundle dropped after the timeout", handled, hook.Dropped())
+	}
+}
@@ -0,0 +1,340 @@
+package block_engine
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	jito_pb "github.com/Prophet-Solutions/block-engine-protos/searcher"
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	block_engine_pkg "github.com/Prophet-Solutions/jito-go/pkg/block-engine"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/mr-tron/base58"
+	"google.golang.org/grpc"
+)
+
+// JSONRPCRequestTimeout is the timeout of requests to the transactions API.
+const JSONRPCRequestTimeout = 10 * time.Second
+
+// InflightBundleState is the state of a bundle reported by getInflightBundleStatuses.
+type InflightBundleState string
+
+// Inflight bundle states
+const (
+	InflightBundleInvalid InflightBundleState = "Invalid" // Bundle not found in the last five minutes
+	InflightBundlePending InflightBundleState = "Pending" // Bundle not failed, not landed and not invalid
+	InflightBundleFailed  InflightBundleState = "Failed"  // Every region marked the bundle as failed
+	InflightBundleLanded  InflightBundleState = "Landed"  // Bundle landed on-chain
+)
+
+// BundleStatus is the on-chain status of a landed bundle.
+type BundleStatus struct {
+	BundleID           string                     `json:"bundle_id"`           // Bundle ID
+	Transactions       []string                   `json:"transactions"`        // Signatures of the bundle transactions
+	Slot               uint64                     `json:"slot"`                // Slot the bundle landed in
+	ConfirmationStatus rpc.ConfirmationStatusType `json:"confirmation_status"` // Commitment reached by the bundle
+	Error              json.RawMessage            `json:"err"`                 // Execution result, {"Ok": null} on success
+}
+
+// GetBundleStatusesResult is the result of getBundleStatuses.
+type GetBundleStatusesResult struct {
+	Slot  uint64          // Slot the statuses were read at
+	Value []*BundleStatus // Statuses in request order, nil for bundles that did not land
+}
+
+// InflightBundleStatus is the status of a bundle submitted in the last five minutes.
+type InflightBundleStatus struct {
+	BundleID   string              `json:"bundle_id"`   // Bundle ID
+	Status     InflightBundleState `json:"status"`      // Bundle state
+	LandedSlot *uint64             `json:"landed_slot"` // Slot the bundle landed in, if landed
+}
+
+// GetInflightBundleStatusesResult is the result of getInflightBundleStatuses.
+type GetInflightBundleStatusesResult struct {
+	Slot  uint64                  // Slot the statuses were read at
+	Value []*InflightBundleStatus // Statuses in request order
+}
+
+// Err returns an error if the landed bundle reported an execution error.
+func (s *BundleStatus) Err() error {
+	var result struct {
+		Err json.RawMessage `json:"Err"`
+	}
+	if len(s.Error) == 0 || json.Unmarshal(s.Error, &result) != nil || len(result.Err) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: bundle %s: %s", pkg.ErrTransactionFailed, s.BundleID, result.Err)
+}
+
+// Err returns the typed rejection error of a failed or invalid bundle, nil otherwise.
+func (s *InflightBundleStatus) Err() error {
+	switch s.Status {
+	case InflightBundleFailed:
+		return &DroppedBundleError{BundleID: s.BundleID, Message: "bundle failed in every region"}
+	case InflightBundleInvalid:
+		return &DroppedBundleError{BundleID: s.BundleID, Message: "bundle not found"}
+	default:
+		return nil
+	}
+}
+
+// NewJSONRPCClient creates a JSONRPCClient for the block engine at blockEngineURL, e.g. block_engine_pkg.NYC.
+// The uuid is sent in the x-jito-auth header when not empty. Transactions are sent base64 encoded.
+func NewJSONRPCClient(blockEngineURL string, rpcClient *rpc.Client, uuid string) *JSONRPCClient {
+	headers := map[string]string{}
+	if uuid != "" {
+		headers["x-jito-auth"] = uuid
+	}
+
+	blockEngineURL = strings.TrimSuffix(blockEngineURL, "/")
+	c := &JSONRPCClient{
+		BlockEngineURL: blockEngineURL,
+		UUID:           uuid,
+		HTTPClient:     &http.Client{Timeout: JSONRPCRequestTimeout},
+		JitoRPCConn:    rpc.NewWithHeaders(blockEngineURL+block_engine_pkg.BundlesPath, headers),
+		RPCConn:        rpcClient,
+		Encoding:       solana.EncodingBase64,
+		BundleTracker:  NewBundleTracker(BundleTrackerRetention),
+	}
+	c.TipAccounts = newTipAccountManager(func(ctx context.Context, _ ...grpc.CallOption) ([]string, error) {
+		resp, err := c.GetTipAccounts(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return resp.GetAccounts(), nil
+	}, TipAccountRandom, DefaultTipAccountsTTL)
+	c.TipAccounts.publish = func(event pkg.Event) { c.Events.Publish(event) }
+
+	return c
+}
+
+// SendBundleWithConfirmation sends a bundle of transactions and waits for confirmation of signatures.
+// It uses DefaultConfirmationPolicy, see SendBundleWithConfirmationPolicy.
+func (c *JSONRPCClient) SendBundleWithConfirmation(
+	ctx context.Context,
+	transactions []*solana.Transaction,
+) (*BundleResponse, error) {
+	return c.SendBundleWithConfirmationPolicy(ctx, transactions, DefaultConfirmationPolicy())
+}
+
+// SendBundleWithConfirmationPolicy sends a bundle of transactions and waits for confirmation of signatures.
+// It polls the inflight status of the bundle and validates the signatures of the transactions as configured
+// by the policy, returning the same BundleResponse and typed errors as SearcherClient.
+func (c *JSONRPCClient) SendBundleWithConfirmationPolicy(
+	ctx context.Context,
+	transactions []*solana.Transaction,
+	policy ConfirmationPolicy,
+) (*BundleResponse, error) {
+	// Send the bundle of transactions
+	resp, err := c.SendBundle(ctx, transactions)
+	if err != nil {
+		return nil, err
+	}
+
+	processed, err := c.waitForBundle(ctx, resp.GetUuid(), transactions, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Return the successful bundle response with extracted signatures
+	return &BundleResponse{
+		BundleResponse: resp,
+		Signatures:     pkg.BatchExtractSigFromTx(transactions),
+		Processed:      processed,
+	}, nil
+}
+
+// waitForBundle polls the inflight status of the bundle and waits for the signatures of its transactions
+// to reach the policy commitment. It returns the landed slot if the block engine reported one.
+// Zero fields of the policy are set from DefaultConfirmationPolicy.
+func (c *JSONRPCClient) waitForBundle(
+	ctx context.Context,
+	bundleID string,
+	transactions []*solana.Transaction,
+	policy ConfirmationPolicy,
+) (*BundleProcessed, error) {
+	policy = policy.withDefaults()
+	var processed *BundleProcessed
+
+	// Retry checking the bundle status up to a configured number of times
+	for i := 0; i < policy.Retries; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(policy.RetryDelay):
+			}
+		}
+
+		if bundleID != "" {
+			statuses, err := c.GetInflightBundleStatuses(ctx, []string{bundleID})
+			if err != nil {
+				c.Events.Publish(pkg.Event{Kind: pkg.EventRefreshError, Source: "getInflightBundleStatuses", Err: err})
+			} else if len(statuses.Value) > 0 && statuses.Value[0] != nil {
+				status := statuses.Value[0]
+				c.BundleTracker.ObserveInflightStatus(status)
+				if err = status.Err(); err != nil {
+					return nil, err
+				}
+				if status.Status == InflightBundleLanded && status.LandedSlot != nil {
+					processed = &BundleProcessed{Slot: *status.LandedSlot}
+				}
+			}
+		}
+
+		// Wait for the statuses of the transaction signatures to reach the target commitment
+		statuses, err := waitForSignatureStatuses(ctx, c.RPCConn, transactions, policy)
+		if err != nil {
+			if errors.Is(err, ErrBlockHeightExceeded) {
+				c.BundleTracker.Expired(bundleID)
+			}
+
+			// Failed or expired transactions will not land on a later check
+			if errors.Is(err, pkg.ErrTransactionFailed) ||
+				errors.Is(err, ErrBlockHeightExceeded) ||
+				ctx.Err() != nil {
+				return nil, err
+			}
+			continue
+		}
+		c.BundleTracker.ObserveSignatureStatuses(bundleID, statuses)
+
+		return processed, nil
+	}
+
+	// If the retries are exhausted, return an error
+	return nil, fmt.Errorf("confirmation error: max retries (%d) exceeded", policy.Retries)
+}
+
+// SendBundle sends a bundle of transactions with the sendBundle method.
+// It runs the same pre-flight checks as SearcherClient.SendBundle and returns the bundle ID as the response UUID.
+func (c *JSONRPCClient) SendBundle(
+	ctx context.Context,
+	transactions []*solana.Transaction,
+) (*jito_pb.SendBundleResponse, error) {
+	// Run the pre-flight checks on the bundle
+	findings, err := c.ValidateBundle(ctx, transactions)
+	if err != nil {
+		return nil, err
+	}
+	if err = findings.Err(); err != nil {
+		return nil, err
+	}
+
+	c.BundleTracker.Created(transactions)
+
+	// Encode the transactions with the configured encoding
+	encoded := make([]string, 0, len(transactions))
+	for i, tx := range transactions {
+		data, err := c.encodeTransaction(tx)
+		if err != nil {
+			return nil, fmt.Errorf("could not serialize transaction %d: %w", i, err)
+		}
+		encoded = append(encoded, data)
+	}
+
+	var bundleID string
+	err = c.JitoRPCConn.RPCCallForInto(ctx, &bundleID, "sendBundle", []interface{}{
+		encoded,
+		map[string]solana.EncodingType{"encoding": c.encoding()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not send bundle: %w", err)
+	}
+	c.BundleTracker.Submitted(transactions, bundleID)
+
+	return &jito_pb.SendBundleResponse{
+		Uuid: bundleID,
+	}, nil
+}
+
+// ValidateBundle runs the pre-flight checks on a bundle of transactions against the cached tip accounts.
+// It returns the typed findings of the validation, or an error if the tip accounts could not be retrieved.
+func (c *JSONRPCClient) ValidateBundle(ctx context.Context, transactions []*solana.Transaction) (pkg.BundleFindings, error) {
+	tipAccounts, err := c.TipAccounts.Accounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return pkg.ValidateBundle(transactions, tipAccounts), nil
+}
+
+// GetBundleStatuses retrieves the on-chain statuses of up to five bundles with the getBundleStatuses method.
+func (c *JSONRPCClient) GetBundleStatuses(ctx context.Context, bundleIDs []string) (*GetBundleStatusesResult, error) {
+	var resp struct {
+		Context struct {
+			Slot uint64 `json:"slot"`
+		} `json:"context"`
+		Value []*BundleStatus `json:"value"`
+	}
+	if err := c.JitoRPCConn.RPCCallForInto(ctx, &resp, "getBundleStatuses", []interface{}{bundleIDs}); err != nil {
+		return nil, fmt.Errorf("could not get bundle statuses: %w", err)
+	}
+
+	return &GetBundleStatusesResult{
+		Slot:  resp.Context.Slot,
+		Value: resp.Value,
+	}, nil
+}
+
+// GetInflightBundleStatuses retrieves the statuses of up to five bundles submitted in the last five minutes
+// with the getInflightBundleStatuses method.
+func (c *JSONRPCClient) GetInflightBundleStatuses(
+	ctx context.Context,
+	bundleIDs []string,
+) (*GetInflightBundleStatusesResult, error) {
+	var resp struct {
+		Context struct {
+			Slot uint64 `json:"slot"`
+		} `json:"context"`
+		Value []*InflightBundleStatus `json:"value"`
+	}
+	if err := c.JitoRPCConn.RPCCallForInto(ctx, &resp, "getInflightBundleStatuses", []interface{}{bundleIDs}); err != nil {
+		return nil, fmt.Errorf("could not get inflight bundle statuses: %w", err)
+	}
+
+	return &GetInflightBundleStatusesResult{
+		Slot:  resp.Context.Slot,
+		Value: resp.Value,
+	}, nil
+}
+
+// GetTipAccounts retrieves the tip accounts with the getTipAccounts method.
+// It returns a GetTipAccountsResponse like SearcherClient.GetTipAccounts.
+func (c *JSONRPCClient) GetTipAccounts(ctx context.Context) (*jito_pb.GetTipAccountsResponse, error) {
+	var accounts []string
+	if err := c.JitoRPCConn.RPCCallForInto(ctx, &accounts, "getTipAccounts", []interface{}{}); err != nil {
+		return nil, fmt.Errorf("could not get tip accounts: %w", err)
+	}
+
+	return &jito_pb.GetTipAccountsResponse{
+		Accounts: accounts,
+	}, nil
+}
+
+// encodeTransaction serializes the transaction with the configured encoding.
+func (c *JSONRPCClient) encodeTransaction(tx *solana.Transaction) (string, error) {
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+
+	if c.encoding() == solana.EncodingBase58 {
+		return base58.Encode(data), nil
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// encoding returns the configured transaction encoding, base64 unless base58 is set.
+func (c *JSONRPCClient) encoding() solana.EncodingType {
+	if c.Encoding == solana.EncodingBase58 {
+		return solana.EncodingBase58
+	}
+	return solana.EncodingBase64
+}
@@ -0,0 +1,196 @@
+package block_engine
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	block_engine_pkg "github.com/Prophet-Solutions/jito-go/pkg/block-engine"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/mr-tron/base58"
+)
+
+// newTestJSONRPCClient returns a JSON-RPC client authenticated with uuid, whose block engine and RPC
+// node are the same fake serving the tip account.
+func newTestJSONRPCClient(t *testing.T, tipAccount solana.PublicKey, uuid string) (*JSONRPCClient, *fakeRPC) {
+	t.Helper()
+	server, rpcClient := newFakeRPC(t)
+	server.handle("getTipAccounts", func([]json.RawMessage) (interface{}, error) {
+		return []string{tipAccount.String()}, nil
+	})
+	return NewJSONRPCClient(server.url+"/", rpcClient, uuid), server
+}
+
+func TestJSONRPCClientSendBundle(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding solana.EncodingType
+		decode   func(string) ([]byte, error)
+	}{
+		{name: "base64", encoding: solana.EncodingBase64, decode: base64.StdEncoding.DecodeString},
+		{name: "base58", encoding: solana.EncodingBase58, decode: base58.Decode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tipAccount := solana.NewWallet().PublicKey()
+			client, server := newTestJSONRPCClient(t, tipAccount, "auth-uuid")
+			client.Encoding = tt.encoding
+			txs := newTestBundle(t, tipAccount)
+
+			var params []json.RawMessage
+			server.handle("sendBundle", func(p []json.RawMessage) (interface{}, error) {
+				params = p
+				return "bundle-id", nil
+			})
+
+			resp, err := client.SendBundle(context.Background(), txs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.GetUuid() != "bundle-id" {
+				t.Errorf("Uuid = %s, want bundle-id", resp.GetUuid())
+			}
+
+			path, header := server.request("sendBundle")
+			if path != block_engine_pkg.BundlesPath || header.Get("x-jito-auth") != "auth-uuid" {
+				t.Errorf("sent to %s with auth %q", path, header.Get("x-jito-auth"))
+			}
+
+			var encoded []string
+			var config struct {
+				Encoding solana.EncodingType `json:"encoding"`
+			}
+			if err = json.Unmarshal(params[0], &encoded); err != nil {
+				t.Fatal(err)
+			}
+			if err = json.Unmarshal(params[1], &config); err != nil {
+				t.Fatal(err)
+			}
+			if config.Encoding != tt.encoding || len(encoded) != len(txs) {
+				t.Fatalf("sent %d transactions as %s", len(encoded), config.Encoding)
+			}
+			data, err := tt.decode(encoded[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, _ := txs[0].MarshalBinary()
+			if string(data) != string(want) {
+				t.Error("encoded transaction does not match the bundle")
+			}
+		})
+	}
+}
+
+func TestJSONRPCClientSendBundleRejectsInvalidBundle(t *testing.T) {
+	tipAccount := solana.NewWallet().PublicKey()
+	client, server := newTestJSONRPCClient(t, tipAccount, "")
+
+	// The bundle tips an account that is not a tip account
+	if _, err := client.SendBundle(context.Background(), newTestBundle(t, solana.NewWallet().PublicKey())); err == nil {
+		t.Error("bundle without a tip sent")
+	}
+	if server.count("sendBundle") != 0 {
+		t.Error("invalid bundle reached the block engine")
+	}
+	if _, header := server.request("getTipAccounts"); header.Get("x-jito-auth") != "" {
+		t.Error("auth header sent without a UUID")
+	}
+}
+
+func TestJSONRPCClientBundleStatuses(t *testing.T) {
+	client, server := newTestJSONRPCClient(t, solana.NewWallet().PublicKey(), "")
+	server.handle("getBundleStatuses", func([]json.RawMessage) (interface{}, error) {
+		return map[string]interface{}{
+			"context": map[string]interface{}{"slot": 12},
+			"value": []interface{}{
+				map[string]interface{}{
+					"bundle_id":           "landed",
+					"transactions":        []string{"sig"},
+					"slot":                10,
+					"confirmation_status": "confirmed",
+					"err":                 map[string]interface{}{"Ok": nil},
+				},
+				map[string]interface{}{"bundle_id": "failed", "err": map[string]interface{}{"Err": "InstructionError"}},
+				nil,
+			},
+		}, nil
+	})
+	server.handle("getInflightBundleStatuses", func([]json.RawMessage) (interface{}, error) {
+		return map[string]interface{}{
+			"context": map[string]interface{}{"slot": 12},
+			"value": []interface{}{
+				map[string]interface{}{"bundle_id": "landed", "status": "Landed", "landed_slot": 10},
+				map[string]interface{}{"bundle_id": "failed", "status": "Failed", "landed_slot": nil},
+			},
+		}, nil
+	})
+
+	statuses, err := client.GetBundleStatuses(context.Background(), []string{"landed", "failed", "unknown"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statuses.Slot != 12 || len(statuses.Value) != 3 || statuses.Value[2] != nil {
+		t.Fatalf("statuses = %+v", statuses)
+	}
+	if landed := statuses.Value[0]; landed.Slot != 10 || landed.ConfirmationStatus != rpc.ConfirmationStatusConfirmed || landed.Err() != nil {
+		t.Errorf("landed bundle = %+v, err %v", landed, landed.Err())
+	}
+	if err = statuses.Value[1].Err(); !errors.Is(err, pkg.ErrTransactionFailed) {
+		t.Errorf("failed bundle: got %v, want ErrTransactionFailed", err)
+	}
+
+	inflight, err := client.GetInflightBundleStatuses(context.Background(), []string{"landed", "failed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if landed := inflight.Value[0]; landed.Status != InflightBundleLanded || landed.LandedSlot == nil || *landed.LandedSlot != 10 {
+		t.Errorf("landed bundle = %+v", landed)
+	}
+	var dropped *DroppedBundleError
+	if err = inflight.Value[1].Err(); !errors.As(err, &dropped) || dropped.BundleID != "failed" {
+		t.Errorf("failed bundle: got %v, want a *DroppedBundleError", err)
+	}
+}
+
+func TestJSONRPCClientSendBundleWithConfirmationPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  InflightBundleState
+		wantErr error
+	}{
+		{name: "landed", status: InflightBundleLanded},
+		{name: "failed", status: InflightBundleFailed, wantErr: ErrDroppedBundle},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tipAccount := solana.NewWallet().PublicKey()
+			client, server := newTestJSONRPCClient(t, tipAccount, "")
+			server.handle("sendBundle", func([]json.RawMessage) (interface{}, error) { return "bundle-id", nil })
+			server.handle("getInflightBundleStatuses", func([]json.RawMessage) (interface{}, error) {
+				return map[string]interface{}{
+					"context": map[string]interface{}{"slot": 12},
+					"value":   []interface{}{map[string]interface{}{"bundle_id": "bundle-id", "status": tt.status, "landed_slot": 10}},
+				}, nil
+			})
+			server.handle("getSignatureStatuses", signatureStatuses(rpc.ConfirmationStatusConfirmed, nil))
+
+			resp, err := client.SendBundleWithConfirmationPolicy(context.Background(), newTestBundle(t, tipAccount), fastConfirmationPolicy())
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Processed == nil || resp.Processed.Slot != 10 || len(resp.Signatures) != 1 {
+				t.Errorf("response = %+v", resp)
+			}
+		})
+	}
+}
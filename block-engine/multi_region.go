@@ -0,0 +1,321 @@
+package block_engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	bundle_pb "github.com/Prophet-Solutions/block-engine-protos/bundle"
+	jito_pb "github.com/Prophet-Solutions/block-engine-protos/searcher"
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	block_engine_pkg "github.com/Prophet-Solutions/jito-go/pkg/block-engine"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"google.golang.org/grpc"
+)
+
+// Constants for multi-region result merging
+const (
+	MultiRegionResultsCapacity = 256             // Number of merged results buffered for the consumer
+	MultiRegionDedupTTL        = 5 * time.Minute // How long a delivered result is remembered for deduplication
+)
+
+// RegionBundleResult is a bundle result together with the region that reported it.
+type RegionBundleResult struct {
+	Region string                  // Location code of the reporting block engine
+	Result *bundle_pb.BundleResult // Reported bundle result
+}
+
+// MultiRegionSearcher holds authenticated searcher clients for several block engine regions.
+// It broadcasts bundles to all of them or to the region of the upcoming leader, and merges their
+// bundle results into one stream deduplicated by bundle UUID.
+type MultiRegionSearcher struct {
+	Searchers      map[string]*SearcherClient // Searcher clients indexed by location code
+	regions        []string                   // Location codes in connection order
+	results        chan *RegionBundleResult   // Merged bundle results
+	seen           map[string]time.Time       // Delivered results indexed by bundle UUID and result kind
+	lastPrune      time.Time                  // Last time the seen results were pruned
+	firstAccepted  map[string]string          // Region that first reported acceptance, indexed by bundle UUID
+	acceptanceWins map[string]uint64          // Number of first acceptances indexed by region
+	dropped        atomic.Uint64              // Merged results dropped because the consumer was not draining
+	cancel         context.CancelFunc         // Cancels the context the searcher clients run with
+	closed         bool                       // Whether Close was called, no result is merged afterwards
+	merging        sync.WaitGroup             // Merges in progress, waited for before closing results
+	closeOnce      sync.Once                  // Guards closing results
+	mu             sync.Mutex                 // Mutex for synchronizing result merging
+}
+
+// newRegionSearcherClient creates the searcher client of a region, replaced in tests.
+var newRegionSearcherClient = NewSearcherClient
+
+// NewMultiRegionSearcher connects and authenticates a searcher client for each location code, e.g. "AMS" or "NYC".
+// It fails if any region cannot be connected, closing the clients of the regions already connected.
+// Close releases the clients.
+func NewMultiRegionSearcher(
+	ctx context.Context,
+	regions []string,
+	jitoRPCClient, rpcClient *rpc.Client,
+	signer pkg.Signer,
+	opts ...grpc.DialOption,
+) (*MultiRegionSearcher, error) {
+	if len(regions) == 0 {
+		return nil, errors.New("at least one region is required")
+	}
+
+	// The clients run with a derived context so that they can be stopped together
+	ctx, cancel := context.WithCancel(ctx)
+
+	m := &MultiRegionSearcher{
+		Searchers:      make(map[string]*SearcherClient, len(regions)),
+		results:        make(chan *RegionBundleResult, MultiRegionResultsCapacity),
+		seen:           make(map[string]time.Time),
+		firstAccepted:  make(map[string]string),
+		acceptanceWins: make(map[string]uint64),
+		cancel:         cancel,
+	}
+
+	for _, region := range regions {
+		endpoint := block_engine_pkg.GetEndpoint(region)
+		if endpoint == "" {
+			m.Close()
+			return nil, fmt.Errorf("unknown region %s", region)
+		}
+		if _, ok := m.Searchers[region]; ok {
+			continue
+		}
+
+		searcher, err := newRegionSearcherClient(ctx, endpoint, jitoRPCClient, rpcClient, signer, opts...)
+		if err != nil {
+			m.Close()
+			return nil, fmt.Errorf("could not create searcher client for region %s: %w", region, err)
+		}
+		searcher.Region = region
+
+		// Merge the region's bundle results into the shared stream
+		region := region
+		searcher.BundleResultDispatcher.AddListener(func(result *bundle_pb.BundleResult) {
+			m.merge(region, result)
+		})
+
+		m.Searchers[region] = searcher
+		m.regions = append(m.regions, region)
+	}
+
+	return m, nil
+}
+
+// Close stops the searcher clients of every region and closes their gRPC connections.
+// The Results channel is closed once the results being merged are delivered, so consumers ranging
+// over it return. Close may be called more than once.
+func (m *MultiRegionSearcher) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+
+	m.cancel()
+
+	var errs []error
+	for _, region := range m.regions {
+		if conn := m.Searchers[region].GRPCConn; conn != nil {
+			if err := conn.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("region %s: %w", region, err))
+			}
+		}
+	}
+
+	m.merging.Wait()
+	m.closeOnce.Do(func() { close(m.results) })
+
+	return errors.Join(errs...)
+}
+
+// Regions returns the location codes of the connected regions.
+func (m *MultiRegionSearcher) Regions() []string {
+	return append([]string(nil), m.regions...)
+}
+
+// Broadcast sends the bundle to every region concurrently.
+// It returns the responses of the regions that accepted the request and the joined errors of the others.
+func (m *MultiRegionSearcher) Broadcast(
+	ctx context.Context,
+	transactions []*solana.Transaction,
+	opts ...grpc.CallOption,
+) (map[string]*jito_pb.SendBundleResponse, error) {
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		responses = make(map[string]*jito_pb.SendBundleResponse, len(m.regions))
+		errs      []error
+	)
+
+	for _, region := range m.regions {
+		wg.Add(1)
+		go func(region string) {
+			defer wg.Done()
+
+			resp, err := m.Searchers[region].SendBundle(ctx, transactions, opts...)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("region %s: %w", region, err))
+				return
+			}
+			responses[region] = resp
+		}(region)
+	}
+	wg.Wait()
+
+	return responses, errors.Join(errs...)
+}
+
+// SendToLeaderRegion sends the bundle to the region the upcoming Jito leader is connected to.
+// Every region is asked for its next scheduled leader, and the bundle goes to the region whose
+// leader comes first. Regions that cannot be queried are skipped.
+// It returns the location code of that region along with the response.
+func (m *MultiRegionSearcher) SendToLeaderRegion(
+	ctx context.Context,
+	transactions []*solana.Transaction,
+	opts ...grpc.CallOption,
+) (string, *jito_pb.SendBundleResponse, error) {
+	region, err := m.leaderRegion(ctx, opts...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	resp, err := m.Searchers[region].SendBundle(ctx, transactions, opts...)
+	if err != nil {
+		return region, nil, err
+	}
+
+	return region, resp, nil
+}
+
+// leaderRegion queries the next scheduled leader of every region concurrently and returns the region
+// whose leader has the lowest slot. A block engine reports the next leader connected to its own region.
+func (m *MultiRegionSearcher) leaderRegion(ctx context.Context, opts ...grpc.CallOption) (string, error) {
+	leaders := make([]*jito_pb.NextScheduledLeaderResponse, len(m.regions))
+	errs := make([]error, len(m.regions))
+
+	var wg sync.WaitGroup
+	for i, region := range m.regions {
+		wg.Add(1)
+		go func(i int, region string) {
+			defer wg.Done()
+
+			leaders[i], errs[i] = m.Searchers[region].GetNextScheduledLeader(ctx, nil, opts...)
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("region %s: %w", region, errs[i])
+			}
+		}(i, region)
+	}
+	wg.Wait()
+
+	best := -1
+	for i, leader := range leaders {
+		if errs[i] != nil || leader == nil {
+			continue
+		}
+		if best < 0 || leader.GetNextLeaderSlot() < leaders[best].GetNextLeaderSlot() {
+			best = i
+		}
+	}
+	if best < 0 {
+		return "", fmt.Errorf("could not get next scheduled leader: %w", errors.Join(errs...))
+	}
+	return m.regions[best], nil
+}
+
+// Results returns the merged bundle results of every region. Each bundle UUID yields at most one
+// result of each kind (accepted, rejected, processed, finalized, dropped), from the first region
+// reporting it. Results are dropped if the channel is not drained, see DroppedResults.
+// The channel is closed by Close.
+func (m *MultiRegionSearcher) Results() <-chan *RegionBundleResult {
+	return m.results
+}
+
+// DroppedResults returns the number of merged results dropped because the Results channel was not drained.
+func (m *MultiRegionSearcher) DroppedResults() uint64 {
+	return m.dropped.Load()
+}
+
+// FirstAcceptedRegion returns the region that first reported the acceptance of the bundle.
+func (m *MultiRegionSearcher) FirstAcceptedRegion(uuid string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	region, ok := m.firstAccepted[uuid]
+	return region, ok
+}
+
+// AcceptanceWins returns, for each region, how many bundles it was the first to report accepted.
+func (m *MultiRegionSearcher) AcceptanceWins() map[string]uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wins := make(map[string]uint64, len(m.acceptanceWins))
+	for region, count := range m.acceptanceWins {
+		wins[region] = count
+	}
+	return wins
+}
+
+// merge forwards a region's bundle result unless another region already reported the same kind of result.
+func (m *MultiRegionSearcher) merge(region string, result *bundle_pb.BundleResult) {
+	now := time.Now()
+	uuid := result.GetBundleId()
+	key := fmt.Sprintf("%s/%T", uuid, result.Result)
+
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return
+	}
+	m.merging.Add(1)
+	defer m.merging.Done()
+
+	m.pruneSeen(now)
+	if _, ok := m.seen[key]; ok {
+		m.mu.Unlock()
+		return
+	}
+	m.seen[key] = now
+
+	// Record the region that reported acceptance first
+	if _, ok := result.Result.(*bundle_pb.BundleResult_Accepted); ok {
+		m.firstAccepted[uuid] = region
+		m.acceptanceWins[region]++
+	}
+	m.mu.Unlock()
+
+	select {
+	case m.results <- &RegionBundleResult{Region: region, Result: result}:
+	default:
+		m.dropped.Add(1)
+		if searcher, ok := m.Searchers[region]; ok {
+			searcher.Events.Publish(pkg.Event{Kind: pkg.EventResultDropped, Source: region, Payload: result})
+		}
+	}
+}
+
+// pruneSeen forgets delivered results older than MultiRegionDedupTTL, at most once a minute.
+func (m *MultiRegionSearcher) pruneSeen(now time.Time) {
+	if now.Sub(m.lastPrune) < time.Minute {
+		return
+	}
+	m.lastPrune = now
+
+	for key, seenAt := range m.seen {
+		if now.Sub(seenAt) > MultiRegionDedupTTL {
+			delete(m.seen, key)
+		}
+	}
+	for uuid := range m.firstAccepted {
+		if _, ok := m.seen[fmt.Sprintf("%s/%T", uuid, &bundle_pb.BundleResult_Accepted{})]; !ok {
+			delete(m.firstAccepted, uuid)
+		}
+	}
+}
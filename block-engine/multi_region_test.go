@@ -0,0 +1,236 @@
+package block_engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	bundle_pb "github.com/Prophet-Solutions/block-engine-protos/bundle"
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	block_engine_pkg "github.com/Prophet-Solutions/jito-go/pkg/block-engine"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestNewMultiRegionSearcherClosesConnectedRegionsOnFailure(t *testing.T) {
+	var (
+		clientCtx context.Context
+		conn      *grpc.ClientConn
+	)
+	newRegionSearcherClient = func(
+		ctx context.Context,
+		grpcAddr string,
+		_, _ *rpc.Client,
+		_ pkg.Signer,
+		_ ...grpc.DialOption,
+	) (*SearcherClient, error) {
+		if grpcAddr != block_engine_pkg.GetEndpoint("AMS") {
+			return nil, errors.New("connection refused")
+		}
+
+		var err error
+		if conn, err = grpc.NewClient("passthrough:///ams", grpc.WithTransportCredentials(insecure.NewCredentials())); err != nil {
+			t.Fatal(err)
+		}
+		clientCtx = ctx
+
+		client := newTestSearcher(t).SearcherClient
+		client.GRPCConn = conn
+		return client, nil
+	}
+	t.Cleanup(func() { newRegionSearcherClient = NewSearcherClient })
+
+	if _, err := NewMultiRegionSearcher(context.Background(), []string{"AMS", "NYC"}, nil, nil, nil); err == nil {
+		t.Fatal("NewMultiRegionSearcher() succeeded with a failing region")
+	}
+
+	if clientCtx == nil || clientCtx.Err() == nil {
+		t.Error("context of the connected region not cancelled")
+	}
+	if conn == nil || conn.GetState() != connectivity.Shutdown {
+		t.Error("connection of the connected region not closed")
+	}
+}
+
+func TestMultiRegionSearcherMergesResults(t *testing.T) {
+	m := &MultiRegionSearcher{
+		results:        make(chan *RegionBundleResult, MultiRegionResultsCapacity),
+		seen:           make(map[string]time.Time),
+		firstAccepted:  make(map[string]string),
+		acceptanceWins: make(map[string]uint64),
+	}
+
+	m.merge("NYC", acceptedResult("uuid"))
+	m.merge("AMS", acceptedResult("uuid"))
+	m.merge("AMS", &bundle_pb.BundleResult{BundleId: "uuid", Result: &bundle_pb.BundleResult_Finalized{Finalized: &bundle_pb.Finalized{}}})
+
+	if got := len(m.Results()); got != 2 {
+		t.Fatalf("got %d merged results, want 2", got)
+	}
+	if first := <-m.Results(); first.Region != "NYC" {
+		t.Errorf("accepted result from %s, want NYC", first.Region)
+	}
+	if region, _ := m.FirstAcceptedRegion("uuid"); region != "NYC" {
+		t.Errorf("FirstAcceptedRegion() = %s, want NYC", region)
+	}
+	if wins := m.AcceptanceWins(); wins["NYC"] != 1 || wins["AMS"] != 0 {
+		t.Errorf("AcceptanceWins() = %v", wins)
+	}
+}
+
+func TestMultiRegionSearcherBroadcast(t *testing.T) {
+	tipAccount := solana.NewWallet().PublicKey()
+	nyc, ams := newTestSearcher(t, tipAccount), newTestSearcher(t, tipAccount)
+	ams.service.sendErr = errors.New("unavailable")
+	m := &MultiRegionSearcher{
+		Searchers: map[string]*SearcherClient{"NYC": nyc.SearcherClient, "AMS": ams.SearcherClient},
+		regions:   []string{"NYC", "AMS"},
+	}
+
+	responses, err := m.Broadcast(context.Background(), newTestBundle(t, tipAccount))
+	if err == nil {
+		t.Error("Broadcast() did not report the failing region")
+	}
+	if len(responses) != 1 || responses["NYC"] == nil {
+		t.Errorf("responses = %v, want NYC only", responses)
+	}
+}
+
+func TestMultiRegionSearcherSendToLeaderRegion(t *testing.T) {
+	tipAccount := solana.NewWallet().PublicKey()
+	unavailable := errors.New("unavailable")
+
+	tests := []struct {
+		name     string
+		setup    func(nyc, ams *testSearcher)
+		want     string
+		wantFail bool
+	}{
+		{
+			name: "leader in the second region",
+			setup: func(nyc, ams *testSearcher) {
+				nyc.service.setLeader(100, 150)
+				ams.service.setLeader(100, 105)
+			},
+			want: "AMS",
+		},
+		{
+			name: "leader in the first region",
+			setup: func(nyc, ams *testSearcher) {
+				nyc.service.setLeader(100, 104)
+				ams.service.setLeader(100, 105)
+			},
+			want: "NYC",
+		},
+		{
+			name: "failing region skipped",
+			setup: func(nyc, ams *testSearcher) {
+				nyc.service.leaderErr = unavailable
+				ams.service.setLeader(100, 180)
+			},
+			want: "AMS",
+		},
+		{
+			name: "every region failing",
+			setup: func(nyc, ams *testSearcher) {
+				nyc.service.leaderErr = unavailable
+				ams.service.leaderErr = unavailable
+			},
+			wantFail: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nyc, ams := newTestSearcher(t, tipAccount), newTestSearcher(t, tipAccount)
+			tt.setup(nyc, ams)
+			m := &MultiRegionSearcher{
+				Searchers: map[string]*SearcherClient{"NYC": nyc.SearcherClient, "AMS": ams.SearcherClient},
+				regions:   []string{"NYC", "AMS"},
+			}
+
+			region, resp, err := m.SendToLeaderRegion(context.Background(), newTestBundle(t, tipAccount))
+			if tt.wantFail {
+				if !errors.Is(err, unavailable) || nyc.service.sent()+ams.service.sent() != 0 {
+					t.Errorf("SendToLeaderRegion() = %v, sent %d bundles, want a failure", err, nyc.service.sent()+ams.service.sent())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if region != tt.want || resp.GetUuid() == "" {
+				t.Errorf("SendToLeaderRegion() = %s, %v, want %s", region, resp, tt.want)
+			}
+			for name, searcher := range map[string]*testSearcher{"NYC": nyc, "AMS": ams} {
+				want := 0
+				if name == tt.want {
+					want = 1
+				}
+				if searcher.service.sent() != want {
+					t.Errorf("region %s received %d bundles, want %d", name, searcher.service.sent(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestMultiRegionSearcherClose(t *testing.T) {
+	_, cancel := context.WithCancel(context.Background())
+	nyc := newTestSearcher(t)
+	nyc.Events = pkg.NewEventBus()
+	events := nyc.Events.Subscribe(1, pkg.EventResultDropped)
+	m := &MultiRegionSearcher{
+		Searchers:      map[string]*SearcherClient{"NYC": nyc.SearcherClient},
+		results:        make(chan *RegionBundleResult, 1),
+		seen:           make(map[string]time.Time),
+		firstAccepted:  make(map[string]string),
+		acceptanceWins: make(map[string]uint64),
+		cancel:         cancel,
+	}
+
+	// Results are dropped and counted once the consumer falls behind
+	m.merge("NYC", acceptedResult("first"))
+	m.merge("NYC", acceptedResult("second"))
+	if got := m.DroppedResults(); got != 1 {
+		t.Errorf("DroppedResults() = %d, want 1", got)
+	}
+	select {
+	case event := <-events.C:
+		if result, ok := event.Payload.(*bundle_pb.BundleResult); !ok || event.Source != "NYC" || result.GetBundleId() != "second" {
+			t.Errorf("dropped result event = %v", event)
+		}
+	default:
+		t.Error("dropped result not published")
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	m.merge("NYC", acceptedResult("late"))
+
+	// Ranging over the results ends once the buffered result is read
+	done := make(chan []string)
+	go func() {
+		var uuids []string
+		for result := range m.Results() {
+			uuids = append(uuids, result.Result.GetBundleId())
+		}
+		done <- uuids
+	}()
+	select {
+	case uuids := <-done:
+		if len(uuids) != 1 || uuids[0] != "first" {
+			t.Errorf("results after Close = %v, want [first]", uuids)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Results() not closed by Close")
+	}
+}
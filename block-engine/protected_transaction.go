@@ -0,0 +1,288 @@
+package block_engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	jito_pb "github.com/Prophet-Solutions/block-engine-protos/searcher"
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	block_engine_pkg "github.com/Prophet-Solutions/jito-go/pkg/block-engine"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"google.golang.org/grpc"
+)
+
+// ErrTipInvalidatesSignature is returned when adding a tip would drop the signature of a signer
+// that is not part of ProtectedTransactionOpts.Signers, such as an external or offline signer.
+var ErrTipInvalidatesSignature = errors.New("adding the tip invalidates the signature of a signer missing from the tip signers")
+
+// ProtectedTransactionOpts configures SendProtectedTransaction.
+type ProtectedTransactionOpts struct {
+	BundleOnly  bool              // Only land the transaction inside a bundle, reverting it if it fails
+	TipLamports uint64            // Tip added to the transaction if it does not tip a tip account yet
+	TipAccount  *solana.PublicKey // Tip account of the added tip, selected by the client's TipAccounts if nil
+	Signers     []pkg.Signer      // Signers re-signing the transaction after the tip is added, all its signers are required
+}
+
+// ProtectedTransactionResponse is the response of SendProtectedTransaction.
+type ProtectedTransactionResponse struct {
+	Signature solana.Signature    // Signature of the sent transaction
+	BundleID  string              // ID of the bundle the transaction was wrapped in, if reported
+	Tipped    bool                // Whether a tip instruction was added to the transaction
+	Processed *BundleProcessed    // Set once the bundle is reported landed, with confirmation only
+	Tx        *solana.Transaction // Transaction as sent, including the added tip
+}
+
+// SendProtectedTransactionWithConfirmation sends a single transaction with SendProtectedTransaction and waits
+// for its signature to reach the policy commitment, like SendBundleWithConfirmationPolicy.
+func (c *JSONRPCClient) SendProtectedTransactionWithConfirmation(
+	ctx context.Context,
+	tx *solana.Transaction,
+	opts ProtectedTransactionOpts,
+	policy ConfirmationPolicy,
+) (*ProtectedTransactionResponse, error) {
+	resp, err := c.SendProtectedTransaction(ctx, tx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Processed, err = c.waitForBundle(ctx, resp.BundleID, []*solana.Transaction{resp.Tx}, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// SendProtectedTransaction sends a single transaction with Jito's sendTransaction method.
+// If the transaction does not tip a tip account and opts.TipLamports is set, a tip transfer from the
+// fee payer is appended and the transaction is re-signed with opts.Signers. The transaction then goes
+// through the same pre-flight checks as a bundle. With opts.BundleOnly the block engine only lands
+// the transaction inside a bundle, so it is reverted rather than landed if it fails.
+func (c *JSONRPCClient) SendProtectedTransaction(
+	ctx context.Context,
+	tx *solana.Transaction,
+	opts ProtectedTransactionOpts,
+) (*ProtectedTransactionResponse, error) {
+	tipAccounts, err := c.TipAccounts.Accounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := newProtectedTransactionResponse(ctx, c.TipAccounts, tipAccounts, tx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Run the pre-flight checks on the transaction as a single-transaction bundle
+	if err = pkg.ValidateBundle([]*solana.Transaction{resp.Tx}, tipAccounts).Err(); err != nil {
+		return nil, err
+	}
+	encoded, err := c.encodeTransaction(resp.Tx)
+	if err != nil {
+		return nil, fmt.Errorf("could not serialize transaction: %w", err)
+	}
+
+	if resp.BundleID, err = c.sendTransaction(ctx, encoded, opts.BundleOnly); err != nil {
+		return nil, err
+	}
+	if resp.BundleID != "" {
+		c.BundleTracker.Submitted([]*solana.Transaction{resp.Tx}, resp.BundleID)
+	}
+
+	return resp, nil
+}
+
+// sendTransaction calls the sendTransaction method of the transactions API and returns the bundle ID
+// reported in the x-bundle-id header, if any.
+func (c *JSONRPCClient) sendTransaction(ctx context.Context, encoded string, bundleOnly bool) (string, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "sendTransaction",
+		"params": []interface{}{
+			encoded,
+			map[string]solana.EncodingType{"encoding": c.encoding()},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	url := c.BlockEngineURL + block_engine_pkg.TransactionsPath
+	if bundleOnly {
+		url += "?bundleOnly=true"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.UUID != "" {
+		req.Header.Set("x-jito-auth", c.UUID)
+	}
+
+	httpResp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("could not send transaction: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return "", fmt.Errorf("could not read sendTransaction response: %w", err)
+	}
+
+	var rpcResp struct {
+		Result string `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err = json.Unmarshal(data, &rpcResp); err != nil {
+		return "", fmt.Errorf("could not decode sendTransaction response (status %d): %w", httpResp.StatusCode, err)
+	}
+	if rpcResp.Error != nil {
+		return "", fmt.Errorf("could not send transaction: %s (code %d)", rpcResp.Error.Message, rpcResp.Error.Code)
+	}
+
+	return httpResp.Header.Get("x-bundle-id"), nil
+}
+
+// SendProtectedTransactionWithConfirmation sends a single transaction with SendProtectedTransaction and waits
+// for its bundle result and signature like SendBundleWithConfirmationPolicy.
+func (c *SearcherClient) SendProtectedTransactionWithConfirmation(
+	ctx context.Context,
+	tx *solana.Transaction,
+	opts ProtectedTransactionOpts,
+	policy ConfirmationPolicy,
+	callOpts ...grpc.CallOption,
+) (*ProtectedTransactionResponse, error) {
+	resp, err := c.SendProtectedTransaction(ctx, tx, opts, callOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	bundleResp, err := c.confirmBundle(ctx, &jito_pb.SendBundleResponse{Uuid: resp.BundleID}, []*solana.Transaction{resp.Tx}, policy)
+	if err != nil {
+		return nil, err
+	}
+	resp.Processed = bundleResp.Processed
+
+	return resp, nil
+}
+
+// SendProtectedTransaction sends a single transaction as a one-transaction bundle through SendBundle.
+// Tips are added as in JSONRPCClient.SendProtectedTransaction. A bundle lands atomically, so the
+// transaction is always bundle-only and opts.BundleOnly has no effect. BundleID is the bundle UUID.
+func (c *SearcherClient) SendProtectedTransaction(
+	ctx context.Context,
+	tx *solana.Transaction,
+	opts ProtectedTransactionOpts,
+	callOpts ...grpc.CallOption,
+) (*ProtectedTransactionResponse, error) {
+	tipAccounts, err := c.TipAccounts.Accounts(ctx, callOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := newProtectedTransactionResponse(ctx, c.TipAccounts, tipAccounts, tx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	bundleResp, err := c.SendBundle(ctx, []*solana.Transaction{resp.Tx}, callOpts...)
+	if err != nil {
+		return nil, err
+	}
+	resp.BundleID = bundleResp.GetUuid()
+
+	return resp, nil
+}
+
+// newProtectedTransactionResponse returns the response of a protected transaction about to be sent.
+// If the transaction does not tip one of the tip accounts and opts.TipLamports is set, a tip is added.
+func newProtectedTransactionResponse(
+	ctx context.Context,
+	manager *TipAccountManager,
+	tipAccounts []solana.PublicKey,
+	tx *solana.Transaction,
+	opts ProtectedTransactionOpts,
+) (*ProtectedTransactionResponse, error) {
+	resp := &ProtectedTransactionResponse{Tx: tx}
+
+	// Place the tip inside the transaction if it does not tip yet
+	if opts.TipLamports > 0 && pkg.ExtractTipLamports([]*solana.Transaction{tx}, tipAccounts) == 0 {
+		var err error
+		if resp.Tx, err = addTip(ctx, manager, tx, opts); err != nil {
+			return nil, err
+		}
+		resp.Tipped = true
+	}
+
+	if len(resp.Tx.Signatures) > 0 {
+		resp.Signature = resp.Tx.Signatures[0]
+	}
+	return resp, nil
+}
+
+// addTip returns a copy of the transaction with a tip transfer from the fee payer appended, re-signed with the signers.
+// The tip changes the message, so every existing signature is dropped: it fails with ErrTipInvalidatesSignature
+// rather than returning a transaction missing the signature of a signer that is not in opts.Signers.
+func addTip(
+	ctx context.Context,
+	manager *TipAccountManager,
+	tx *solana.Transaction,
+	opts ProtectedTransactionOpts,
+) (*solana.Transaction, error) {
+	if len(tx.Message.AccountKeys) == 0 {
+		return nil, errors.New("transaction has no fee payer")
+	}
+	feePayer := tx.Message.AccountKeys[0]
+
+	tipAccount := opts.TipAccount
+	if tipAccount == nil {
+		account, err := manager.Next(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("could not select tip account: %w", err)
+		}
+		tipAccount = &account
+	}
+
+	instructions, err := pkg.DecompileInstructions(tx)
+	if err != nil {
+		return nil, fmt.Errorf("could not add tip: %w", err)
+	}
+	instructions = append(instructions, system.NewTransferInstruction(opts.TipLamports, feePayer, *tipAccount).Build())
+
+	tipped, err := solana.NewTransaction(instructions, tx.Message.RecentBlockhash, solana.TransactionPayer(feePayer))
+	if err != nil {
+		return nil, fmt.Errorf("could not add tip: %w", err)
+	}
+	if !pkg.ValidateTransaction(tipped) {
+		return nil, fmt.Errorf("transaction exceeds %d bytes once tipped", pkg.MaxTransactionSize)
+	}
+
+	// Every signer must be able to sign the tipped message again
+	signers := make(map[solana.PublicKey]struct{}, len(opts.Signers))
+	for _, signer := range opts.Signers {
+		signers[signer.PublicKey()] = struct{}{}
+	}
+	for _, key := range tipped.Message.AccountKeys[:tipped.Message.Header.NumRequiredSignatures] {
+		if _, ok := signers[key]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrTipInvalidatesSignature, key)
+		}
+	}
+	if err = pkg.PartialSignTransaction(tipped, opts.Signers); err != nil {
+		return nil, fmt.Errorf("could not sign tipped transaction: %w", err)
+	}
+
+	return tipped, nil
+}
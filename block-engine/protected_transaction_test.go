@@ -0,0 +1,116 @@
+package block_engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	block_engine_pkg "github.com/Prophet-Solutions/jito-go/pkg/block-engine"
+	"github.com/gagliardetto/solana-go"
+)
+
+// newSignedTransaction returns a transaction paid by the first signer, transferring from every signer.
+func newSignedTransaction(t *testing.T, signers ...pkg.Signer) *solana.Transaction {
+	t.Helper()
+	instructions := make([]solana.Instruction, 0, len(signers))
+	for _, signer := range signers {
+		instructions = append(instructions, transferInstruction(signer.PublicKey(), 1))
+	}
+	tx, err := solana.NewTransaction(instructions, solana.Hash{1}, solana.TransactionPayer(signers[0].PublicKey()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = pkg.PartialSignTransaction(tx, signers); err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+func TestJSONRPCClientSendProtectedTransaction(t *testing.T) {
+	tipAccount := solana.NewWallet().PublicKey()
+	client, server := newTestJSONRPCClient(t, tipAccount, "")
+	server.header.Set("x-bundle-id", "bundle-id")
+	server.handle("sendTransaction", func([]json.RawMessage) (interface{}, error) { return "signature", nil })
+	payer := newTestSigner(t)
+
+	resp, err := client.SendProtectedTransaction(context.Background(), newSignedTransaction(t, payer), ProtectedTransactionOpts{
+		BundleOnly:  true,
+		TipLamports: 10_000,
+		Signers:     []pkg.Signer{payer},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !resp.Tipped || resp.BundleID != "bundle-id" {
+		t.Errorf("response = %+v", resp)
+	}
+	if tip := pkg.ExtractTipLamports([]*solana.Transaction{resp.Tx}, []solana.PublicKey{tipAccount}); tip != 10_000 {
+		t.Errorf("sent a %d lamports tip, want 10000", tip)
+	}
+	if resp.Signature != resp.Tx.Signatures[0] || resp.Tx.VerifySignatures() != nil {
+		t.Error("tipped transaction not re-signed")
+	}
+	if uri, _ := server.request("sendTransaction"); uri != block_engine_pkg.TransactionsPath+"?bundleOnly=true" {
+		t.Errorf("sent to %s", uri)
+	}
+}
+
+func TestSendProtectedTransactionKeepsTippedTransaction(t *testing.T) {
+	tipAccount := solana.NewWallet().PublicKey()
+	client, server := newTestJSONRPCClient(t, tipAccount, "")
+	server.handle("sendTransaction", func([]json.RawMessage) (interface{}, error) { return "signature", nil })
+	tx := newTestBundle(t, tipAccount)[0]
+
+	resp, err := client.SendProtectedTransaction(context.Background(), tx, ProtectedTransactionOpts{TipLamports: 10_000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Tipped || resp.Tx != tx || resp.Signature != tx.Signatures[0] {
+		t.Errorf("already tipped transaction modified: %+v", resp)
+	}
+}
+
+func TestSendProtectedTransactionRefusesToDropSignatures(t *testing.T) {
+	tipAccount := solana.NewWallet().PublicKey()
+	client, server := newTestJSONRPCClient(t, tipAccount, "")
+	payer, external := newTestSigner(t), newTestSigner(t)
+	tx := newSignedTransaction(t, payer, external)
+
+	_, err := client.SendProtectedTransaction(context.Background(), tx, ProtectedTransactionOpts{
+		TipLamports: 10_000,
+		Signers:     []pkg.Signer{payer},
+	})
+	if !errors.Is(err, ErrTipInvalidatesSignature) {
+		t.Errorf("got %v, want ErrTipInvalidatesSignature", err)
+	}
+	if server.count("sendTransaction") != 0 {
+		t.Error("transaction sent without the external signature")
+	}
+	if tx.VerifySignatures() != nil {
+		t.Error("original transaction modified")
+	}
+}
+
+func TestSearcherClientSendProtectedTransaction(t *testing.T) {
+	tipAccount := solana.NewWallet().PublicKey()
+	client := newTestSearcher(t, tipAccount)
+	payer := newTestSigner(t)
+
+	resp, err := client.SendProtectedTransaction(context.Background(), newSignedTransaction(t, payer), ProtectedTransactionOpts{
+		TipLamports: 10_000,
+		Signers:     []pkg.Signer{payer},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !resp.Tipped || resp.BundleID != "bundle-1" || resp.Signature != resp.Tx.Signatures[0] {
+		t.Errorf("response = %+v", resp)
+	}
+	if client.service.sent() != 1 || len(client.service.bundles[0].Bundle.Packets) != 1 {
+		t.Error("transaction not sent as a one-transaction bundle")
+	}
+}
@@ -0,0 +1,361 @@
+package block_engine
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Constants for the default rate limiter configuration
+const (
+	DefaultRateLimitRate          = 5               // Calls per second allowed for each method and region
+	DefaultRateLimitBurst         = 5               // Calls allowed at once for each method and region
+	DefaultRateLimitQueueCapacity = 100             // Number of calls waiting for each method and region
+	DefaultRateLimitMaxQueueWait  = 2 * time.Second // Time after which a waiting call is dropped
+)
+
+// Errors reported by the rate limiter
+var (
+	ErrRateLimitQueueFull = errors.New("rate limit queue full")
+	ErrRateLimitStale     = errors.New("call waited too long for the rate limit")
+)
+
+// RateLimit is a token bucket limit.
+type RateLimit struct {
+	Rate  float64 // Calls per second, 0 for no limit
+	Burst int     // Calls allowed at once
+}
+
+// RateLimitKey identifies the bucket of a searcher method in a region.
+// An empty field in a configured key matches every region or method.
+type RateLimitKey struct {
+	Region string // Location code of the block engine
+	Method string // Searcher method, e.g. "SendBundle"
+}
+
+// RateLimiterConfig configures a RateLimiter.
+type RateLimiterConfig struct {
+	Limits        map[RateLimitKey]RateLimit // Limits by region and method
+	DefaultLimit  RateLimit                  // Limit of calls without a matching entry in Limits
+	QueueCapacity int                        // Number of calls waiting for each method and region
+	MaxQueueWait  time.Duration              // Time after which a waiting call is dropped, 0 to never drop
+}
+
+// DefaultRateLimiterConfig returns the default RateLimiter configuration.
+func DefaultRateLimiterConfig() RateLimiterConfig {
+	return RateLimiterConfig{
+		DefaultLimit: RateLimit{
+			Rate:  DefaultRateLimitRate,
+			Burst: DefaultRateLimitBurst,
+		},
+		QueueCapacity: DefaultRateLimitQueueCapacity,
+		MaxQueueWait:  DefaultRateLimitMaxQueueWait,
+	}
+}
+
+// RateLimiterMetrics are the counters of a rate limit bucket.
+type RateLimiterMetrics struct {
+	QueueDepth      int           // Calls currently waiting
+	Throttled       uint64        // Calls that had to wait for a token
+	Dropped         uint64        // Calls dropped for waiting longer than MaxQueueWait
+	Rejected        uint64        // Calls rejected or evicted because the queue was full
+	ServerThrottled uint64        // Calls the block engine answered with ResourceExhausted
+	ThrottleTime    time.Duration // Total time calls waited for a token
+	MaxThrottleTime time.Duration // Longest time a call waited for a token
+}
+
+// RateLimiter is a client-side token bucket limiter for searcher calls, with one bucket per method and region.
+// Calls over the limit wait in a bounded priority queue where higher priorities, e.g. higher tips, go first.
+// A RateLimiter can be shared by the searcher clients of several regions.
+type RateLimiter struct {
+	config  RateLimiterConfig            // Limiter configuration
+	buckets map[RateLimitKey]*rateBucket // Buckets indexed by region and method
+	mu      sync.Mutex                   // Mutex for synchronizing the buckets
+}
+
+// rateBucket is the token bucket and queue of a method in a region.
+type rateBucket struct {
+	limit     RateLimit
+	tokens    float64
+	updatedAt time.Time
+	queue     rateQueue
+	draining  bool
+	metrics   RateLimiterMetrics
+}
+
+// rateWaiter is a call waiting for a token.
+type rateWaiter struct {
+	priority   uint64
+	enqueuedAt time.Time
+	ready      chan error
+	index      int
+}
+
+// NewRateLimiter creates a RateLimiter with the given configuration.
+func NewRateLimiter(config RateLimiterConfig) *RateLimiter {
+	if config.QueueCapacity <= 0 {
+		config.QueueCapacity = DefaultRateLimitQueueCapacity
+	}
+
+	return &RateLimiter{
+		config:  config,
+		buckets: make(map[RateLimitKey]*rateBucket),
+	}
+}
+
+// Wait blocks until the call may be made. It returns ErrRateLimitQueueFull if the queue is full of calls with
+// a higher priority, ErrRateLimitStale if the call waited longer than MaxQueueWait, or the context error.
+func (l *RateLimiter) Wait(ctx context.Context, region, method string, priority uint64) error {
+	key := RateLimitKey{Region: region, Method: method}
+
+	l.mu.Lock()
+	b := l.bucket(key)
+	if b == nil {
+		l.mu.Unlock()
+		return nil
+	}
+
+	now := time.Now()
+	b.refill(now)
+	if b.queue.Len() == 0 && b.tokens >= 1 {
+		b.tokens--
+		l.mu.Unlock()
+		return nil
+	}
+
+	// Make room by evicting the lowest priority call, if it is lower than this one
+	if b.queue.Len() >= l.config.QueueCapacity {
+		lowest := b.queue.lowest()
+		if lowest.priority >= priority {
+			b.metrics.Rejected++
+			l.mu.Unlock()
+			return ErrRateLimitQueueFull
+		}
+		heap.Remove(&b.queue, lowest.index)
+		lowest.ready <- ErrRateLimitQueueFull
+		b.metrics.Rejected++
+	}
+
+	w := &rateWaiter{
+		priority:   priority,
+		enqueuedAt: now,
+		ready:      make(chan error, 1),
+	}
+	heap.Push(&b.queue, w)
+	b.metrics.Throttled++
+	if !b.draining {
+		b.draining = true
+		go l.drain(b)
+	}
+	l.mu.Unlock()
+
+	select {
+	case err := <-w.ready:
+		return err
+	case <-ctx.Done():
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if w.index >= 0 {
+			heap.Remove(&b.queue, w.index)
+			return ctx.Err()
+		}
+
+		// The call was released while the context was cancelled, give its token back as it is not made
+		select {
+		case err := <-w.ready:
+			if err == nil {
+				b.tokens++
+			}
+		default:
+		}
+		return ctx.Err()
+	}
+}
+
+// Penalize empties the bucket of a method in a region, e.g. after the block engine answered ResourceExhausted.
+func (l *RateLimiter) Penalize(region, method string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if b := l.bucket(RateLimitKey{Region: region, Method: method}); b != nil {
+		b.tokens = 0
+		b.updatedAt = time.Now()
+		b.metrics.ServerThrottled++
+	}
+}
+
+// Metrics returns the counters of every bucket.
+func (l *RateLimiter) Metrics() map[RateLimitKey]RateLimiterMetrics {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	metrics := make(map[RateLimitKey]RateLimiterMetrics, len(l.buckets))
+	for key, b := range l.buckets {
+		m := b.metrics
+		m.QueueDepth = b.queue.Len()
+		metrics[key] = m
+	}
+	return metrics
+}
+
+// bucket returns the bucket of a method in a region, creating it from the most specific configured limit.
+// It returns nil if the method is not limited. It must be called with mu held.
+func (l *RateLimiter) bucket(key RateLimitKey) *rateBucket {
+	if b, ok := l.buckets[key]; ok {
+		return b
+	}
+
+	limit, ok := l.config.Limits[key]
+	if !ok {
+		limit, ok = l.config.Limits[RateLimitKey{Method: key.Method}]
+	}
+	if !ok {
+		limit, ok = l.config.Limits[RateLimitKey{Region: key.Region}]
+	}
+	if !ok {
+		limit = l.config.DefaultLimit
+	}
+	if limit.Rate <= 0 {
+		return nil
+	}
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+
+	b := &rateBucket{
+		limit:     limit,
+		tokens:    float64(limit.Burst),
+		updatedAt: time.Now(),
+	}
+	l.buckets[key] = b
+	return b
+}
+
+// drain releases the queued calls of a bucket as tokens become available, highest priority first.
+func (l *RateLimiter) drain(b *rateBucket) {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		b.refill(now)
+		l.dropStale(b, now)
+
+		if b.queue.Len() == 0 {
+			b.draining = false
+			l.mu.Unlock()
+			return
+		}
+
+		if b.tokens >= 1 {
+			b.tokens--
+			w := heap.Pop(&b.queue).(*rateWaiter)
+			waited := now.Sub(w.enqueuedAt)
+			b.metrics.ThrottleTime += waited
+			if waited > b.metrics.MaxThrottleTime {
+				b.metrics.MaxThrottleTime = waited
+			}
+			w.ready <- nil
+			l.mu.Unlock()
+			continue
+		}
+
+		// Wake up for the next token, or earlier to drop the oldest call once it is stale
+		wait := time.Duration((1 - b.tokens) / b.limit.Rate * float64(time.Second))
+		if l.config.MaxQueueWait > 0 {
+			if staleIn := l.config.MaxQueueWait - now.Sub(b.queue.oldest().enqueuedAt); staleIn < wait {
+				wait = staleIn + time.Millisecond
+			}
+		}
+		l.mu.Unlock()
+		time.Sleep(wait)
+	}
+}
+
+// dropStale drops the calls that waited longer than MaxQueueWait. It must be called with mu held.
+func (l *RateLimiter) dropStale(b *rateBucket, now time.Time) {
+	if l.config.MaxQueueWait <= 0 {
+		return
+	}
+
+	// Collect the stale calls first, as removing one reorders the heap
+	var stale []*rateWaiter
+	for _, w := range b.queue {
+		if now.Sub(w.enqueuedAt) > l.config.MaxQueueWait {
+			stale = append(stale, w)
+		}
+	}
+
+	for _, w := range stale {
+		heap.Remove(&b.queue, w.index)
+		w.ready <- ErrRateLimitStale
+		b.metrics.Dropped++
+	}
+}
+
+// refill adds the tokens earned since the last update, up to the burst.
+func (b *rateBucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.updatedAt).Seconds() * b.limit.Rate
+	if b.tokens > float64(b.limit.Burst) {
+		b.tokens = float64(b.limit.Burst)
+	}
+	b.updatedAt = now
+}
+
+// rateQueue is a heap of waiting calls ordered by priority, then by arrival.
+type rateQueue []*rateWaiter
+
+func (q rateQueue) Len() int { return len(q) }
+
+func (q rateQueue) Less(i, j int) bool { return q.less(q[i], q[j]) }
+
+func (q rateQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *rateQueue) Push(x interface{}) {
+	w := x.(*rateWaiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *rateQueue) Pop() interface{} {
+	old := *q
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	w.index = -1
+	*q = old[:len(old)-1]
+	return w
+}
+
+// lowest returns the waiting call released last.
+func (q rateQueue) lowest() *rateWaiter {
+	lowest := q[0]
+	for _, w := range q[1:] {
+		if q.less(lowest, w) {
+			lowest = w
+		}
+	}
+	return lowest
+}
+
+// oldest returns the waiting call enqueued first.
+func (q rateQueue) oldest() *rateWaiter {
+	oldest := q[0]
+	for _, w := range q[1:] {
+		if w.enqueuedAt.Before(oldest.enqueuedAt) {
+			oldest = w
+		}
+	}
+	return oldest
+}
+
+// less reports whether a is released before b.
+func (q rateQueue) less(a, b *rateWaiter) bool {
+	if a.priority != b.priority {
+		return a.priority > b.priority
+	}
+	return a.enqueuedAt.Before(b.enqueuedAt)
+}
@@ -0,0 +1,245 @@
+package block_engine
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestRateLimiter(limit RateLimit, queueCapacity int, maxQueueWait time.Duration) *RateLimiter {
+	return NewRateLimiter(RateLimiterConfig{
+		Limits:        map[RateLimitKey]RateLimit{{Method: "SendBundle"}: limit},
+		QueueCapacity: queueCapacity,
+		MaxQueueWait:  maxQueueWait,
+	})
+}
+
+// waitAsync starts a Wait and returns the channel receiving its result.
+func waitAsync(ctx context.Context, l *RateLimiter, priority uint64) <-chan error {
+	done := make(chan error, 1)
+	go func() { done <- l.Wait(ctx, "NYC", "SendBundle", priority) }()
+	return done
+}
+
+// waitForQueueDepth waits until the SendBundle queue in NYC holds depth calls.
+func waitForQueueDepth(t *testing.T, l *RateLimiter, depth int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for l.Metrics()[RateLimitKey{Region: "NYC", Method: "SendBundle"}].QueueDepth != depth {
+		if time.Now().After(deadline) {
+			t.Fatalf("queue depth did not reach %d", depth)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRateLimiterBurstAndThrottle(t *testing.T) {
+	l := newTestRateLimiter(RateLimit{Rate: 50, Burst: 2}, 0, 0)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(ctx, "NYC", "SendBundle", 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("third call waited %s, want about 20ms", elapsed)
+	}
+
+	metrics := l.Metrics()[RateLimitKey{Region: "NYC", Method: "SendBundle"}]
+	if metrics.Throttled != 1 || metrics.ThrottleTime <= 0 {
+		t.Errorf("metrics = %+v, want one throttled call", metrics)
+	}
+
+	// Methods without a limit are not throttled
+	for i := 0; i < 10; i++ {
+		if err := l.Wait(ctx, "NYC", "GetTipAccounts", 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := l.Metrics()[RateLimitKey{Region: "NYC", Method: "GetTipAccounts"}]; ok {
+		t.Error("bucket created for an unlimited method")
+	}
+}
+
+func TestRateLimiterPriority(t *testing.T) {
+	l := newTestRateLimiter(RateLimit{Rate: 20, Burst: 1}, 0, 0)
+	ctx := context.Background()
+	if err := l.Wait(ctx, "NYC", "SendBundle", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	low := waitAsync(ctx, l, 1)
+	waitForQueueDepth(t, l, 1)
+	high := waitAsync(ctx, l, 10)
+	waitForQueueDepth(t, l, 2)
+
+	select {
+	case err := <-high:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-low:
+		t.Fatal("lower tip released first")
+	case <-time.After(time.Second):
+		t.Fatal("no call released")
+	}
+	if err := <-low; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRateLimiterQueueFull(t *testing.T) {
+	l := newTestRateLimiter(RateLimit{Rate: 1, Burst: 1}, 1, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := l.Wait(ctx, "NYC", "SendBundle", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	queued := waitAsync(ctx, l, 5)
+	waitForQueueDepth(t, l, 1)
+
+	if err := l.Wait(ctx, "NYC", "SendBundle", 1); !errors.Is(err, ErrRateLimitQueueFull) {
+		t.Errorf("lower priority call: got %v, want ErrRateLimitQueueFull", err)
+	}
+
+	// A higher priority call evicts the queued one
+	waitAsync(ctx, l, 10)
+	if err := <-queued; !errors.Is(err, ErrRateLimitQueueFull) {
+		t.Errorf("evicted call: got %v, want ErrRateLimitQueueFull", err)
+	}
+	if rejected := l.Metrics()[RateLimitKey{Region: "NYC", Method: "SendBundle"}].Rejected; rejected != 2 {
+		t.Errorf("Rejected = %d, want 2", rejected)
+	}
+}
+
+func TestRateLimiterDropsStaleCalls(t *testing.T) {
+	l := newTestRateLimiter(RateLimit{Rate: 1, Burst: 1}, 0, 20*time.Millisecond)
+	ctx := context.Background()
+	if err := l.Wait(ctx, "NYC", "SendBundle", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// The next token is a second away, the call is dropped well before
+	start := time.Now()
+	if err := l.Wait(ctx, "NYC", "SendBundle", 0); !errors.Is(err, ErrRateLimitStale) {
+		t.Fatalf("got %v, want ErrRateLimitStale", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("stale call dropped after %s, want about 20ms", elapsed)
+	}
+}
+
+func TestRateLimiterContextCancellation(t *testing.T) {
+	l := newTestRateLimiter(RateLimit{Rate: 1, Burst: 1}, 0, 0)
+	if err := l.Wait(context.Background(), "NYC", "SendBundle", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, "NYC", "SendBundle", 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+	waitForQueueDepth(t, l, 0)
+}
+
+func TestRateLimiterReturnsTokenOfCancelledRelease(t *testing.T) {
+	l := newTestRateLimiter(RateLimit{Rate: 1, Burst: 1}, 0, 0)
+	if err := l.Wait(context.Background(), "NYC", "SendBundle", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := waitAsync(ctx, l, 0)
+	waitForQueueDepth(t, l, 1)
+
+	// Cancel the call and release it before it gets the lock back
+	l.mu.Lock()
+	cancel()
+	time.Sleep(20 * time.Millisecond)
+	w := heap.Pop(&l.buckets[RateLimitKey{Region: "NYC", Method: "SendBundle"}].queue).(*rateWaiter)
+	w.ready <- nil
+	l.mu.Unlock()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+
+	// The next token is a second away, the returned one is used right away
+	ctx, cancel = context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, "NYC", "SendBundle", 0); err != nil {
+		t.Errorf("token of the cancelled call not returned: %v", err)
+	}
+}
+
+func TestRateLimiterDropStale(t *testing.T) {
+	l := newTestRateLimiter(RateLimit{Rate: 1, Burst: 1}, 0, time.Second)
+	b := l.bucket(RateLimitKey{Region: "NYC", Method: "SendBundle"})
+
+	now := time.Now()
+	var stale, fresh []*rateWaiter
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		w := &rateWaiter{
+			priority:   uint64(rng.Intn(10)),
+			enqueuedAt: now.Add(-time.Duration(rng.Intn(2000)) * time.Millisecond),
+			ready:      make(chan error, 1),
+		}
+		if now.Sub(w.enqueuedAt) > time.Second {
+			stale = append(stale, w)
+		} else {
+			fresh = append(fresh, w)
+		}
+		heap.Push(&b.queue, w)
+	}
+
+	l.dropStale(b, now)
+
+	for _, w := range stale {
+		select {
+		case err := <-w.ready:
+			if !errors.Is(err, ErrRateLimitStale) {
+				t.Errorf("stale call released with %v, want ErrRateLimitStale", err)
+			}
+		default:
+			t.Errorf("stale call enqueued %s ago not dropped", now.Sub(w.enqueuedAt))
+		}
+	}
+	if b.queue.Len() != len(fresh) || b.metrics.Dropped != uint64(len(stale)) {
+		t.Errorf("%d calls queued and %d dropped, want %d and %d", b.queue.Len(), b.metrics.Dropped, len(fresh), len(stale))
+	}
+
+	// The remaining calls are released in order
+	for prev := (*rateWaiter)(nil); b.queue.Len() > 0; {
+		w := heap.Pop(&b.queue).(*rateWaiter)
+		if prev != nil && b.queue.less(w, prev) {
+			t.Fatal("queue order broken by dropping stale calls")
+		}
+		prev = w
+	}
+}
+
+func TestSearcherClientPenalizesResourceExhausted(t *testing.T) {
+	tipAccount := solana.NewWallet().PublicKey()
+	client := newTestSearcher(t, tipAccount)
+	client.Region = "NYC"
+	client.RateLimiter = newTestRateLimiter(RateLimit{Rate: 1000, Burst: 10}, 0, 0)
+	client.service.sendErr = status.Error(codes.ResourceExhausted, "rate limited")
+
+	if _, err := client.SendBundle(context.Background(), newTestBundle(t, tipAccount)); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("got %v, want ResourceExhausted", err)
+	}
+	if got := client.RateLimiter.Metrics()[RateLimitKey{Region: "NYC", Method: "SendBundle"}].ServerThrottled; got != 1 {
+		t.Errorf("ServerThrottled = %d, want 1", got)
+	}
+}
@@ -6,180 +6,171 @@ import (
 	auth_pb "github.com/Prophet-Solutions/block-engine-protos/auth"
 	jito_pb "github.com/Prophet-Solutions/block-engine-protos/block_engine"
 	"github.com/Prophet-Solutions/jito-go/pkg"
-	block_engine_pkg "github.com/Prophet-Solutions/jito-go/pkg/block-engine"
-	"github.com/gagliardetto/solana-go"
 	"google.golang.org/grpc"
 )
 
-// NewRelayer initializes a new Relayer with the given context, gRPC address, and Solana private key.
-// It establishes a gRPC connection, sets up authentication if a keyPair is provided, and returns the Relayer.
+// NewRelayer initializes a new Relayer with the given context, gRPC address, and authentication signer.
+// It establishes a gRPC connection, sets up authentication if a signer is provided, and returns the Relayer.
 func NewRelayer(
 	ctx context.Context,
 	grpcAddr string,
-	keyPair *solana.PrivateKey,
+	signer pkg.Signer,
 	opts ...grpc.DialOption,
 ) (*Relayer, error) {
-	// Channel to handle errors during gRPC connection setup
-	chErr := make(chan error)
+	// Event bus for auth, connection and stream events, shared if given with pkg.WithEventBus
+	bus := pkg.EventBusFromDialOptions(opts)
 
-	// Create a new gRPC connection using the provided address and options
-	conn, err := pkg.CreateGRPCConnection(ctx, chErr, grpcAddr, opts...)
+	// Create an authenticated gRPC connection using the provided address and options
+	conn, authService, err := createAuthenticatedConnection(ctx, bus, grpcAddr, signer, auth_pb.Role_RELAYER, opts...)
 	if err != nil {
 		return nil, err
 	}
 
 	// Create a new BlockEngineRelayerClient with the established connection
 	blockEngineRelayerClient := jito_pb.NewBlockEngineRelayerClient(conn)
-	var authService *block_engine_pkg.AuthenticationService
-
-	// Set up authentication if a keyPair is provided
-	if keyPair != nil {
-		authService = block_engine_pkg.NewAuthenticationService(context.Background(), conn, keyPair)
-		if err = authService.AuthenticateAndRefresh(auth_pb.Role_RELAYER); err != nil {
-			return nil, err
-		}
-	}
 
 	// Return the initialized Relayer
 	return &Relayer{
 		GRPCConn:              conn,
 		Client:                blockEngineRelayerClient,
 		AuthenticationService: authService,
-		ErrChan:               chErr,
+		Events:                bus,
 	}, nil
 }
 
 // SubscribeAccountsOfInterest subscribes to accounts of interest updates from the BlockEngineRelayer service.
 // It returns a client for receiving these updates.
-func (r *Relayer) SubscribeAccountsOfInterest(opts ...grpc.CallOption) (
+func (r *Relayer) SubscribeAccountsOfInterest(ctx context.Context, opts ...grpc.CallOption) (
 	jito_pb.BlockEngineRelayer_SubscribeAccountsOfInterestClient, error) {
 	return r.Client.SubscribeAccountsOfInterest(
-		r.AuthenticationService.GRPCCtx,
+		ctx,
 		&jito_pb.AccountsOfInterestRequest{},
 		opts...,
 	)
 }
 
-// OnSubscribeAccountsOfInterest subscribes to accounts of interest updates and handles the incoming updates and errors.
-// It returns channels for the updates and errors.
+// OnSubscribeAccountsOfInterest subscribes to accounts of interest updates and handles the incoming updates.
+// It returns a channel for the updates, closed when the stream ends. The error ending it is published on Events.
 func (r *Relayer) OnSubscribeAccountsOfInterest(ctx context.Context) (
-	<-chan *jito_pb.AccountsOfInterestUpdate, <-chan error, error) {
+	<-chan *jito_pb.AccountsOfInterestUpdate, error) {
 	// Subscribe to accounts of interest
-	sub, err := r.SubscribeAccountsOfInterest()
+	sub, err := r.SubscribeAccountsOfInterest(ctx)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	// Channels to handle accounts of interest updates and errors
+	// Channel to handle accounts of interest updates
 	chAccountOfInterest := make(chan *jito_pb.AccountsOfInterestUpdate)
-	chErr := make(chan error)
 
-	// Goroutine to receive updates and errors
+	// Goroutine to receive updates until the stream ends
 	go func() {
+		defer close(chAccountOfInterest)
 		for {
+			resp, err := sub.Recv()
+			if err != nil {
+				if ctx.Err() == nil {
+					r.Events.Publish(pkg.Event{Kind: pkg.EventStreamError, Source: "SubscribeAccountsOfInterest", Err: err})
+				}
+				return
+			}
+
 			select {
+			case chAccountOfInterest <- resp:
 			case <-ctx.Done():
 				return
-			case <-r.AuthenticationService.GRPCCtx.Done():
-				return
-			default:
-				resp, err := sub.Recv()
-				if err != nil {
-					chErr <- err
-					continue
-				}
-				chAccountOfInterest <- resp
 			}
 		}
 	}()
 
-	return chAccountOfInterest, chErr, nil
+	return chAccountOfInterest, nil
 }
 
 // SubscribeProgramsOfInterest subscribes to programs of interest updates from the BlockEngineRelayer service.
 // It returns a client for receiving these updates.
-func (r *Relayer) SubscribeProgramsOfInterest(opts ...grpc.CallOption) (
+func (r *Relayer) SubscribeProgramsOfInterest(ctx context.Context, opts ...grpc.CallOption) (
 	jito_pb.BlockEngineRelayer_SubscribeProgramsOfInterestClient, error) {
 	return r.Client.SubscribeProgramsOfInterest(
-		r.AuthenticationService.GRPCCtx,
+		ctx,
 		&jito_pb.ProgramsOfInterestRequest{},
 		opts...,
 	)
 }
 
-// OnSubscribeProgramsOfInterest subscribes to programs of interest updates and handles the incoming updates and errors.
-// It returns channels for the updates and errors.
+// OnSubscribeProgramsOfInterest subscribes to programs of interest updates and handles the incoming updates.
+// It returns a channel for the updates, closed when the stream ends. The error ending it is published on Events.
 func (r *Relayer) OnSubscribeProgramsOfInterest(ctx context.Context) (
-	<-chan *jito_pb.ProgramsOfInterestUpdate, <-chan error, error) {
+	<-chan *jito_pb.ProgramsOfInterestUpdate, error) {
 	// Subscribe to programs of interest
-	sub, err := r.SubscribeProgramsOfInterest()
+	sub, err := r.SubscribeProgramsOfInterest(ctx)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	// Channels to handle programs of interest updates and errors
+	// Channel to handle programs of interest updates
 	chProgramsOfInterest := make(chan *jito_pb.ProgramsOfInterestUpdate)
-	chErr := make(chan error)
 
-	// Goroutine to receive updates and errors
+	// Goroutine to receive updates until the stream ends
 	go func() {
+		defer close(chProgramsOfInterest)
 		for {
+			subInfo, err := sub.Recv()
+			if err != nil {
+				if ctx.Err() == nil {
+					r.Events.Publish(pkg.Event{Kind: pkg.EventStreamError, Source: "SubscribeProgramsOfInterest", Err: err})
+				}
+				return
+			}
+
 			select {
+			case chProgramsOfInterest <- subInfo:
 			case <-ctx.Done():
 				return
-			default:
-				subInfo, err := sub.Recv()
-				if err != nil {
-					chErr <- err
-					continue
-				}
-				chProgramsOfInterest <- subInfo
 			}
 		}
 	}()
 
-	return chProgramsOfInterest, chErr, nil
+	return chProgramsOfInterest, nil
 }
 
 // StartExpiringPacketStream starts a stream for receiving expiring packet updates from the BlockEngineRelayer service.
 // It returns a client for receiving these updates.
-func (r *Relayer) StartExpiringPacketStream(opts ...grpc.CallOption) (
+func (r *Relayer) StartExpiringPacketStream(ctx context.Context, opts ...grpc.CallOption) (
 	jito_pb.BlockEngineRelayer_StartExpiringPacketStreamClient, error) {
-	return r.Client.StartExpiringPacketStream(r.AuthenticationService.GRPCCtx, opts...)
+	return r.Client.StartExpiringPacketStream(ctx, opts...)
 }
 
-// OnStartExpiringPacketStream starts a stream for receiving expiring packet updates and handles the incoming updates and errors.
-// It returns channels for the updates and errors.
+// OnStartExpiringPacketStream starts a stream for receiving expiring packet updates and handles the incoming updates.
+// It returns a channel for the updates, closed when the stream ends. The error ending it is published on Events.
 func (r *Relayer) OnStartExpiringPacketStream(ctx context.Context) (
-	<-chan *jito_pb.StartExpiringPacketStreamResponse, <-chan error, error) {
+	<-chan *jito_pb.StartExpiringPacketStreamResponse, error) {
 	// Start the expiring packet stream
-	sub, err := r.StartExpiringPacketStream()
+	sub, err := r.StartExpiringPacketStream(ctx)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	// Channels to handle expiring packet updates and errors
+	// Channel to handle expiring packet updates
 	chPacket := make(chan *jito_pb.StartExpiringPacketStreamResponse)
-	chErr := make(chan error)
 
-	// Goroutine to receive updates and errors
+	// Goroutine to receive updates until the stream ends
 	go func() {
+		defer close(chPacket)
 		for {
+			resp, err := sub.Recv()
+			if err != nil {
+				if ctx.Err() == nil {
+					r.Events.Publish(pkg.Event{Kind: pkg.EventStreamError, Source: "StartExpiringPacketStream", Err: err})
+				}
+				return
+			}
+
 			select {
+			case chPacket <- resp:
 			case <-ctx.Done():
 				return
-			case <-r.AuthenticationService.GRPCCtx.Done():
-				return
-			default:
-				resp, err := sub.Recv()
-				if err != nil {
-					chErr <- err
-					continue
-				}
-				chPacket <- resp
 			}
 		}
 	}()
 
-	return chPacket, chErr, nil
+	return chPacket, nil
 }
@@ -0,0 +1,154 @@
+package block_engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"google.golang.org/grpc"
+)
+
+// DefaultResubmitAttempts is the default number of attempts of SendBundleWithResubmission.
+const DefaultResubmitAttempts = 3
+
+// ErrResubmitAttemptsExhausted is returned when no attempt of a resubmitted bundle landed.
+var ErrResubmitAttemptsExhausted = errors.New("bundle resubmission attempts exhausted")
+
+// RebuildBundleFunc builds and signs the bundle transactions for an attempt with the given blockhash.
+// Attempts are numbered from 1.
+type RebuildBundleFunc func(ctx context.Context, attempt int, blockhash solana.Hash) ([]*solana.Transaction, error)
+
+// ResubmitPolicy configures SendBundleWithResubmission.
+type ResubmitPolicy struct {
+	Confirmation    ConfirmationPolicy  // Confirmation of each attempt, bounded by the attempt's blockhash validity
+	MaxAttempts     int                 // Maximum number of attempts, DefaultResubmitAttempts if zero
+	Deadline        time.Time           // Time after which no attempt is started, none if zero
+	BlockhashSource pkg.BlockhashSource // Source of fresh blockhashes, the client's RPC connection if nil
+}
+
+// DefaultResubmitPolicy returns the default ResubmitPolicy, confirming each attempt with DefaultConfirmationPolicy.
+func DefaultResubmitPolicy() ResubmitPolicy {
+	return ResubmitPolicy{
+		Confirmation: DefaultConfirmationPolicy(),
+		MaxAttempts:  DefaultResubmitAttempts,
+	}
+}
+
+// ResubmitAttempt is the outcome of a single attempt of a resubmitted bundle.
+type ResubmitAttempt struct {
+	Attempt   int         // Attempt number, starting at 1
+	UUID      string      // Bundle UUID of the attempt, empty if it was not sent
+	Blockhash solana.Hash // Blockhash the attempt was built with
+	SentAt    time.Time   // Time the attempt was sent
+	Err       error       // Error that ended the attempt, nil if it landed
+}
+
+// ResubmitResult is the outcome of SendBundleWithResubmission.
+type ResubmitResult struct {
+	LogicalID string            // UUID of the first attempt, linking the attempts in the BundleTracker
+	Response  *BundleResponse   // Response of the attempt that landed, nil if none did
+	Attempts  []ResubmitAttempt // Every attempt in order
+}
+
+// SendBundleWithResubmission sends a bundle and resends it with a fresh blockhash whenever an attempt
+// expires or fails, until one lands, MaxAttempts is reached or the deadline passes. For each attempt
+// it fetches a blockhash and calls rebuild to build and sign the transactions with it. Every attempt
+// is linked to the first one in the BundleTracker, so they are tracked as one logical bundle.
+// Attempts are not retried after an on-chain transaction error or a failed pre-flight validation.
+// The account locks of an attempt are released once it is superseded by the next one.
+func (c *SearcherClient) SendBundleWithResubmission(
+	ctx context.Context,
+	rebuild RebuildBundleFunc,
+	policy ResubmitPolicy,
+	opts ...grpc.CallOption,
+) (*ResubmitResult, error) {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = DefaultResubmitAttempts
+	}
+	if policy.BlockhashSource == nil {
+		policy.BlockhashSource = pkg.NewRPCBlockhashSource(c.RPCConn, rpc.CommitmentConfirmed)
+	}
+	if !policy.Deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, policy.Deadline)
+		defer cancel()
+	}
+
+	result := &ResubmitResult{}
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		resp, record, err := c.resubmitAttempt(ctx, attempt, rebuild, policy, result.LogicalID, opts...)
+		record.Err = err
+		result.Attempts = append(result.Attempts, record)
+		if result.LogicalID == "" {
+			result.LogicalID = record.UUID
+		}
+
+		if err == nil {
+			result.Response = resp
+			return result, nil
+		}
+		if !retryableResubmitError(err) || ctx.Err() != nil {
+			return result, err
+		}
+
+		// The next attempt supersedes this one and must not contend with its account locks
+		if c.AccountLocks != nil && record.UUID != "" {
+			c.AccountLocks.Release(record.UUID)
+		}
+	}
+
+	return result, fmt.Errorf("%w after %d attempts: %w",
+		ErrResubmitAttemptsExhausted, len(result.Attempts), result.Attempts[len(result.Attempts)-1].Err)
+}
+
+// resubmitAttempt builds, sends and confirms a single attempt of a resubmitted bundle.
+func (c *SearcherClient) resubmitAttempt(
+	ctx context.Context,
+	attempt int,
+	rebuild RebuildBundleFunc,
+	policy ResubmitPolicy,
+	logicalID string,
+	opts ...grpc.CallOption,
+) (*BundleResponse, ResubmitAttempt, error) {
+	record := ResubmitAttempt{Attempt: attempt}
+
+	blockhash, err := policy.BlockhashSource.LatestBlockhash(ctx)
+	if err != nil {
+		return nil, record, err
+	}
+	record.Blockhash = blockhash.Blockhash
+
+	transactions, err := rebuild(ctx, attempt, blockhash.Blockhash)
+	if err != nil {
+		return nil, record, fmt.Errorf("could not rebuild bundle for attempt %d: %w", attempt, err)
+	}
+
+	resp, err := c.SendBundle(ctx, transactions, opts...)
+	if err != nil {
+		return nil, record, err
+	}
+	record.UUID = resp.GetUuid()
+	record.SentAt = time.Now()
+
+	if logicalID == "" {
+		logicalID = record.UUID
+	}
+	c.BundleTracker.Link(record.UUID, logicalID, attempt)
+
+	// Stop waiting on this attempt once its blockhash expired
+	confirmation := policy.Confirmation
+	confirmation.LastValidBlockHeight = blockhash.LastValidBlockHeight
+
+	bundleResponse, err := c.confirmBundle(ctx, resp, transactions, confirmation)
+	return bundleResponse, record, err
+}
+
+// retryableResubmitError reports whether a fresh attempt may land after the error.
+func retryableResubmitError(err error) bool {
+	var validationErr *pkg.BundleValidationError
+	return !errors.Is(err, pkg.ErrTransactionFailed) && !errors.As(err, &validationErr)
+}
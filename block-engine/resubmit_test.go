@@ -0,0 +1,188 @@
+package block_engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// fakeBlockhashSource returns the blockhash Hash{n} on the nth call, valid up to the block height of lastValid.
+type fakeBlockhashSource struct {
+	lastValid func(n int) uint64
+	calls     int
+	mu        sync.Mutex
+}
+
+func (s *fakeBlockhashSource) LatestBlockhash(context.Context) (*pkg.LatestBlockhash, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	return &pkg.LatestBlockhash{
+		Blockhash:            solana.Hash{byte(s.calls)},
+		LastValidBlockHeight: s.lastValid(s.calls),
+	}, nil
+}
+
+// resubmissionTest is a searcher at block height 10 whose signature statuses are set by the test.
+type resubmissionTest struct {
+	*testSearcher
+	rebuild RebuildBundleFunc
+	status  func(attempt int) (rpc.ConfirmationStatusType, interface{})
+	attempt int
+	mu      sync.Mutex
+}
+
+func newResubmissionTest(t *testing.T) *resubmissionTest {
+	t.Helper()
+	tipAccount := solana.NewWallet().PublicKey()
+	payer := newTestSigner(t)
+	r := &resubmissionTest{testSearcher: newTestSearcher(t, tipAccount)}
+
+	r.rebuild = func(ctx context.Context, attempt int, blockhash solana.Hash) ([]*solana.Transaction, error) {
+		r.mu.Lock()
+		r.attempt = attempt
+		r.mu.Unlock()
+
+		return r.NewBundleBuilder(payer.PublicKey(), 10_000).
+			AddInstructions(transferInstruction(payer.PublicKey(), 1)).
+			WithSigners(payer).
+			WithRecentBlockhash(blockhash).
+			Build(ctx)
+	}
+	r.rpc.handle("getBlockHeight", func([]json.RawMessage) (interface{}, error) { return 10, nil })
+	r.rpc.handle("getSignatureStatuses", func(params []json.RawMessage) (interface{}, error) {
+		r.mu.Lock()
+		status, txErr := r.status(r.attempt)
+		r.mu.Unlock()
+		return signatureStatuses(status, txErr)(params)
+	})
+	return r
+}
+
+// policy returns a resubmission policy whose blockhashes expire before block height 10 up to the given attempt.
+func (r *resubmissionTest) policy(maxAttempts, expiringAttempts int) ResubmitPolicy {
+	return ResubmitPolicy{
+		Confirmation: fastConfirmationPolicy(),
+		MaxAttempts:  maxAttempts,
+		BlockhashSource: &fakeBlockhashSource{lastValid: func(n int) uint64 {
+			if n <= expiringAttempts {
+				return 5
+			}
+			return 1_000
+		}},
+	}
+}
+
+func TestSendBundleWithResubmissionAfterExpiry(t *testing.T) {
+	r := newResubmissionTest(t)
+	r.status = func(attempt int) (rpc.ConfirmationStatusType, interface{}) {
+		if attempt < 2 {
+			return "", nil
+		}
+		return rpc.ConfirmationStatusConfirmed, nil
+	}
+
+	result, err := r.SendBundleWithResubmission(context.Background(), r.rebuild, r.policy(3, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Attempts) != 2 || result.Response == nil || result.LogicalID != "bundle-1" {
+		t.Fatalf("result = %+v", result)
+	}
+	if first := result.Attempts[0]; !errors.Is(first.Err, ErrBlockHeightExceeded) || first.Blockhash != (solana.Hash{1}) {
+		t.Errorf("first attempt = %+v, want expired with blockhash 1", first)
+	}
+	if second := result.Attempts[1]; second.Err != nil || second.UUID != "bundle-2" || second.Blockhash != (solana.Hash{2}) {
+		t.Errorf("second attempt = %+v", second)
+	}
+
+	attempts := r.BundleTracker.Attempts(result.LogicalID)
+	if len(attempts) != 2 || attempts[0].State != BundleStateExpired || attempts[1].Attempt != 2 {
+		t.Errorf("tracked attempts = %+v", attempts)
+	}
+}
+
+func TestSendBundleWithResubmissionStopsOnTransactionError(t *testing.T) {
+	r := newResubmissionTest(t)
+	r.status = func(int) (rpc.ConfirmationStatusType, interface{}) {
+		return rpc.ConfirmationStatusConfirmed, map[string]interface{}{"InstructionError": []interface{}{0, "Custom"}}
+	}
+
+	policy := r.policy(3, 0)
+	policy.Confirmation.FailOnTransactionError = true
+
+	result, err := r.SendBundleWithResubmission(context.Background(), r.rebuild, policy)
+	if !errors.Is(err, pkg.ErrTransactionFailed) {
+		t.Fatalf("got %v, want ErrTransactionFailed", err)
+	}
+	if len(result.Attempts) != 1 || r.service.sent() != 1 {
+		t.Errorf("resubmitted after a transaction error: %d attempts", len(result.Attempts))
+	}
+}
+
+func TestSendBundleWithResubmissionExhausted(t *testing.T) {
+	r := newResubmissionTest(t)
+	r.status = func(int) (rpc.ConfirmationStatusType, interface{}) { return "", nil }
+
+	result, err := r.SendBundleWithResubmission(context.Background(), r.rebuild, r.policy(2, 2))
+	if !errors.Is(err, ErrResubmitAttemptsExhausted) || !errors.Is(err, ErrBlockHeightExceeded) {
+		t.Fatalf("got %v, want ErrResubmitAttemptsExhausted wrapping ErrBlockHeightExceeded", err)
+	}
+	if len(result.Attempts) != 2 || result.Response != nil {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestSendBundleWithResubmissionReleasesSupersededLocks(t *testing.T) {
+	r := newResubmissionTest(t)
+	r.AccountLocks = pkg.NewAccountLockAnalyzer(r.RPCConn)
+	r.status = func(attempt int) (rpc.ConfirmationStatusType, interface{}) {
+		if attempt < 2 {
+			return "", nil
+		}
+		return rpc.ConfirmationStatusConfirmed, nil
+	}
+
+	// Every attempt writes the same recipient, it must not contend with the attempt it supersedes
+	var conflicts []pkg.BundleConflict
+	payer, recipient := newTestSigner(t), solana.NewWallet().PublicKey()
+	rebuild := func(ctx context.Context, attempt int, blockhash solana.Hash) ([]*solana.Transaction, error) {
+		if _, err := r.rebuild(ctx, attempt, blockhash); err != nil {
+			return nil, err
+		}
+		txs, err := r.NewBundleBuilder(payer.PublicKey(), 10_000).
+			AddInstructions(system.NewTransferInstruction(1, payer.PublicKey(), recipient).Build()).
+			WithSigners(payer).
+			WithRecentBlockhash(blockhash).
+			Build(ctx)
+		if err != nil {
+			return nil, err
+		}
+		locks, _, err := r.AccountLocks.Analyze(ctx, txs)
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, r.AccountLocks.Conflicts(locks)...)
+		return txs, nil
+	}
+
+	result, err := r.SendBundleWithResubmission(context.Background(), rebuild, r.policy(3, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Attempts) != 2 {
+		t.Fatalf("got %d attempts, want 2", len(result.Attempts))
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("attempts contend with earlier attempts: %+v", conflicts)
+	}
+}
@@ -0,0 +1,349 @@
+package block_engine
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	jito_pb "github.com/Prophet-Solutions/block-engine-protos/searcher"
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Constants for the default bundle scheduler configuration
+const (
+	DefaultLeaderSlotsAhead      = 2                      // Release bundles when the next Jito leader is this many slots away
+	DefaultSchedulerPollInterval = 400 * time.Millisecond // Delay between leader schedule checks
+	DefaultSlotDuration          = 400 * time.Millisecond // Expected duration of a slot, used to estimate send times
+)
+
+// Errors reported by the bundle scheduler
+var (
+	ErrBundleExpired    = errors.New("bundle blockhash expires before the next Jito leader")
+	ErrSchedulerStopped = errors.New("bundle scheduler stopped")
+)
+
+// BundleSchedulerConfig configures a BundleScheduler.
+type BundleSchedulerConfig struct {
+	LeaderSlotsAhead uint64        // Release bundles when the next Jito leader is at most this many slots away
+	PollInterval     time.Duration // Delay between leader schedule checks
+	SlotDuration     time.Duration // Expected duration of a slot, used to estimate send times
+	Regions          []string      // Regions considered for the next leader, all if empty
+}
+
+// DefaultBundleSchedulerConfig returns the default BundleScheduler configuration.
+func DefaultBundleSchedulerConfig() BundleSchedulerConfig {
+	return BundleSchedulerConfig{
+		LeaderSlotsAhead: DefaultLeaderSlotsAhead,
+		PollInterval:     DefaultSchedulerPollInterval,
+		SlotDuration:     DefaultSlotDuration,
+	}
+}
+
+// ScheduledBundle is a bundle held by the scheduler until a Jito leader is near.
+type ScheduledBundle struct {
+	Transactions         []*solana.Transaction // Bundle transactions
+	LastValidBlockHeight uint64                // Last block height the blockhash is valid at, 0 disables expiry
+	SubmittedAt          time.Time             // Time the bundle was submitted to the scheduler
+	done                 chan struct{}         // Closed once the bundle was sent or dropped
+	result               ScheduledBundleResult // Outcome, set before done is closed
+	leaderSlot           uint64                // Slot of the Jito leader the bundle is expected to be sent for
+	sendAt               time.Time             // Estimated time the bundle is sent
+	mu                   sync.Mutex            // Mutex for synchronizing the schedule
+}
+
+// ScheduledBundleResult is the outcome of a scheduled bundle.
+type ScheduledBundleResult struct {
+	SentAt         time.Time                   // Time the bundle was released and sent
+	LeaderSlot     uint64                      // Slot of the Jito leader the bundle was released for
+	LeaderIdentity string                      // Identity of the Jito leader the bundle was released for
+	Response       *jito_pb.SendBundleResponse // Response from sending the bundle
+	Err            error                       // Error if the bundle expired or could not be sent
+}
+
+// PredictedLeaderSlot returns the slot of the Jito leader the bundle is expected to be sent for, as of the
+// last leader schedule check. It is 0 until the leader schedule is known.
+func (b *ScheduledBundle) PredictedLeaderSlot() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.leaderSlot
+}
+
+// EstimatedSendTime returns the estimated time the bundle is sent, when the predicted leader is
+// LeaderSlotsAhead slots away, as of the last leader schedule check. It is zero until the leader
+// schedule is known.
+func (b *ScheduledBundle) EstimatedSendTime() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.sendAt
+}
+
+// setSchedule records the predicted leader slot and estimated send time of the bundle.
+func (b *ScheduledBundle) setSchedule(leaderSlot uint64, sendAt time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.leaderSlot = leaderSlot
+	b.sendAt = sendAt
+}
+
+// Done returns a channel that is closed once the bundle was sent or dropped.
+func (b *ScheduledBundle) Done() <-chan struct{} {
+	return b.done
+}
+
+// Result returns the outcome of the bundle. It must only be called once Done is closed.
+func (b *ScheduledBundle) Result() ScheduledBundleResult {
+	return b.result
+}
+
+// Wait waits for the bundle to be sent or dropped and returns its outcome.
+func (b *ScheduledBundle) Wait(ctx context.Context) (ScheduledBundleResult, error) {
+	select {
+	case <-ctx.Done():
+		return ScheduledBundleResult{}, ctx.Err()
+	case <-b.done:
+		return b.result, b.result.Err
+	}
+}
+
+// complete records the outcome of the bundle and notifies its waiters.
+func (b *ScheduledBundle) complete(result ScheduledBundleResult) {
+	b.result = result
+	close(b.done)
+}
+
+// BundleScheduler holds submitted bundles until the next Jito-connected leader is within
+// the configured number of slots, then sends them. Bundles whose blockhash expires before
+// the leader's slot are dropped with ErrBundleExpired.
+type BundleScheduler struct {
+	client             *SearcherClient       // Searcher client used for the leader schedule and sending
+	config             BundleSchedulerConfig // Scheduler configuration
+	queue              []*ScheduledBundle    // Bundles waiting for a leader
+	currentSlot        uint64                // Last known current slot
+	nextLeaderSlot     uint64                // Last known slot of the next Jito leader
+	nextLeaderIdentity string                // Last known identity of the next Jito leader
+	releaseAt          time.Time             // Estimated time the queue is released for the next Jito leader
+	stopped            bool                  // Whether the scheduler loop stopped
+	mu                 sync.Mutex            // Mutex for synchronizing the queue and schedule
+}
+
+// NewBundleScheduler creates a BundleScheduler sending through the searcher client.
+// Start must be called for queued bundles to be released.
+func (c *SearcherClient) NewBundleScheduler(config BundleSchedulerConfig) *BundleScheduler {
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultSchedulerPollInterval
+	}
+	if config.SlotDuration <= 0 {
+		config.SlotDuration = DefaultSlotDuration
+	}
+
+	return &BundleScheduler{
+		client: c,
+		config: config,
+	}
+}
+
+// Submit queues a bundle until the next Jito leader is near. lastValidBlockHeight is the last
+// block height the bundle's blockhash is valid at; 0 disables the expiry check.
+// The predicted leader slot and estimated send time of the bundle are set from the last known
+// leader schedule, and updated on every schedule check while it is queued.
+func (s *BundleScheduler) Submit(transactions []*solana.Transaction, lastValidBlockHeight uint64) *ScheduledBundle {
+	bundle := &ScheduledBundle{
+		Transactions:         transactions,
+		LastValidBlockHeight: lastValidBlockHeight,
+		SubmittedAt:          time.Now(),
+		done:                 make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		bundle.complete(ScheduledBundleResult{Err: ErrSchedulerStopped})
+		return bundle
+	}
+
+	if s.nextLeaderSlot > 0 {
+		sendAt := s.releaseAt
+		if now := time.Now(); sendAt.Before(now) {
+			sendAt = now
+		}
+		bundle.setSchedule(s.nextLeaderSlot, sendAt)
+	}
+	s.queue = append(s.queue, bundle)
+	return bundle
+}
+
+// NextLeader returns the last known current slot and slot of the next Jito leader.
+func (s *BundleScheduler) NextLeader() (currentSlot uint64, nextLeaderSlot uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.currentSlot, s.nextLeaderSlot
+}
+
+// QueueLength returns the number of bundles waiting for a leader.
+func (s *BundleScheduler) QueueLength() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.queue)
+}
+
+// Start tracks the leader schedule and releases queued bundles until the context is done.
+// Errors getting the leader schedule are published on the client Events as pkg.EventRefreshError.
+// Bundles still queued when it returns are dropped with ErrSchedulerStopped.
+func (s *BundleScheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.stop()
+			return
+		case <-ticker.C:
+			if err := s.tick(ctx); err != nil {
+				s.client.Events.Publish(pkg.Event{Kind: pkg.EventRefreshError, Source: "GetNextScheduledLeader", Err: err})
+			}
+		}
+	}
+}
+
+// tick refreshes the leader schedule, drops expired bundles and releases the queue if a leader is near.
+func (s *BundleScheduler) tick(ctx context.Context) error {
+	leader, err := s.client.GetNextScheduledLeader(ctx, s.config.Regions)
+	if err != nil {
+		return err
+	}
+
+	var slotsAway uint64
+	if leader.GetNextLeaderSlot() > leader.GetCurrentSlot() {
+		slotsAway = leader.GetNextLeaderSlot() - leader.GetCurrentSlot()
+	}
+
+	// The queue is released once the leader is LeaderSlotsAhead slots away
+	var sendIn time.Duration
+	if slotsAway > s.config.LeaderSlotsAhead {
+		sendIn = time.Duration(slotsAway-s.config.LeaderSlotsAhead) * s.config.SlotDuration
+	}
+
+	s.mu.Lock()
+	s.currentSlot = leader.GetCurrentSlot()
+	s.nextLeaderSlot = leader.GetNextLeaderSlot()
+	s.nextLeaderIdentity = leader.GetNextLeaderIdentity()
+	s.releaseAt = time.Now().Add(sendIn)
+	for _, bundle := range s.queue {
+		bundle.setSchedule(s.nextLeaderSlot, s.releaseAt)
+	}
+	queue := s.queue
+	release := slotsAway <= s.config.LeaderSlotsAhead
+	if release {
+		s.queue = nil
+	}
+	s.mu.Unlock()
+
+	if len(queue) == 0 {
+		return nil
+	}
+
+	// Estimate the block height at the leader's slot to find bundles that will be stale by then
+	blockHeight, err := s.currentBlockHeight(ctx, queue)
+	if err != nil {
+		if release {
+			// Keep the released bundles queued for the next leader
+			s.requeue(queue)
+		}
+		return err
+	}
+	expired := func(bundle *ScheduledBundle) bool {
+		return bundle.LastValidBlockHeight > 0 && blockHeight+slotsAway > bundle.LastValidBlockHeight
+	}
+
+	if !release {
+		s.dropExpired(expired)
+		return nil
+	}
+
+	for _, bundle := range queue {
+		if expired(bundle) {
+			bundle.complete(ScheduledBundleResult{
+				LeaderSlot:     leader.GetNextLeaderSlot(),
+				LeaderIdentity: leader.GetNextLeaderIdentity(),
+				Err:            ErrBundleExpired,
+			})
+			continue
+		}
+
+		go s.send(ctx, bundle, leader)
+	}
+
+	return nil
+}
+
+// send sends a released bundle and records its outcome.
+func (s *BundleScheduler) send(ctx context.Context, bundle *ScheduledBundle, leader *jito_pb.NextScheduledLeaderResponse) {
+	result := ScheduledBundleResult{
+		SentAt:         time.Now(),
+		LeaderSlot:     leader.GetNextLeaderSlot(),
+		LeaderIdentity: leader.GetNextLeaderIdentity(),
+	}
+	result.Response, result.Err = s.client.SendBundle(ctx, bundle.Transactions)
+
+	bundle.complete(result)
+}
+
+// currentBlockHeight returns the current block height if any bundle of the queue needs an expiry check.
+func (s *BundleScheduler) currentBlockHeight(ctx context.Context, queue []*ScheduledBundle) (uint64, error) {
+	for _, bundle := range queue {
+		if bundle.LastValidBlockHeight > 0 {
+			return s.client.RPCConn.GetBlockHeight(ctx, rpc.CommitmentConfirmed)
+		}
+	}
+	return 0, nil
+}
+
+// dropExpired removes the expired bundles from the queue.
+func (s *BundleScheduler) dropExpired(expired func(*ScheduledBundle) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.queue[:0]
+	for _, bundle := range s.queue {
+		if expired(bundle) {
+			bundle.complete(ScheduledBundleResult{
+				LeaderSlot:     s.nextLeaderSlot,
+				LeaderIdentity: s.nextLeaderIdentity,
+				Err:            ErrBundleExpired,
+			})
+			continue
+		}
+		kept = append(kept, bundle)
+	}
+	s.queue = kept
+}
+
+// requeue puts bundles back at the front of the queue.
+func (s *BundleScheduler) requeue(bundles []*ScheduledBundle) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.queue = append(bundles, s.queue...)
+}
+
+// stop drops every queued bundle and rejects further submissions.
+func (s *BundleScheduler) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopped = true
+	for _, bundle := range s.queue {
+		bundle.complete(ScheduledBundleResult{Err: ErrSchedulerStopped})
+	}
+	s.queue = nil
+}
@@ -0,0 +1,188 @@
+package block_engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestBundleSchedulerHoldsUntilLeaderIsNear(t *testing.T) {
+	tipAccount := solana.NewWallet().PublicKey()
+	client := newTestSearcher(t, tipAccount)
+	scheduler := client.NewBundleScheduler(DefaultBundleSchedulerConfig())
+	ctx := context.Background()
+
+	bundle := scheduler.Submit(newTestBundle(t, tipAccount), 0)
+
+	client.service.setLeader(100, 110)
+	if err := scheduler.tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if current, next := scheduler.NextLeader(); current != 100 || next != 110 {
+		t.Errorf("NextLeader() = %d, %d, want 100, 110", current, next)
+	}
+	if scheduler.QueueLength() != 1 || client.service.sent() != 0 {
+		t.Fatalf("bundle released while the leader is 10 slots away")
+	}
+
+	client.service.setLeader(108, 110)
+	if err := scheduler.tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	result, err := bundle.Wait(waitCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.LeaderSlot != 110 || result.LeaderIdentity != "leader" || result.SentAt.IsZero() {
+		t.Errorf("result = %+v", result)
+	}
+	if result.Response.GetUuid() != "bundle-1" || client.service.sent() != 1 {
+		t.Errorf("bundle not sent, response %v", result.Response)
+	}
+	if scheduler.QueueLength() != 0 {
+		t.Error("released bundle still queued")
+	}
+}
+
+func TestBundleSchedulerDropsExpiredBundles(t *testing.T) {
+	tipAccount := solana.NewWallet().PublicKey()
+	client := newTestSearcher(t, tipAccount)
+	client.rpc.handle("getBlockHeight", func([]json.RawMessage) (interface{}, error) { return 1_000, nil })
+	scheduler := client.NewBundleScheduler(DefaultBundleSchedulerConfig())
+
+	// The leader is 10 slots away: the first blockhash is stale by then, the second still valid
+	expiring := scheduler.Submit(newTestBundle(t, tipAccount), 1_005)
+	valid := scheduler.Submit(newTestBundle(t, tipAccount), 1_050)
+
+	client.service.setLeader(100, 110)
+	if err := scheduler.tick(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-expiring.Done():
+		if err := expiring.Result().Err; !errors.Is(err, ErrBundleExpired) {
+			t.Errorf("expired bundle: got %v, want ErrBundleExpired", err)
+		}
+	default:
+		t.Fatal("expiring bundle still queued")
+	}
+	select {
+	case <-valid.Done():
+		t.Fatalf("valid bundle completed with %v", valid.Result().Err)
+	default:
+	}
+	if scheduler.QueueLength() != 1 {
+		t.Errorf("QueueLength() = %d, want 1", scheduler.QueueLength())
+	}
+}
+
+func TestBundleSchedulerStop(t *testing.T) {
+	tipAccount := solana.NewWallet().PublicKey()
+	client := newTestSearcher(t, tipAccount)
+	scheduler := client.NewBundleScheduler(DefaultBundleSchedulerConfig())
+
+	queued := scheduler.Submit(newTestBundle(t, tipAccount), 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	scheduler.Start(ctx)
+
+	if _, err := queued.Wait(context.Background()); !errors.Is(err, ErrSchedulerStopped) {
+		t.Errorf("queued bundle: got %v, want ErrSchedulerStopped", err)
+	}
+	late := scheduler.Submit(newTestBundle(t, tipAccount), 0)
+	if _, err := late.Wait(context.Background()); !errors.Is(err, ErrSchedulerStopped) {
+		t.Errorf("bundle submitted after stop: got %v, want ErrSchedulerStopped", err)
+	}
+}
+
+func TestBundleSchedulerPublishesLeaderErrors(t *testing.T) {
+	client := newTestSearcher(t)
+	client.Events = pkg.NewEventBus()
+	events := client.Events.Subscribe(1, pkg.EventRefreshError)
+	client.service.leaderErr = errors.New("unavailable")
+
+	config := DefaultBundleSchedulerConfig()
+	config.PollInterval = time.Millisecond
+	scheduler := client.NewBundleScheduler(config)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go scheduler.Start(ctx)
+
+	select {
+	case event := <-events.C:
+		if event.Source != "GetNextScheduledLeader" || event.Err == nil {
+			t.Errorf("event = %v, want the leader schedule error", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("leader schedule error not published")
+	}
+}
+
+func TestBundleSchedulerEstimatesSendTime(t *testing.T) {
+	tipAccount := solana.NewWallet().PublicKey()
+	client := newTestSearcher(t, tipAccount)
+	scheduler := client.NewBundleScheduler(DefaultBundleSchedulerConfig())
+	ctx := context.Background()
+
+	queued := scheduler.Submit(newTestBundle(t, tipAccount), 0)
+	if slot, sendAt := queued.PredictedLeaderSlot(), queued.EstimatedSendTime(); slot != 0 || !sendAt.IsZero() {
+		t.Errorf("schedule before the leader is known = %d, %s, want none", slot, sendAt)
+	}
+
+	// checkSchedule checks the bundle is expected to be sent for the leader slot once the slots elapsed
+	checkSchedule := func(bundle *ScheduledBundle, leaderSlot uint64, slots int, before, after time.Time) {
+		t.Helper()
+
+		if got := bundle.PredictedLeaderSlot(); got != leaderSlot {
+			t.Errorf("PredictedLeaderSlot() = %d, want %d", got, leaderSlot)
+		}
+		sendIn := time.Duration(slots) * DefaultSlotDuration
+		if got := bundle.EstimatedSendTime(); got.Before(before.Add(sendIn)) || got.After(after.Add(sendIn)) {
+			t.Errorf("EstimatedSendTime() = %s, want %s after the check", got, sendIn)
+		}
+	}
+
+	// The queue is released 2 slots before the leader at slot 110
+	client.service.setLeader(100, 110)
+	before := time.Now()
+	if err := scheduler.tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+	after := time.Now()
+	checkSchedule(queued, 110, 8, before, after)
+
+	// Bundles submitted between checks get the last known schedule
+	late := scheduler.Submit(newTestBundle(t, tipAccount), 0)
+	checkSchedule(late, 110, 8, before, after)
+
+	// Every check updates the schedule of the queued bundles
+	client.service.setLeader(104, 120)
+	before = time.Now()
+	if err := scheduler.tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+	after = time.Now()
+	checkSchedule(queued, 120, 14, before, after)
+	checkSchedule(late, 120, 14, before, after)
+
+	client.service.setLeader(119, 120)
+	before = time.Now()
+	if err := scheduler.tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+	checkSchedule(queued, 120, 0, before, time.Now())
+	if result, err := queued.Wait(ctx); err != nil || result.LeaderSlot != queued.PredictedLeaderSlot() {
+		t.Errorf("result = %+v, %v, want sent for the predicted leader", result, err)
+	}
+}
@@ -8,51 +8,37 @@ import (
 	auth_pb "github.com/Prophet-Solutions/block-engine-protos/auth"
 	jito_pb "github.com/Prophet-Solutions/block-engine-protos/searcher"
 	"github.com/Prophet-Solutions/jito-go/pkg"
-	block_engine_pkg "github.com/Prophet-Solutions/jito-go/pkg/block-engine"
-	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
 	"google.golang.org/grpc"
 )
 
 // NewSearcherClient initializes a new SearcherClient with the provided context, gRPC address,
-// Jito and standard RPC clients, Solana private key, and additional gRPC dial options.
-// It establishes a gRPC connection, sets up authentication if a keyPair is provided, and returns the SearcherClient.
+// Jito and standard RPC clients, authentication signer, and additional gRPC dial options.
+// It establishes a gRPC connection, sets up authentication if a signer is provided, and returns the SearcherClient.
+// The account lock analysis is disabled, as it fetches lookup tables over RPC: set AccountLocks to enable it.
 func NewSearcherClient(
 	ctx context.Context,
 	grpcAddr string,
 	jitoRPCClient, rpcClient *rpc.Client,
-	keyPair *solana.PrivateKey,
+	signer pkg.Signer,
 	opts ...grpc.DialOption,
 ) (*SearcherClient, error) {
-	// Channel to handle errors during gRPC connection setup
-	chErr := make(chan error)
+	// Event bus for auth, connection and stream events, shared if given with pkg.WithEventBus
+	bus := pkg.EventBusFromDialOptions(opts)
 
-	// Create a new gRPC connection using the provided address and options
-	conn, err := pkg.CreateGRPCConnection(ctx, chErr, grpcAddr, opts...)
+	// Create an authenticated gRPC connection using the provided address and options
+	conn, authService, err := createAuthenticatedConnection(ctx, bus, grpcAddr, signer, auth_pb.Role_SEARCHER, opts...)
 	if err != nil {
 		return nil, err
 	}
 
 	// Create a new SearcherServiceClient with the established connection
 	searcherService := jito_pb.NewSearcherServiceClient(conn)
-	var authService *block_engine_pkg.AuthenticationService
-
-	// Set up authentication if a keyPair is provided
-	if keyPair != nil {
-		authService = block_engine_pkg.NewAuthenticationService(context.Background(), conn, keyPair)
-		if err = authService.AuthenticateAndRefresh(auth_pb.Role_SEARCHER); err != nil {
-			return nil, err
-		}
-	} else {
-		authService = &block_engine_pkg.AuthenticationService{
-			GRPCCtx: ctx,
-		}
-	}
 
-	// Subscribe to bundle results if authentication is set up
+	// Subscribe to bundle results for the lifetime of the client
 	var subBundleRes jito_pb.SearcherService_SubscribeBundleResultsClient
 	subBundleRes, err = searcherService.SubscribeBundleResults(
-		authService.GRPCCtx,
+		ctx,
 		&jito_pb.SubscribeBundleResultsRequest{},
 	)
 	if err != nil {
@@ -60,22 +46,48 @@ func NewSearcherClient(
 	}
 
 	// Return the initialized SearcherClient
-	return &SearcherClient{
+	client := &SearcherClient{
 		GRPCConn:                 conn,
 		RPCConn:                  rpcClient,
 		JitoRPCConn:              jitoRPCClient,
 		SearcherService:          searcherService,
 		AuthenticationService:    authService,
 		BundleStreamSubscription: subBundleRes,
-		ErrChan:                  chErr,
-	}, nil
+		BundleResultDispatcher:   NewBundleResultDispatcher(subBundleRes, BundleResultBufferTTL, bus),
+		Events:                   bus,
+	}
+	client.TipAccounts = NewTipAccountManager(client, TipAccountRandom, DefaultTipAccountsTTL)
+	client.BundleTracker = NewBundleTracker(BundleTrackerRetention)
+	client.BundleResultDispatcher.AddListener(client.BundleTracker.ObserveResult)
+	client.BundleResultDispatcher.AddListener(client.notifyBundleResult)
+	client.BundleResultDispatcher.AddListener(client.releaseAccountLocks)
+	go client.publishBundleResultsError(ctx)
+
+	return client, nil
+}
+
+// publishBundleResultsError publishes the error that terminated the bundle results stream on Events,
+// unless the client context was cancelled.
+func (c *SearcherClient) publishBundleResultsError(ctx context.Context) {
+	<-c.BundleResultDispatcher.Done()
+	if ctx.Err() == nil {
+		c.Events.Publish(pkg.Event{
+			Kind:   pkg.EventStreamError,
+			Source: "SubscribeBundleResults",
+			Err:    c.BundleResultDispatcher.Err(),
+		})
+	}
 }
 
 // GetRegions retrieves the regions from the Searcher service.
 // It returns a GetRegionsResponse or an error.
-func (c *SearcherClient) GetRegions(opts ...grpc.CallOption) (*jito_pb.GetRegionsResponse, error) {
+func (c *SearcherClient) GetRegions(ctx context.Context, opts ...grpc.CallOption) (*jito_pb.GetRegionsResponse, error) {
+	if err := c.throttle(ctx, "GetRegions", 0); err != nil {
+		return nil, err
+	}
+
 	return c.SearcherService.GetRegions(
-		c.AuthenticationService.GRPCCtx,
+		ctx,
 		&jito_pb.GetRegionsRequest{},
 		opts...,
 	)
@@ -83,9 +95,13 @@ func (c *SearcherClient) GetRegions(opts ...grpc.CallOption) (*jito_pb.GetRegion
 
 // GetConnectedLeaders retrieves the connected leaders from the Searcher service.
 // It returns a ConnectedLeadersResponse or an error.
-func (c *SearcherClient) GetConnectedLeaders(opts ...grpc.CallOption) (*jito_pb.ConnectedLeadersResponse, error) {
+func (c *SearcherClient) GetConnectedLeaders(ctx context.Context, opts ...grpc.CallOption) (*jito_pb.ConnectedLeadersResponse, error) {
+	if err := c.throttle(ctx, "GetConnectedLeaders", 0); err != nil {
+		return nil, err
+	}
+
 	return c.SearcherService.GetConnectedLeaders(
-		c.AuthenticationService.GRPCCtx,
+		ctx,
 		&jito_pb.ConnectedLeadersRequest{},
 		opts...,
 	)
@@ -93,9 +109,13 @@ func (c *SearcherClient) GetConnectedLeaders(opts ...grpc.CallOption) (*jito_pb.
 
 // GetNextScheduledLeader retrieves the next scheduled leader for the specified regions from the Searcher service.
 // It returns a NextScheduledLeaderResponse or an error.
-func (c *SearcherClient) GetNextScheduledLeader(regions []string, opts ...grpc.CallOption) (*jito_pb.NextScheduledLeaderResponse, error) {
+func (c *SearcherClient) GetNextScheduledLeader(ctx context.Context, regions []string, opts ...grpc.CallOption) (*jito_pb.NextScheduledLeaderResponse, error) {
+	if err := c.throttle(ctx, "GetNextScheduledLeader", 0); err != nil {
+		return nil, err
+	}
+
 	return c.SearcherService.GetNextScheduledLeader(
-		c.AuthenticationService.GRPCCtx,
+		ctx,
 		&jito_pb.NextScheduledLeaderRequest{
 			Regions: regions,
 		},
@@ -105,9 +125,13 @@ func (c *SearcherClient) GetNextScheduledLeader(regions []string, opts ...grpc.C
 
 // GetConnectedLeadersRegioned retrieves the connected leaders for specified regions from the Searcher service.
 // It returns a ConnectedLeadersRegionedResponse or an error.
-func (c *SearcherClient) GetConnectedLeadersRegioned(regions []string, opts ...grpc.CallOption) (*jito_pb.ConnectedLeadersRegionedResponse, error) {
+func (c *SearcherClient) GetConnectedLeadersRegioned(ctx context.Context, regions []string, opts ...grpc.CallOption) (*jito_pb.ConnectedLeadersRegionedResponse, error) {
+	if err := c.throttle(ctx, "GetConnectedLeadersRegioned", 0); err != nil {
+		return nil, err
+	}
+
 	return c.SearcherService.GetConnectedLeadersRegioned(
-		c.AuthenticationService.GRPCCtx,
+		ctx,
 		&jito_pb.ConnectedLeadersRegionedRequest{
 			Regions: regions,
 		},
@@ -117,22 +141,38 @@ func (c *SearcherClient) GetConnectedLeadersRegioned(regions []string, opts ...g
 
 // GetTipAccounts retrieves the tip accounts from the Searcher service.
 // It returns a GetTipAccountsResponse or an error.
-func (c *SearcherClient) GetTipAccounts(opts ...grpc.CallOption) (*jito_pb.GetTipAccountsResponse, error) {
+func (c *SearcherClient) GetTipAccounts(ctx context.Context, opts ...grpc.CallOption) (*jito_pb.GetTipAccountsResponse, error) {
+	if err := c.throttle(ctx, "GetTipAccounts", 0); err != nil {
+		return nil, err
+	}
+
 	return c.SearcherService.GetTipAccounts(
-		c.AuthenticationService.GRPCCtx,
+		ctx,
 		&jito_pb.GetTipAccountsRequest{},
 		opts...,
 	)
 }
 
-// GetRandomTipAccount retrieves a random tip account from the list of tip accounts.
-// It returns the selected account or an error.
-func (c *SearcherClient) GetRandomTipAccount(opts ...grpc.CallOption) (string, error) {
-	resp, err := c.GetTipAccounts(opts...)
+// GetRandomTipAccount retrieves a random tip account from the cached list of tip accounts.
+// It returns the selected account, or ErrNoTipAccounts if the block engine reports none.
+func (c *SearcherClient) GetRandomTipAccount(ctx context.Context, opts ...grpc.CallOption) (string, error) {
+	accounts, err := c.TipAccounts.Accounts(ctx, opts...)
 	if err != nil {
 		return "", err
 	}
+	if len(accounts) == 0 {
+		return "", ErrNoTipAccounts
+	}
 
 	// Return a randomly selected account from the list of tip accounts
-	return resp.Accounts[rand.Intn(len(resp.Accounts))], nil
+	return accounts[rand.Intn(len(accounts))].String(), nil
+}
+
+// throttle waits for the client-side rate limit of the method, if a rate limiter is set.
+// Calls with a higher priority are released first.
+func (c *SearcherClient) throttle(ctx context.Context, method string, priority uint64) error {
+	if c.RateLimiter == nil {
+		return nil
+	}
+	return c.RateLimiter.Wait(ctx, c.Region, method, priority)
 }
@@ -0,0 +1,162 @@
+package block_engine
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	jito_pb "github.com/Prophet-Solutions/block-engine-protos/searcher"
+	"github.com/gagliardetto/solana-go"
+	"google.golang.org/grpc"
+)
+
+// fakeSearcherService is a searcher service accepting every bundle.
+// Methods it does not override panic through the nil embedded client.
+type fakeSearcherService struct {
+	jito_pb.SearcherServiceClient
+	tipAccounts []string                             // Tip accounts returned by GetTipAccounts
+	sendErr     error                                // Error returned by SendBundle, if any
+	bundles     []*jito_pb.SendBundleRequest         // Bundles received by SendBundle
+	uuid        func(n int) string                   // UUID of the nth bundle, bundle-<n> if nil
+	leader      *jito_pb.NextScheduledLeaderResponse // Response of GetNextScheduledLeader
+	leaderErr   error                                // Error returned by GetNextScheduledLeader, if any
+	mu          sync.Mutex
+}
+
+func (s *fakeSearcherService) SendBundle(
+	_ context.Context,
+	in *jito_pb.SendBundleRequest,
+	_ ...grpc.CallOption,
+) (*jito_pb.SendBundleResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sendErr != nil {
+		return nil, s.sendErr
+	}
+	s.bundles = append(s.bundles, in)
+
+	uuid := fmt.Sprintf("bundle-%d", len(s.bundles))
+	if s.uuid != nil {
+		uuid = s.uuid(len(s.bundles))
+	}
+	return &jito_pb.SendBundleResponse{Uuid: uuid}, nil
+}
+
+func (s *fakeSearcherService) GetTipAccounts(
+	context.Context,
+	*jito_pb.GetTipAccountsRequest,
+	...grpc.CallOption,
+) (*jito_pb.GetTipAccountsResponse, error) {
+	return &jito_pb.GetTipAccountsResponse{Accounts: s.tipAccounts}, nil
+}
+
+func (s *fakeSearcherService) GetNextScheduledLeader(
+	context.Context,
+	*jito_pb.NextScheduledLeaderRequest,
+	...grpc.CallOption,
+) (*jito_pb.NextScheduledLeaderResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.leaderErr != nil {
+		return nil, s.leaderErr
+	}
+	return s.leader, nil
+}
+
+// setLeader sets the current slot and the slot of the next leader.
+func (s *fakeSearcherService) setLeader(currentSlot, nextLeaderSlot uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.leader = &jito_pb.NextScheduledLeaderResponse{
+		CurrentSlot:        currentSlot,
+		NextLeaderSlot:     nextLeaderSlot,
+		NextLeaderIdentity: "leader",
+	}
+}
+
+// sent returns the number of bundles received.
+func (s *fakeSearcherService) sent() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.bundles)
+}
+
+// testSearcher is a SearcherClient wired like NewSearcherClient to fake services.
+type testSearcher struct {
+	*SearcherClient
+	service *fakeSearcherService
+	stream  *fakeBundleResultStream
+	rpc     *fakeRPC
+}
+
+// newTestSearcher returns a searcher client serving the tip accounts, backed by fake services.
+func newTestSearcher(t *testing.T, tipAccounts ...solana.PublicKey) *testSearcher {
+	t.Helper()
+
+	service := &fakeSearcherService{}
+	for _, account := range tipAccounts {
+		service.tipAccounts = append(service.tipAccounts, account.String())
+	}
+	stream := newFakeBundleResultStream()
+	t.Cleanup(stream.close)
+	server, rpcClient := newFakeRPC(t)
+
+	client := &SearcherClient{
+		RPCConn:                  rpcClient,
+		JitoRPCConn:              rpcClient,
+		SearcherService:          service,
+		BundleStreamSubscription: stream,
+		BundleResultDispatcher:   NewBundleResultDispatcher(stream, BundleResultBufferTTL, nil),
+	}
+	client.TipAccounts = NewTipAccountManager(client, TipAccountRoundRobin, DefaultTipAccountsTTL)
+	client.BundleTracker = NewBundleTracker(BundleTrackerRetention)
+	client.BundleResultDispatcher.AddListener(client.BundleTracker.ObserveResult)
+	client.BundleResultDispatcher.AddListener(client.notifyBundleResult)
+	client.BundleResultDispatcher.AddListener(client.releaseAccountLocks)
+
+	return &testSearcher{
+		SearcherClient: client,
+		service:        service,
+		stream:         stream,
+		rpc:            server,
+	}
+}
+
+// newTestBundle returns a signed bundle of a transfer and a tip to the tip account.
+func newTestBundle(t *testing.T, tipAccount solana.PublicKey) []*solana.Transaction {
+	t.Helper()
+	payer := newTestSigner(t)
+	client := newTestSearcher(t, tipAccount)
+
+	txs, err := client.NewBundleBuilder(payer.PublicKey(), 10_000).
+		AddInstructions(transferInstruction(payer.PublicKey(), 1)).
+		WithSigners(payer).
+		WithRecentBlockhash(solana.Hash{1}).
+		Build(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return txs
+}
+
+func TestSearcherClientGetRandomTipAccount(t *testing.T) {
+	tipAccount := solana.NewWallet().PublicKey()
+	client := newTestSearcher(t, tipAccount)
+
+	account, err := client.GetRandomTipAccount(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account != tipAccount.String() {
+		t.Errorf("GetRandomTipAccount() = %s, want %s", account, tipAccount)
+	}
+
+	if _, err = newTestSearcher(t).GetRandomTipAccount(context.Background()); err == nil {
+		t.Error("GetRandomTipAccount() succeeded without tip accounts")
+	}
+}
@@ -0,0 +1,273 @@
+package block_engine
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// SimulateBundleAccountsConfig selects the accounts returned before or after a transaction executes.
+type SimulateBundleAccountsConfig struct {
+	Addresses []solana.PublicKey // Accounts to return
+	Encoding  string             // Account data encoding, base64 if empty
+}
+
+// SimulateBundleOpts configures a bundle simulation.
+type SimulateBundleOpts struct {
+	PreExecutionAccountsConfigs  []*SimulateBundleAccountsConfig // Accounts returned before each transaction, indexed like the bundle
+	PostExecutionAccountsConfigs []*SimulateBundleAccountsConfig // Accounts returned after each transaction, indexed like the bundle
+	Commitment                   rpc.CommitmentType              // Bank to simulate on, the RPC default if empty
+	SkipSigVerify                bool                            // Whether to skip signature verification
+	ReplaceRecentBlockhash       bool                            // Whether to replace the blockhash with the bank's latest
+}
+
+// SimulateBundleResult is the outcome of a bundle simulation.
+type SimulateBundleResult struct {
+	Slot                   uint64                       // Slot the simulation ran on
+	Succeeded              bool                         // Whether every transaction of the bundle succeeded
+	FailedTransactionIndex int                          // Index of the failing transaction, -1 if none
+	FailedTxSignature      string                       // Signature of the failing transaction, if reported
+	Error                  json.RawMessage              // Bundle execution error as returned by the RPC
+	TransactionResults     []SimulatedTransactionResult // Results of the executed transactions in bundle order
+}
+
+// SimulatedTransactionResult is the simulation outcome of a single bundle transaction.
+type SimulatedTransactionResult struct {
+	Err                   interface{}          `json:"err"`                   // Transaction error, nil on success
+	Logs                  []string             `json:"logs"`                  // Program logs
+	PreExecutionAccounts  []*rpc.Account       `json:"preExecutionAccounts"`  // Requested accounts before execution
+	PostExecutionAccounts []*rpc.Account       `json:"postExecutionAccounts"` // Requested accounts after execution
+	UnitsConsumed         *uint64              `json:"unitsConsumed"`         // Compute units consumed
+	ReturnData            *SimulatedReturnData `json:"returnData"`            // Data returned by the last program
+}
+
+// SimulatedReturnData is the return data of a simulated transaction.
+type SimulatedReturnData struct {
+	ProgramID solana.PublicKey // Program that returned the data
+	Data      []byte           // Decoded return data
+}
+
+// UnmarshalJSON decodes the [data, encoding] pair returned by the RPC.
+func (d *SimulatedReturnData) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		ProgramID solana.PublicKey `json:"programId"`
+		Data      []string         `json:"data"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	d.ProgramID = raw.ProgramID
+	if len(raw.Data) == 0 {
+		return nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(raw.Data[0])
+	if err != nil {
+		return fmt.Errorf("could not decode return data: %w", err)
+	}
+	d.Data = decoded
+
+	return nil
+}
+
+// Err returns a SimulationFailureError describing the failing transaction, or nil if the simulation succeeded.
+func (r *SimulateBundleResult) Err() error {
+	if r.Succeeded {
+		return nil
+	}
+	return &SimulationFailureError{
+		TxSignature: r.FailedTxSignature,
+		Message:     string(r.Error),
+	}
+}
+
+// simulateBundleAccountsConfig is the JSON form of SimulateBundleAccountsConfig.
+type simulateBundleAccountsConfig struct {
+	Encoding  string   `json:"encoding"`
+	Addresses []string `json:"addresses"`
+}
+
+// simulateBundleConfig is the JSON form of SimulateBundleOpts.
+type simulateBundleConfig struct {
+	PreExecutionAccountsConfigs  []*simulateBundleAccountsConfig `json:"preExecutionAccountsConfigs"`
+	PostExecutionAccountsConfigs []*simulateBundleAccountsConfig `json:"postExecutionAccountsConfigs"`
+	TransactionEncoding          string                          `json:"transactionEncoding"`
+	SimulationBank               interface{}                     `json:"simulationBank,omitempty"`
+	SkipSigVerify                bool                            `json:"skipSigVerify"`
+	ReplaceRecentBlockhash       bool                            `json:"replaceRecentBlockhash"`
+}
+
+// simulateBundleResponse is the JSON response of the simulateBundle method.
+type simulateBundleResponse struct {
+	Context struct {
+		Slot uint64 `json:"slot"`
+	} `json:"context"`
+	Value struct {
+		Summary            simulateBundleSummary        `json:"summary"`
+		TransactionResults []SimulatedTransactionResult `json:"transactionResults"`
+	} `json:"value"`
+}
+
+// simulateBundleSummary is either the string "succeeded" or a {"failed": {...}} object.
+type simulateBundleSummary struct {
+	Succeeded   bool
+	Error       json.RawMessage
+	TxSignature string
+}
+
+// UnmarshalJSON decodes both forms of the simulation summary.
+func (s *simulateBundleSummary) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		s.Succeeded = name == "succeeded"
+		return nil
+	}
+
+	var failed struct {
+		Failed struct {
+			Error       json.RawMessage `json:"error"`
+			TxSignature *string         `json:"tx_signature"`
+		} `json:"failed"`
+	}
+	if err := json.Unmarshal(data, &failed); err != nil {
+		return err
+	}
+
+	s.Error = failed.Failed.Error
+	if failed.Failed.TxSignature != nil {
+		s.TxSignature = *failed.Failed.TxSignature
+	}
+
+	return nil
+}
+
+// SimulateBundle simulates a bundle of transactions with Jito's simulateBundle method on the Jito RPC connection.
+// It returns the per-transaction logs, compute units, return data and requested accounts, and the index of the
+// failing transaction if the bundle failed. A failed simulation is not returned as an error, see SimulateBundleResult.Err.
+func (c *SearcherClient) SimulateBundle(
+	ctx context.Context,
+	transactions []*solana.Transaction,
+	opts *SimulateBundleOpts,
+) (*SimulateBundleResult, error) {
+	if c.JitoRPCConn == nil {
+		return nil, errors.New("bundle simulation requires a Jito RPC connection")
+	}
+	if opts == nil {
+		opts = &SimulateBundleOpts{}
+	}
+
+	// Encode the transactions in base64
+	encoded := make([]string, 0, len(transactions))
+	for i, tx := range transactions {
+		data, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("could not serialize transaction %d: %w", i, err)
+		}
+		encoded = append(encoded, base64.StdEncoding.EncodeToString(data))
+	}
+
+	preConfigs, err := encodeSimulateBundleAccountsConfigs(opts.PreExecutionAccountsConfigs, len(transactions))
+	if err != nil {
+		return nil, err
+	}
+	postConfigs, err := encodeSimulateBundleAccountsConfigs(opts.PostExecutionAccountsConfigs, len(transactions))
+	if err != nil {
+		return nil, err
+	}
+
+	config := simulateBundleConfig{
+		PreExecutionAccountsConfigs:  preConfigs,
+		PostExecutionAccountsConfigs: postConfigs,
+		TransactionEncoding:          "base64",
+		SkipSigVerify:                opts.SkipSigVerify,
+		ReplaceRecentBlockhash:       opts.ReplaceRecentBlockhash,
+	}
+	if opts.Commitment != "" {
+		config.SimulationBank = map[string]interface{}{
+			"commitment": map[string]rpc.CommitmentType{"commitment": opts.Commitment},
+		}
+	}
+
+	var resp simulateBundleResponse
+	err = c.JitoRPCConn.RPCCallForInto(ctx, &resp, "simulateBundle", []interface{}{
+		map[string][]string{"encodedTransactions": encoded},
+		config,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not simulate bundle: %w", err)
+	}
+
+	result := &SimulateBundleResult{
+		Slot:                   resp.Context.Slot,
+		Succeeded:              resp.Value.Summary.Succeeded,
+		FailedTransactionIndex: -1,
+		FailedTxSignature:      resp.Value.Summary.TxSignature,
+		Error:                  resp.Value.Summary.Error,
+		TransactionResults:     resp.Value.TransactionResults,
+	}
+	if !result.Succeeded {
+		result.FailedTransactionIndex = failedTransactionIndex(transactions, result)
+	}
+
+	return result, nil
+}
+
+// encodeSimulateBundleAccountsConfigs converts the account configs to one JSON entry per transaction.
+func encodeSimulateBundleAccountsConfigs(
+	configs []*SimulateBundleAccountsConfig,
+	numTransactions int,
+) ([]*simulateBundleAccountsConfig, error) {
+	if len(configs) > numTransactions {
+		return nil, fmt.Errorf("%d account configs for %d transactions", len(configs), numTransactions)
+	}
+
+	encoded := make([]*simulateBundleAccountsConfig, numTransactions)
+	for i, config := range configs {
+		if config == nil {
+			continue
+		}
+
+		encoding := config.Encoding
+		if encoding == "" {
+			encoding = "base64"
+		}
+
+		addresses := make([]string, 0, len(config.Addresses))
+		for _, address := range config.Addresses {
+			addresses = append(addresses, address.String())
+		}
+
+		encoded[i] = &simulateBundleAccountsConfig{
+			Encoding:  encoding,
+			Addresses: addresses,
+		}
+	}
+
+	return encoded, nil
+}
+
+// failedTransactionIndex locates the failing transaction from the reported signature,
+// falling back to the first transaction result carrying an error.
+func failedTransactionIndex(transactions []*solana.Transaction, result *SimulateBundleResult) int {
+	if result.FailedTxSignature != "" {
+		for i, tx := range transactions {
+			if len(tx.Signatures) > 0 && tx.Signatures[0].String() == result.FailedTxSignature {
+				return i
+			}
+		}
+	}
+
+	for i, txResult := range result.TransactionResults {
+		if txResult.Err != nil {
+			return i
+		}
+	}
+
+	return -1
+}
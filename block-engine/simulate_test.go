@@ -0,0 +1,141 @@
+package block_engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func TestSimulateBundleSucceeded(t *testing.T) {
+	tipAccount := solana.NewWallet().PublicKey()
+	client := newTestSearcher(t, tipAccount)
+	txs := newTestBundle(t, tipAccount)
+	account := solana.NewWallet().PublicKey()
+
+	var params []json.RawMessage
+	client.rpc.handle("simulateBundle", func(p []json.RawMessage) (interface{}, error) {
+		params = p
+		return map[string]interface{}{
+			"context": map[string]interface{}{"slot": 42},
+			"value": map[string]interface{}{
+				"summary": "succeeded",
+				"transactionResults": []interface{}{map[string]interface{}{
+					"err":           nil,
+					"logs":          []string{"Program log: ok"},
+					"unitsConsumed": 450,
+					"returnData":    map[string]interface{}{"programId": tipAccount.String(), "data": []string{"AQID", "base64"}},
+				}},
+			},
+		}, nil
+	})
+
+	result, err := client.SimulateBundle(context.Background(), txs, &SimulateBundleOpts{
+		PreExecutionAccountsConfigs: []*SimulateBundleAccountsConfig{{Addresses: []solana.PublicKey{account}}},
+		Commitment:                  rpc.CommitmentConfirmed,
+		ReplaceRecentBlockhash:      true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.Succeeded || result.Slot != 42 || result.FailedTransactionIndex != -1 {
+		t.Errorf("result = %+v", result)
+	}
+	if err = result.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+	if len(result.TransactionResults) != 1 {
+		t.Fatalf("got %d transaction results, want 1", len(result.TransactionResults))
+	}
+	txResult := result.TransactionResults[0]
+	if txResult.UnitsConsumed == nil || *txResult.UnitsConsumed != 450 {
+		t.Errorf("UnitsConsumed = %v, want 450", txResult.UnitsConsumed)
+	}
+	if txResult.ReturnData == nil || string(txResult.ReturnData.Data) != "\x01\x02\x03" {
+		t.Errorf("ReturnData = %+v", txResult.ReturnData)
+	}
+
+	// The request carries one encoded transaction and one account config per transaction
+	var encoded struct {
+		EncodedTransactions []string `json:"encodedTransactions"`
+	}
+	var config struct {
+		PreExecutionAccountsConfigs []*struct {
+			Encoding  string   `json:"encoding"`
+			Addresses []string `json:"addresses"`
+		} `json:"preExecutionAccountsConfigs"`
+		TransactionEncoding    string                 `json:"transactionEncoding"`
+		SimulationBank         map[string]interface{} `json:"simulationBank"`
+		ReplaceRecentBlockhash bool                   `json:"replaceRecentBlockhash"`
+	}
+	if err = json.Unmarshal(params[0], &encoded); err != nil {
+		t.Fatal(err)
+	}
+	if err = json.Unmarshal(params[1], &config); err != nil {
+		t.Fatal(err)
+	}
+	if len(encoded.EncodedTransactions) != len(txs) {
+		t.Errorf("sent %d transactions, want %d", len(encoded.EncodedTransactions), len(txs))
+	}
+	if len(config.PreExecutionAccountsConfigs) != len(txs) ||
+		config.PreExecutionAccountsConfigs[0].Addresses[0] != account.String() ||
+		config.PreExecutionAccountsConfigs[0].Encoding != "base64" {
+		t.Errorf("pre-execution configs = %+v", config.PreExecutionAccountsConfigs)
+	}
+	if config.TransactionEncoding != "base64" || !config.ReplaceRecentBlockhash || config.SimulationBank == nil {
+		t.Errorf("config = %+v", config)
+	}
+}
+
+func TestSimulateBundleFailed(t *testing.T) {
+	tipAccount := solana.NewWallet().PublicKey()
+	client := newTestSearcher(t, tipAccount)
+	txs := newTestBundle(t, tipAccount)
+	signature := txs[0].Signatures[0].String()
+
+	client.rpc.handle("simulateBundle", func([]json.RawMessage) (interface{}, error) {
+		return map[string]interface{}{
+			"context": map[string]interface{}{"slot": 42},
+			"value": map[string]interface{}{
+				"summary": map[string]interface{}{"failed": map[string]interface{}{
+					"error":        map[string]interface{}{"TransactionFailure": []interface{}{[]int{1}, "failed"}},
+					"tx_signature": signature,
+				}},
+				"transactionResults": []interface{}{},
+			},
+		}, nil
+	})
+
+	result, err := client.SimulateBundle(context.Background(), txs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Succeeded || result.FailedTransactionIndex != 0 || result.FailedTxSignature != signature {
+		t.Errorf("result = %+v", result)
+	}
+
+	var simErr *SimulationFailureError
+	if err = result.Err(); !errors.As(err, &simErr) || simErr.TxSignature != signature {
+		t.Errorf("Err() = %v, want a *SimulationFailureError for %s", err, signature)
+	}
+}
+
+func TestSimulateBundleErrors(t *testing.T) {
+	tipAccount := solana.NewWallet().PublicKey()
+	txs := newTestBundle(t, tipAccount)
+
+	client := newTestSearcher(t, tipAccount)
+	tooMany := make([]*SimulateBundleAccountsConfig, len(txs)+1)
+	if _, err := client.SimulateBundle(context.Background(), txs, &SimulateBundleOpts{PreExecutionAccountsConfigs: tooMany}); err == nil {
+		t.Error("more account configs than transactions accepted")
+	}
+
+	client.JitoRPCConn = nil
+	if _, err := client.SimulateBundle(context.Background(), txs, nil); err == nil {
+		t.Error("simulation without a Jito RPC connection succeeded")
+	}
+}
@@ -0,0 +1,270 @@
+package block_engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	"github.com/gagliardetto/solana-go"
+	"google.golang.org/grpc"
+)
+
+// DefaultTipAccountsTTL is how long the cached tip accounts are served before being refreshed.
+const DefaultTipAccountsTTL = 10 * time.Minute
+
+// ErrNoTipAccounts is returned when the block engine reports no tip accounts.
+var ErrNoTipAccounts = errors.New("no tip accounts available")
+
+// TipAccountStrategy selects the tip account used for a bundle.
+type TipAccountStrategy int
+
+// Tip account selection strategies
+const (
+	TipAccountRandom            TipAccountStrategy = iota // Pick a random tip account
+	TipAccountRoundRobin                                  // Cycle through the tip accounts in order
+	TipAccountLeastRecentlyUsed                           // Pick the tip account our bundles used least recently
+)
+
+// TipAccountManager caches the tip accounts of the block engine and selects the tip account for each bundle.
+// Spreading concurrent bundles over several tip accounts keeps them from write-locking the same account.
+type TipAccountManager struct {
+	fetch     func(ctx context.Context, opts ...grpc.CallOption) ([]string, error) // Fetches the tip accounts from the block engine
+	strategy  TipAccountStrategy                                                   // Tip account selection strategy
+	ttl       time.Duration                                                        // How long the cached tip accounts are served
+	accounts  []solana.PublicKey                                                   // Cached tip accounts
+	known     map[solana.PublicKey]struct{}                                        // Cached tip accounts as a set
+	fetchedAt time.Time                                                            // Time the tip accounts were last fetched
+	next      int                                                                  // Index of the next round-robin tip account
+	lastUsed  map[solana.PublicKey]time.Time                                       // Last time each tip account was selected for our bundles
+	publish   func(pkg.Event)                                                      // Publishes the background refresh errors, nil to discard them
+	mu        sync.Mutex                                                           // Mutex for synchronizing the cache and selection state
+	refreshMu sync.Mutex                                                           // Mutex serializing refreshes
+}
+
+// NewTipAccountManager creates a TipAccountManager fetching the tip accounts through the searcher client.
+// The tip accounts are fetched on first use and refreshed once older than ttl, DefaultTipAccountsTTL if zero.
+// Background refresh errors are published on the client Events as pkg.EventRefreshError.
+func NewTipAccountManager(client *SearcherClient, strategy TipAccountStrategy, ttl time.Duration) *TipAccountManager {
+	m := newTipAccountManager(func(ctx context.Context, opts ...grpc.CallOption) ([]string, error) {
+		resp, err := client.GetTipAccounts(ctx, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return resp.GetAccounts(), nil
+	}, strategy, ttl)
+	m.publish = func(event pkg.Event) { client.Events.Publish(event) }
+	return m
+}
+
+// newTipAccountManager creates a TipAccountManager fetching the tip accounts with the given function.
+func newTipAccountManager(
+	fetch func(ctx context.Context, opts ...grpc.CallOption) ([]string, error),
+	strategy TipAccountStrategy,
+	ttl time.Duration,
+) *TipAccountManager {
+	if ttl <= 0 {
+		ttl = DefaultTipAccountsTTL
+	}
+
+	return &TipAccountManager{
+		fetch:    fetch,
+		strategy: strategy,
+		ttl:      ttl,
+		known:    make(map[solana.PublicKey]struct{}),
+		lastUsed: make(map[solana.PublicKey]time.Time),
+	}
+}
+
+// SetStrategy changes the tip account selection strategy.
+func (m *TipAccountManager) SetStrategy(strategy TipAccountStrategy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.strategy = strategy
+}
+
+// Start refreshes the tip accounts every TTL until the context is done, so lookups never wait on the network.
+func (m *TipAccountManager) Start(ctx context.Context, opts ...grpc.CallOption) {
+	if err := m.Refresh(ctx, opts...); err != nil {
+		m.refreshError(err)
+	}
+
+	ticker := time.NewTicker(m.ttl)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Refresh(ctx, opts...); err != nil {
+				m.refreshError(err)
+			}
+		}
+	}
+}
+
+// Refresh fetches the tip accounts from the block engine and replaces the cache.
+func (m *TipAccountManager) Refresh(ctx context.Context, opts ...grpc.CallOption) error {
+	m.refreshMu.Lock()
+	defer m.refreshMu.Unlock()
+
+	return m.refresh(ctx, opts...)
+}
+
+// Accounts returns the cached tip accounts, fetching them if the cache is empty or stale.
+func (m *TipAccountManager) Accounts(ctx context.Context, opts ...grpc.CallOption) ([]solana.PublicKey, error) {
+	if err := m.ensureFresh(ctx, opts...); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]solana.PublicKey(nil), m.accounts...), nil
+}
+
+// IsTipAccount reports whether the public key is one of the tip accounts.
+func (m *TipAccountManager) IsTipAccount(ctx context.Context, pubkey solana.PublicKey, opts ...grpc.CallOption) (bool, error) {
+	if err := m.ensureFresh(ctx, opts...); err != nil {
+		return false, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.known[pubkey]
+	return ok, nil
+}
+
+// Next selects a tip account with the configured strategy and records its use by our bundles.
+func (m *TipAccountManager) Next(ctx context.Context, opts ...grpc.CallOption) (solana.PublicKey, error) {
+	if err := m.ensureFresh(ctx, opts...); err != nil {
+		return solana.PublicKey{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.accounts) == 0 {
+		return solana.PublicKey{}, ErrNoTipAccounts
+	}
+
+	var account solana.PublicKey
+	switch m.strategy {
+	case TipAccountRoundRobin:
+		account = m.accounts[m.next%len(m.accounts)]
+		m.next = (m.next + 1) % len(m.accounts)
+	case TipAccountLeastRecentlyUsed:
+		account = m.accounts[0]
+		for _, candidate := range m.accounts[1:] {
+			if m.lastUsed[candidate].Before(m.lastUsed[account]) {
+				account = candidate
+			}
+		}
+	default:
+		account = m.accounts[rand.Intn(len(m.accounts))]
+	}
+
+	m.lastUsed[account] = time.Now()
+	return account, nil
+}
+
+// MarkUsed records that one of our bundles tipped the account without selecting it through Next.
+func (m *TipAccountManager) MarkUsed(pubkey solana.PublicKey) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.known[pubkey]; ok {
+		m.lastUsed[pubkey] = time.Now()
+	}
+}
+
+// ensureFresh refreshes the cache if it is empty or older than the TTL.
+// A failed refresh is only reported if there are no cached tip accounts to fall back on.
+func (m *TipAccountManager) ensureFresh(ctx context.Context, opts ...grpc.CallOption) error {
+	if !m.stale() {
+		return nil
+	}
+
+	m.refreshMu.Lock()
+	defer m.refreshMu.Unlock()
+
+	// Another caller may have refreshed while we waited
+	if !m.stale() {
+		return nil
+	}
+
+	if err := m.refresh(ctx, opts...); err != nil {
+		m.mu.Lock()
+		cached := len(m.accounts)
+		m.mu.Unlock()
+
+		if cached == 0 {
+			return err
+		}
+		m.refreshError(err)
+	}
+
+	return nil
+}
+
+// refreshError publishes the error of a refresh that was not returned to a caller.
+func (m *TipAccountManager) refreshError(err error) {
+	if m.publish != nil {
+		m.publish(pkg.Event{Kind: pkg.EventRefreshError, Source: "GetTipAccounts", Err: err})
+	}
+}
+
+// stale reports whether the cache is empty or older than the TTL.
+func (m *TipAccountManager) stale() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.accounts) == 0 || time.Since(m.fetchedAt) > m.ttl
+}
+
+// refresh fetches the tip accounts and replaces the cache. It must be called with refreshMu held.
+func (m *TipAccountManager) refresh(ctx context.Context, opts ...grpc.CallOption) error {
+	tipAccounts, err := m.fetch(ctx, opts...)
+	if err != nil {
+		return fmt.Errorf("could not get tip accounts: %w", err)
+	}
+	if len(tipAccounts) == 0 {
+		return ErrNoTipAccounts
+	}
+
+	accounts := make([]solana.PublicKey, 0, len(tipAccounts))
+	known := make(map[solana.PublicKey]struct{}, len(tipAccounts))
+	for _, account := range tipAccounts {
+		pubkey, err := solana.PublicKeyFromBase58(account)
+		if err != nil {
+			return fmt.Errorf("invalid tip account %s: %w", account, err)
+		}
+		accounts = append(accounts, pubkey)
+		known[pubkey] = struct{}{}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.accounts = accounts
+	m.known = known
+	m.fetchedAt = time.Now()
+	if m.next >= len(accounts) {
+		m.next = 0
+	}
+
+	// Forget the usage of accounts that are no longer tip accounts
+	for pubkey := range m.lastUsed {
+		if _, ok := known[pubkey]; !ok {
+			delete(m.lastUsed, pubkey)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package block_engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	"github.com/gagliardetto/solana-go"
+	"google.golang.org/grpc"
+)
+
+// fakeTipAccountsFetch serves the tip accounts and counts the fetches.
+type fakeTipAccountsFetch struct {
+	accounts []string
+	err      error
+	calls    int
+}
+
+func (f *fakeTipAccountsFetch) fetch(context.Context, ...grpc.CallOption) ([]string, error) {
+	f.calls++
+	return f.accounts, f.err
+}
+
+func newTipAccounts(n int) ([]solana.PublicKey, []string) {
+	pubkeys := make([]solana.PublicKey, n)
+	accounts := make([]string, n)
+	for i := range pubkeys {
+		pubkeys[i] = solana.NewWallet().PublicKey()
+		accounts[i] = pubkeys[i].String()
+	}
+	return pubkeys, accounts
+}
+
+func TestTipAccountManagerRoundRobin(t *testing.T) {
+	pubkeys, accounts := newTipAccounts(3)
+	source := &fakeTipAccountsFetch{accounts: accounts}
+	m := newTipAccountManager(source.fetch, TipAccountRoundRobin, time.Minute)
+
+	for i := 0; i < 2*len(pubkeys); i++ {
+		account, err := m.Next(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := pubkeys[i%len(pubkeys)]; account != want {
+			t.Errorf("selection %d = %s, want %s", i, account, want)
+		}
+	}
+	if source.calls != 1 {
+		t.Errorf("fetched the tip accounts %d times, want 1", source.calls)
+	}
+}
+
+func TestTipAccountManagerLeastRecentlyUsed(t *testing.T) {
+	pubkeys, accounts := newTipAccounts(3)
+	source := &fakeTipAccountsFetch{accounts: accounts}
+	m := newTipAccountManager(source.fetch, TipAccountLeastRecentlyUsed, time.Minute)
+
+	if err := m.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	m.MarkUsed(pubkeys[0])
+	time.Sleep(time.Millisecond)
+	m.MarkUsed(pubkeys[2])
+
+	account, err := m.Next(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account != pubkeys[1] {
+		t.Errorf("Next() = %s, want the unused account %s", account, pubkeys[1])
+	}
+	if account, _ = m.Next(context.Background()); account != pubkeys[0] {
+		t.Errorf("Next() = %s, want the least recently used account %s", account, pubkeys[0])
+	}
+}
+
+func TestTipAccountManagerRefresh(t *testing.T) {
+	pubkeys, accounts := newTipAccounts(2)
+	source := &fakeTipAccountsFetch{accounts: accounts}
+	m := newTipAccountManager(source.fetch, TipAccountRandom, 10*time.Millisecond)
+	ctx := context.Background()
+
+	if ok, err := m.IsTipAccount(ctx, pubkeys[1]); err != nil || !ok {
+		t.Fatalf("IsTipAccount() = %v, %v, want true", ok, err)
+	}
+
+	// A stale cache is refetched, and served if the refresh fails, whose error is published
+	var events []pkg.Event
+	m.publish = func(event pkg.Event) { events = append(events, event) }
+	time.Sleep(20 * time.Millisecond)
+	source.err = errors.New("unavailable")
+	got, err := m.Accounts(ctx)
+	if err != nil {
+		t.Fatalf("stale cache not served on refresh failure: %v", err)
+	}
+	if len(got) != len(pubkeys) || source.calls != 2 {
+		t.Errorf("Accounts() = %v after %d fetches", got, source.calls)
+	}
+	if len(events) != 1 || events[0].Kind != pkg.EventRefreshError || !errors.Is(events[0].Err, source.err) {
+		t.Errorf("events = %v, want the refresh error", events)
+	}
+
+	// New tip accounts replace the cache
+	newPubkeys, newAccounts := newTipAccounts(1)
+	source.accounts, source.err = newAccounts, nil
+	if err = m.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := m.IsTipAccount(ctx, pubkeys[0]); ok {
+		t.Error("former tip account still known")
+	}
+	if ok, _ := m.IsTipAccount(ctx, newPubkeys[0]); !ok {
+		t.Error("new tip account unknown")
+	}
+}
+
+func TestTipAccountManagerErrors(t *testing.T) {
+	empty := newTipAccountManager((&fakeTipAccountsFetch{}).fetch, TipAccountRandom, 0)
+	if _, err := empty.Next(context.Background()); !errors.Is(err, ErrNoTipAccounts) {
+		t.Errorf("no tip accounts: got %v, want ErrNoTipAccounts", err)
+	}
+
+	invalid := newTipAccountManager((&fakeTipAccountsFetch{accounts: []string{"not a key"}}).fetch, TipAccountRandom, 0)
+	if _, err := invalid.Accounts(context.Background()); err == nil {
+		t.Error("invalid tip account accepted")
+	}
+
+	failing := newTipAccountManager((&fakeTipAccountsFetch{err: errors.New("unavailable")}).fetch, TipAccountRandom, 0)
+	if _, err := failing.Next(context.Background()); err == nil {
+		t.Error("fetch error not reported without cached accounts")
+	}
+}
@@ -0,0 +1,394 @@
+package block_engine
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	bundle_pb "github.com/Prophet-Solutions/block-engine-protos/bundle"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Constants for the default tip estimator configuration
+const (
+	DefaultTipEstimatorWindow     = 10 * time.Minute // Age of the oldest sample used for estimates
+	DefaultTipEstimatorMaxSamples = 1000             // Number of samples kept in the window
+	DefaultTipEstimatorMinSamples = 10               // Number of samples required before recommending a tip
+)
+
+// ErrNotEnoughTipSamples is returned when the estimator has too few samples to recommend a tip.
+var ErrNotEnoughTipSamples = errors.New("not enough tip samples")
+
+// TipEstimatorConfig configures a TipEstimator.
+type TipEstimatorConfig struct {
+	Window          time.Duration // Age of the oldest sample used for estimates
+	MaxSamples      int           // Number of samples kept in the window
+	MinSamples      int           // Number of samples required before recommending a tip
+	FloorLamports   uint64        // Lowest tip recommended
+	CeilingLamports uint64        // Highest tip recommended, 0 for no ceiling
+}
+
+// DefaultTipEstimatorConfig returns the default TipEstimator configuration, without a ceiling.
+func DefaultTipEstimatorConfig() TipEstimatorConfig {
+	return TipEstimatorConfig{
+		Window:     DefaultTipEstimatorWindow,
+		MaxSamples: DefaultTipEstimatorMaxSamples,
+		MinSamples: DefaultTipEstimatorMinSamples,
+	}
+}
+
+// TipDistribution summarizes a set of tips in lamports.
+type TipDistribution struct {
+	Count int    // Number of tips
+	Min   uint64 // Lowest tip
+	Max   uint64 // Highest tip
+	Mean  uint64 // Mean tip
+	P25   uint64 // 25th percentile
+	P50   uint64 // Median
+	P75   uint64 // 75th percentile
+	P95   uint64 // 95th percentile
+}
+
+// AuctionTipStats summarizes our bids in a single auction.
+// The block engine reports the auction of rejected bids only, so accepted bids are grouped per slot
+// they were forwarded in, under the auction identifier returned by SlotAuctionID.
+type AuctionTipStats struct {
+	AuctionID        string          // Auction identifier reported by the block engine, or SlotAuctionID
+	WinningBids      TipDistribution // Our bids accepted in the auction
+	LosingBids       TipDistribution // Our bids rejected in the auction
+	HighestLosingBid uint64          // Lower bound of the winning tip
+	LastSeen         time.Time       // Time of the most recent bid in the auction
+}
+
+// SlotAuctionID returns the auction identifier under which the bids accepted for the slot are grouped.
+func SlotAuctionID(slot uint64) string {
+	return fmt.Sprintf("slot-%d", slot)
+}
+
+// tipSample is a single bid with its outcome.
+type tipSample struct {
+	lamports   uint64
+	won        bool
+	auctionID  string
+	observedAt time.Time
+}
+
+// trackedTip is the tip of a sent bundle waiting for its result.
+type trackedTip struct {
+	lamports  uint64
+	trackedAt time.Time
+}
+
+// acceptedBid is the acceptance of a bundle whose tip was not tracked yet.
+type acceptedBid struct {
+	slot       uint64
+	observedAt time.Time
+}
+
+// TipEstimator learns the tip needed to win bundle auctions from the results of our own bundles.
+// Accepted bundles contribute the tip recorded with Track, rejected auction bids contribute
+// the simulated bid reported by the block engine.
+type TipEstimator struct {
+	config   TipEstimatorConfig     // Estimator configuration
+	samples  []tipSample            // Samples in the window, oldest first
+	tracked  map[string]trackedTip  // Tips of sent bundles indexed by bundle UUID
+	accepted map[string]acceptedBid // Acceptances received before the tip was tracked, indexed by bundle UUID
+	mu       sync.Mutex             // Mutex for synchronizing the samples
+}
+
+// NewTipEstimator creates a TipEstimator with the given configuration.
+// Results must be fed with Observe, or use SearcherClient.NewTipEstimator to subscribe automatically.
+func NewTipEstimator(config TipEstimatorConfig) *TipEstimator {
+	if config.Window <= 0 {
+		config.Window = DefaultTipEstimatorWindow
+	}
+	if config.MaxSamples <= 0 {
+		config.MaxSamples = DefaultTipEstimatorMaxSamples
+	}
+
+	return &TipEstimator{
+		config:   config,
+		tracked:  make(map[string]trackedTip),
+		accepted: make(map[string]acceptedBid),
+	}
+}
+
+// NewTipEstimator creates a TipEstimator attached to the searcher client as a BundleHook.
+// It tracks the tip of every bundle sent with SendBundle and is fed with every bundle result received.
+// It must be created before sending bundles.
+func (c *SearcherClient) NewTipEstimator(config TipEstimatorConfig) *TipEstimator {
+	e := NewTipEstimator(config)
+	c.AddBundleHook(e)
+	return e
+}
+
+// Track records the tip paid by a sent bundle so its acceptance can be attributed a tip.
+// An acceptance received before the bundle was tracked is counted now.
+func (e *TipEstimator) Track(uuid string, tipLamports uint64) {
+	now := time.Now()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.prune(now)
+
+	if accepted, ok := e.accepted[uuid]; ok {
+		delete(e.accepted, uuid)
+		e.add(tipSample{lamports: tipLamports, won: true, auctionID: SlotAuctionID(accepted.slot), observedAt: now})
+		return
+	}
+
+	e.tracked[uuid] = trackedTip{
+		lamports:  tipLamports,
+		trackedAt: now,
+	}
+}
+
+// OnBundleSent tracks the tip of a bundle sent by the searcher client.
+func (e *TipEstimator) OnBundleSent(bundle *SentBundle) {
+	e.Track(bundle.UUID, bundle.TipLamports)
+}
+
+// OnBundleResult ingests a bundle result received by the searcher client.
+func (e *TipEstimator) OnBundleResult(result *bundle_pb.BundleResult) {
+	e.Observe(result)
+}
+
+// OnBundleConfirmed is a no-op, confirmations carry no tip information.
+func (e *TipEstimator) OnBundleConfirmed(string, []solana.Signature, *rpc.GetSignatureStatusesResult, error) {
+}
+
+// Observe ingests a bundle result. Acceptances of tracked bundles count as won bids and auction
+// rejections as lost bids; other results carry no tip information and are ignored.
+func (e *TipEstimator) Observe(result *bundle_pb.BundleResult) {
+	now := time.Now()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.prune(now)
+
+	uuid := result.GetBundleId()
+	tracked, isTracked := e.tracked[uuid]
+
+	switch result.Result.(type) {
+	case *bundle_pb.BundleResult_Accepted:
+		slot := result.GetAccepted().GetSlot()
+		if !isTracked {
+			// The result may arrive before the sender tracked the tip
+			if _, ok := e.accepted[uuid]; !ok {
+				e.accepted[uuid] = acceptedBid{slot: slot, observedAt: now}
+			}
+			return
+		}
+		e.add(tipSample{lamports: tracked.lamports, won: true, auctionID: SlotAuctionID(slot), observedAt: now})
+	case *bundle_pb.BundleResult_Rejected:
+		var (
+			stateAuction *StateAuctionBidRejectedError
+			batchAuction *WinningBatchBidRejectedError
+		)
+		err := NewBundleRejectionError(uuid, result.GetRejected())
+		switch {
+		case errors.As(err, &stateAuction):
+			e.add(tipSample{lamports: stateAuction.SimulatedBidLamports, auctionID: stateAuction.AuctionID, observedAt: now})
+		case errors.As(err, &batchAuction):
+			e.add(tipSample{lamports: batchAuction.SimulatedBidLamports, auctionID: batchAuction.AuctionID, observedAt: now})
+		default:
+			return
+		}
+	default:
+		return
+	}
+
+	// A bundle is only counted once, even if several regions report it
+	delete(e.tracked, uuid)
+}
+
+// WinProbability estimates the probability that a bid of the given tip wins.
+// Accepted bundles that paid at most the tip count in favor of it, rejected bids of at least the tip against it.
+func (e *TipEstimator) WinProbability(tipLamports uint64) float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.prune(time.Now())
+	return winProbability(e.samples, tipLamports)
+}
+
+// Recommend returns the lowest observed tip whose estimated win probability reaches the target,
+// clamped between the configured floor and ceiling. It returns ErrNotEnoughTipSamples until
+// MinSamples samples are in the window.
+func (e *TipEstimator) Recommend(probability float64) (uint64, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.prune(time.Now())
+	if len(e.samples) == 0 || len(e.samples) < e.config.MinSamples {
+		return 0, ErrNotEnoughTipSamples
+	}
+
+	candidates := make([]uint64, 0, len(e.samples))
+	for _, sample := range e.samples {
+		candidates = append(candidates, sample.lamports)
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i] < candidates[j] })
+
+	// Outbid the highest sample if no observed tip reaches the target
+	tip := candidates[len(candidates)-1] + 1
+	for _, candidate := range candidates {
+		if winProbability(e.samples, candidate) >= probability {
+			tip = candidate
+			break
+		}
+	}
+
+	if tip < e.config.FloorLamports {
+		tip = e.config.FloorLamports
+	}
+	if e.config.CeilingLamports > 0 && tip > e.config.CeilingLamports {
+		tip = e.config.CeilingLamports
+	}
+
+	return tip, nil
+}
+
+// WinningTips returns the distribution of the tips paid by our accepted bundles in the window.
+func (e *TipEstimator) WinningTips() TipDistribution {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.prune(time.Now())
+
+	var tips []uint64
+	for _, sample := range e.samples {
+		if sample.won {
+			tips = append(tips, sample.lamports)
+		}
+	}
+	return newTipDistribution(tips)
+}
+
+// Auctions returns the bid statistics of every auction in the window, most recent first.
+func (e *TipEstimator) Auctions() []AuctionTipStats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.prune(time.Now())
+
+	winning := make(map[string][]uint64)
+	losing := make(map[string][]uint64)
+	lastSeen := make(map[string]time.Time)
+	for _, sample := range e.samples {
+		if sample.auctionID == "" {
+			continue
+		}
+		if sample.won {
+			winning[sample.auctionID] = append(winning[sample.auctionID], sample.lamports)
+		} else {
+			losing[sample.auctionID] = append(losing[sample.auctionID], sample.lamports)
+		}
+		if sample.observedAt.After(lastSeen[sample.auctionID]) {
+			lastSeen[sample.auctionID] = sample.observedAt
+		}
+	}
+
+	stats := make([]AuctionTipStats, 0, len(lastSeen))
+	for auctionID, seenAt := range lastSeen {
+		losingBids := newTipDistribution(losing[auctionID])
+		stats = append(stats, AuctionTipStats{
+			AuctionID:        auctionID,
+			WinningBids:      newTipDistribution(winning[auctionID]),
+			LosingBids:       losingBids,
+			HighestLosingBid: losingBids.Max,
+			LastSeen:         seenAt,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].LastSeen.After(stats[j].LastSeen) })
+
+	return stats
+}
+
+// add appends a sample, evicting the oldest once the window is full.
+func (e *TipEstimator) add(sample tipSample) {
+	e.samples = append(e.samples, sample)
+	if len(e.samples) > e.config.MaxSamples {
+		e.samples = e.samples[len(e.samples)-e.config.MaxSamples:]
+	}
+}
+
+// prune drops the samples, tracked tips and untracked acceptances older than the window.
+func (e *TipEstimator) prune(now time.Time) {
+	cutoff := now.Add(-e.config.Window)
+
+	i := 0
+	for i < len(e.samples) && e.samples[i].observedAt.Before(cutoff) {
+		i++
+	}
+	e.samples = e.samples[i:]
+
+	for uuid, tracked := range e.tracked {
+		if tracked.trackedAt.Before(cutoff) {
+			delete(e.tracked, uuid)
+		}
+	}
+	for uuid, accepted := range e.accepted {
+		if accepted.observedAt.Before(cutoff) {
+			delete(e.accepted, uuid)
+		}
+	}
+}
+
+// winProbability estimates the probability that a bid of the given tip wins against the samples.
+func winProbability(samples []tipSample, tipLamports uint64) float64 {
+	var wins, losses int
+	for _, sample := range samples {
+		switch {
+		case sample.won && sample.lamports <= tipLamports:
+			wins++
+		case !sample.won && sample.lamports >= tipLamports:
+			losses++
+		}
+	}
+
+	if wins+losses == 0 {
+		return 0
+	}
+	return float64(wins) / float64(wins+losses)
+}
+
+// newTipDistribution summarizes the tips.
+func newTipDistribution(tips []uint64) TipDistribution {
+	if len(tips) == 0 {
+		return TipDistribution{}
+	}
+
+	sorted := append([]uint64(nil), tips...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var sum float64
+	for _, tip := range sorted {
+		sum += float64(tip)
+	}
+
+	// Nearest-rank percentile of the sorted tips
+	percentile := func(p int) uint64 {
+		rank := (p*len(sorted) + 99) / 100
+		if rank < 1 {
+			rank = 1
+		}
+		return sorted[rank-1]
+	}
+
+	return TipDistribution{
+		Count: len(sorted),
+		Min:   sorted[0],
+		Max:   sorted[len(sorted)-1],
+		Mean:  uint64(sum / float64(len(sorted))),
+		P25:   percentile(25),
+		P50:   percentile(50),
+		P75:   percentile(75),
+		P95:   percentile(95),
+	}
+}
@@ -0,0 +1,133 @@
+package block_engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	bundle_pb "github.com/Prophet-Solutions/block-engine-protos/bundle"
+	"github.com/gagliardetto/solana-go"
+)
+
+func auctionRejectedResult(uuid, auctionID string, bidLamports uint64) *bundle_pb.BundleResult {
+	return &bundle_pb.BundleResult{BundleId: uuid, Result: &bundle_pb.BundleResult_Rejected{
+		Rejected: &bundle_pb.Rejected{Reason: &bundle_pb.Rejected_StateAuctionBidRejected{
+			StateAuctionBidRejected: &bundle_pb.StateAuctionBidRejected{AuctionId: auctionID, SimulatedBidLamports: bidLamports},
+		}},
+	}}
+}
+
+func TestTipEstimatorAuctions(t *testing.T) {
+	e := NewTipEstimator(DefaultTipEstimatorConfig())
+
+	e.Track("won", 5_000)
+	e.Observe(acceptedResult("won"))
+	e.Observe(acceptedResult("untracked"))
+	e.Observe(auctionRejectedResult("lost", "auction", 3_000))
+	e.Observe(auctionRejectedResult("lost-again", "auction", 4_000))
+
+	if winning := e.WinningTips(); winning.Count != 1 || winning.P50 != 5_000 {
+		t.Errorf("WinningTips() = %+v, want a single 5000 tip", winning)
+	}
+
+	auctions := make(map[string]AuctionTipStats)
+	for _, stats := range e.Auctions() {
+		auctions[stats.AuctionID] = stats
+	}
+	if len(auctions) != 2 {
+		t.Fatalf("Auctions() = %+v, want the rejecting auction and the accepted slot", auctions)
+	}
+	if lost := auctions["auction"]; lost.LosingBids.Count != 2 || lost.HighestLosingBid != 4_000 || lost.WinningBids.Count != 0 {
+		t.Errorf("rejecting auction = %+v", lost)
+	}
+	if won := auctions[SlotAuctionID(1)]; won.WinningBids.Count != 1 || won.WinningBids.Max != 5_000 || won.LosingBids.Count != 0 {
+		t.Errorf("accepted slot = %+v", won)
+	}
+}
+
+func TestTipEstimatorAcceptedBeforeTrack(t *testing.T) {
+	e := NewTipEstimator(DefaultTipEstimatorConfig())
+
+	e.Observe(acceptedResult("uuid"))
+	if e.WinningTips().Count != 0 {
+		t.Fatal("untracked acceptance counted")
+	}
+
+	e.Track("uuid", 7_000)
+	if winning := e.WinningTips(); winning.Count != 1 || winning.Max != 7_000 {
+		t.Errorf("WinningTips() = %+v, want the acceptance counted once tracked", winning)
+	}
+
+	// A bundle counts once, even if another region reports it again
+	e.Observe(acceptedResult("uuid"))
+	if got := e.WinningTips().Count; got != 1 {
+		t.Errorf("got %d winning tips after a duplicate acceptance, want 1", got)
+	}
+}
+
+func TestTipEstimatorRecommend(t *testing.T) {
+	config := DefaultTipEstimatorConfig()
+	config.MinSamples = 3
+	e := NewTipEstimator(config)
+
+	if _, err := e.Recommend(0.5); !errors.Is(err, ErrNotEnoughTipSamples) {
+		t.Errorf("empty estimator: got %v, want ErrNotEnoughTipSamples", err)
+	}
+
+	e.Track("a", 100)
+	e.Observe(acceptedResult("a"))
+	e.Track("b", 200)
+	e.Observe(acceptedResult("b"))
+	e.Observe(auctionRejectedResult("c", "auction", 150))
+
+	if p := e.WinProbability(100); p != 0.5 {
+		t.Errorf("WinProbability(100) = %v, want 0.5", p)
+	}
+
+	tests := []struct {
+		name     string
+		floor    uint64
+		ceiling  uint64
+		expected uint64
+	}{
+		{name: "unclamped", expected: 200},
+		{name: "ceiling", ceiling: 180, expected: 180},
+		{name: "floor", floor: 300, expected: 300},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e.config.FloorLamports, e.config.CeilingLamports = tt.floor, tt.ceiling
+			tip, err := e.Recommend(0.8)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tip != tt.expected {
+				t.Errorf("Recommend(0.8) = %d, want %d", tip, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSearcherClientTipEstimatorTracksSentBundles(t *testing.T) {
+	tipAccount := solana.NewWallet().PublicKey()
+	client := newTestSearcher(t, tipAccount)
+	e := client.NewTipEstimator(DefaultTipEstimatorConfig())
+
+	resp, err := client.SendBundle(context.Background(), newTestBundle(t, tipAccount))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.stream.results <- acceptedResult(resp.GetUuid())
+
+	deadline := time.Now().Add(time.Second)
+	for e.WinningTips().Count == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("accepted bundle not counted as a won bid")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if tip := e.WinningTips().Max; tip != 10_000 {
+		t.Errorf("won bid of %d lamports, want the 10000 tip of the bundle", tip)
+	}
+}
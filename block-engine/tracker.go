@@ -0,0 +1,468 @@
+package block_engine
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	bundle_pb "github.com/Prophet-Solutions/block-engine-protos/bundle"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Constants for bundle tracking
+const (
+	BundleTrackerRetention          = 10 * time.Minute // How long bundles are kept after their last transition
+	BundleTrackerSubscriberCapacity = 64               // Number of transitions buffered per subscriber channel
+)
+
+// BundleState is a state of the bundle lifecycle.
+type BundleState string
+
+// Bundle lifecycle states
+const (
+	BundleStateCreated   BundleState = "created"   // Bundle built but not sent
+	BundleStateSubmitted BundleState = "submitted" // Bundle sent to the block engine
+	BundleStateAccepted  BundleState = "accepted"  // Bundle forwarded to a leader
+	BundleStateRejected  BundleState = "rejected"  // Bundle rejected by the block engine
+	BundleStateProcessed BundleState = "processed" // Bundle processed by a leader
+	BundleStateConfirmed BundleState = "confirmed" // Bundle transactions confirmed
+	BundleStateFinalized BundleState = "finalized" // Bundle transactions finalized
+	BundleStateDropped   BundleState = "dropped"   // Bundle dropped after being accepted
+	BundleStateExpired   BundleState = "expired"   // Bundle blockhash expired before it landed
+)
+
+// landingRank orders the states of a bundle on its way to landing, 0 for the failure states.
+func (s BundleState) landingRank() int {
+	switch s {
+	case BundleStateCreated:
+		return 1
+	case BundleStateSubmitted:
+		return 2
+	case BundleStateAccepted:
+		return 3
+	case BundleStateProcessed:
+		return 4
+	case BundleStateConfirmed:
+		return 5
+	case BundleStateFinalized:
+		return 6
+	default:
+		return 0
+	}
+}
+
+// Final reports whether no further transition is expected from the state.
+func (s BundleState) Final() bool {
+	switch s {
+	case BundleStateFinalized, BundleStateRejected, BundleStateDropped, BundleStateExpired:
+		return true
+	default:
+		return false
+	}
+}
+
+// BundleTransition is a change of state of a tracked bundle.
+type BundleTransition struct {
+	UUID      string      // Bundle UUID, empty before submission
+	LogicalID string      // UUID of the first attempt of the logical bundle, empty if not resubmitted
+	From      BundleState // Previous state, empty for a newly created bundle
+	To        BundleState // New state
+	At        time.Time   // Time of the transition
+	Slot      uint64      // Slot reported with the transition, if any
+	Err       error       // Typed error of a rejected, dropped or expired bundle
+}
+
+// TrackedBundle is the lifecycle of a bundle.
+type TrackedBundle struct {
+	UUID        string             // Bundle UUID, empty before submission
+	LogicalID   string             // UUID of the first attempt of the logical bundle, empty if not resubmitted
+	Attempt     int                // Attempt number within the logical bundle, starting at 1
+	Signatures  []solana.Signature // Signatures of the bundle transactions
+	State       BundleState        // Current state
+	Slot        uint64             // Last slot reported for the bundle
+	Err         error              // Typed error of a rejected, dropped or expired bundle
+	Transitions []BundleTransition // Every transition, oldest first
+}
+
+// BundleTracker models every bundle through the lifecycle states and publishes each transition.
+// Bundles are queryable by UUID and by the signature of any of their transactions.
+type BundleTracker struct {
+	bundles     []*TrackedBundle                    // Tracked bundles, oldest first
+	byUUID      map[string]*TrackedBundle           // Tracked bundles indexed by UUID
+	bySignature map[solana.Signature]*TrackedBundle // Tracked bundles indexed by transaction signature
+	subscribers map[chan BundleTransition]struct{}  // Transition subscribers
+	retention   time.Duration                       // How long bundles are kept after their last transition
+	lastPrune   time.Time                           // Last time the bundles were pruned
+	mu          sync.Mutex                          // Mutex for synchronizing the bundles and subscribers
+}
+
+// NewBundleTracker creates a BundleTracker keeping bundles for retention after their last transition,
+// BundleTrackerRetention if zero. Bundles stuck in a non-final state are forgotten as well.
+func NewBundleTracker(retention time.Duration) *BundleTracker {
+	if retention <= 0 {
+		retention = BundleTrackerRetention
+	}
+
+	return &BundleTracker{
+		byUUID:      make(map[string]*TrackedBundle),
+		bySignature: make(map[solana.Signature]*TrackedBundle),
+		subscribers: make(map[chan BundleTransition]struct{}),
+		retention:   retention,
+	}
+}
+
+// Subscribe returns a channel receiving every transition and a function to unsubscribe.
+// Transitions are dropped for subscribers that do not drain their channel.
+func (t *BundleTracker) Subscribe() (<-chan BundleTransition, func()) {
+	ch := make(chan BundleTransition, BundleTrackerSubscriberCapacity)
+
+	t.mu.Lock()
+	t.subscribers[ch] = struct{}{}
+	t.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+
+			delete(t.subscribers, ch)
+			close(ch)
+		})
+	}
+}
+
+// Get returns a snapshot of the bundle with the given UUID.
+func (t *BundleTracker) Get(uuid string) (TrackedBundle, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune(time.Now())
+	bundle, ok := t.byUUID[uuid]
+	if !ok {
+		return TrackedBundle{}, false
+	}
+	return bundle.snapshot(), true
+}
+
+// GetBySignature returns a snapshot of the most recent bundle containing the transaction signature.
+func (t *BundleTracker) GetBySignature(signature solana.Signature) (TrackedBundle, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune(time.Now())
+	bundle, ok := t.bySignature[signature]
+	if !ok {
+		return TrackedBundle{}, false
+	}
+	return bundle.snapshot(), true
+}
+
+// Bundles returns snapshots of the tracked bundles in the given states, or of every bundle if none is given.
+func (t *BundleTracker) Bundles(states ...BundleState) []TrackedBundle {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune(time.Now())
+
+	bundles := make([]TrackedBundle, 0, len(t.bundles))
+	for _, bundle := range t.bundles {
+		if len(states) > 0 && !containsState(states, bundle.State) {
+			continue
+		}
+		bundles = append(bundles, bundle.snapshot())
+	}
+	return bundles
+}
+
+// Created starts tracking a bundle of transactions in the created state.
+func (t *BundleTracker) Created(transactions []*solana.Transaction) {
+	signatures := bundleSignatures(transactions)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune(time.Now())
+	t.create(signatures)
+}
+
+// Submitted records that the bundle of transactions was sent and received the UUID.
+// Bundles not created through Created are tracked from this point.
+func (t *BundleTracker) Submitted(transactions []*solana.Transaction, uuid string) {
+	signatures := bundleSignatures(transactions)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune(time.Now())
+	// Resubmissions of a bundle are tracked as a new bundle
+	bundle := t.lookup(signatures)
+	if bundle == nil || bundle.State != BundleStateCreated {
+		bundle = t.create(signatures)
+	}
+
+	bundle.UUID = uuid
+	t.byUUID[uuid] = bundle
+	t.transition(bundle, BundleStateSubmitted, 0, nil)
+}
+
+// Link records that the bundle with the given UUID is an attempt of the logical bundle,
+// identified by the UUID of its first attempt.
+func (t *BundleTracker) Link(uuid, logicalID string, attempt int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune(time.Now())
+	if bundle, ok := t.byUUID[uuid]; ok {
+		bundle.LogicalID = logicalID
+		bundle.Attempt = attempt
+	}
+}
+
+// Attempts returns snapshots of every attempt of the logical bundle, in attempt order.
+func (t *BundleTracker) Attempts(logicalID string) []TrackedBundle {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune(time.Now())
+	var attempts []TrackedBundle
+	for _, bundle := range t.bundles {
+		if bundle.LogicalID == logicalID {
+			attempts = append(attempts, bundle.snapshot())
+		}
+	}
+	sort.Slice(attempts, func(i, j int) bool { return attempts[i].Attempt < attempts[j].Attempt })
+	return attempts
+}
+
+// ObserveResult records a bundle result received from the block engine.
+func (t *BundleTracker) ObserveResult(result *bundle_pb.BundleResult) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune(time.Now())
+	bundle, ok := t.byUUID[result.GetBundleId()]
+	if !ok {
+		return
+	}
+
+	switch r := result.Result.(type) {
+	case *bundle_pb.BundleResult_Accepted:
+		t.transition(bundle, BundleStateAccepted, r.Accepted.GetSlot(), nil)
+	case *bundle_pb.BundleResult_Rejected:
+		t.transition(bundle, BundleStateRejected, 0, NewBundleRejectionError(bundle.UUID, r.Rejected))
+	case *bundle_pb.BundleResult_Processed:
+		t.transition(bundle, BundleStateProcessed, r.Processed.GetSlot(), nil)
+	case *bundle_pb.BundleResult_Finalized:
+		t.transition(bundle, BundleStateFinalized, 0, nil)
+	case *bundle_pb.BundleResult_Dropped:
+		t.transition(bundle, BundleStateDropped, 0, &DroppedBundleError{
+			BundleID: bundle.UUID,
+			Message:  r.Dropped.GetReason().String(),
+		})
+	}
+}
+
+// ObserveInflightStatus records a bundle status reported by the JSON-RPC getInflightBundleStatuses method.
+func (t *BundleTracker) ObserveInflightStatus(status *InflightBundleStatus) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune(time.Now())
+	bundle, ok := t.byUUID[status.BundleID]
+	if !ok {
+		return
+	}
+
+	switch status.Status {
+	case InflightBundleLanded:
+		var slot uint64
+		if status.LandedSlot != nil {
+			slot = *status.LandedSlot
+		}
+		t.transition(bundle, BundleStateProcessed, slot, nil)
+	case InflightBundleFailed:
+		t.transition(bundle, BundleStateRejected, 0, status.Err())
+	case InflightBundleInvalid:
+		t.transition(bundle, BundleStateDropped, 0, status.Err())
+	}
+}
+
+// ObserveSignatureStatuses records the signature statuses of the bundle's transactions.
+// The bundle is confirmed or finalized once every signature reached that status, and processed
+// once every signature is known to the cluster.
+func (t *BundleTracker) ObserveSignatureStatuses(uuid string, statuses *rpc.GetSignatureStatusesResult) {
+	if statuses == nil || len(statuses.Value) == 0 {
+		return
+	}
+
+	state := BundleStateFinalized
+	var slot uint64
+	for _, status := range statuses.Value {
+		if status == nil {
+			return
+		}
+		if status.Slot > slot {
+			slot = status.Slot
+		}
+
+		switch status.ConfirmationStatus {
+		case rpc.ConfirmationStatusFinalized:
+		case rpc.ConfirmationStatusConfirmed:
+			if state == BundleStateFinalized {
+				state = BundleStateConfirmed
+			}
+		default:
+			state = BundleStateProcessed
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune(time.Now())
+	if bundle, ok := t.byUUID[uuid]; ok {
+		t.transition(bundle, state, slot, nil)
+	}
+}
+
+// Expired records that the blockhash of the bundle expired before it landed.
+func (t *BundleTracker) Expired(uuid string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune(time.Now())
+	if bundle, ok := t.byUUID[uuid]; ok {
+		t.transition(bundle, BundleStateExpired, 0, ErrBlockHeightExceeded)
+	}
+}
+
+// create starts tracking a bundle with the given signatures.
+func (t *BundleTracker) create(signatures []solana.Signature) *TrackedBundle {
+	bundle := &TrackedBundle{Signatures: signatures}
+	t.bundles = append(t.bundles, bundle)
+	for _, signature := range signatures {
+		t.bySignature[signature] = bundle
+	}
+
+	t.transition(bundle, BundleStateCreated, 0, nil)
+	return bundle
+}
+
+// lookup returns the tracked bundle containing the first signature, if any.
+func (t *BundleTracker) lookup(signatures []solana.Signature) *TrackedBundle {
+	if len(signatures) == 0 {
+		return nil
+	}
+	return t.bySignature[signatures[0]]
+}
+
+// transition moves the bundle to a new state and publishes the transition.
+// On-chain states override failures reported by the block engine, other transitions only move forward.
+func (t *BundleTracker) transition(bundle *TrackedBundle, to BundleState, slot uint64, err error) {
+	from := bundle.State
+	switch {
+	case from == to:
+		return
+	case from == "":
+	case to.landingRank() == 0:
+		// Failures end bundles that did not land
+		if from.Final() || from.landingRank() >= BundleStateProcessed.landingRank() {
+			return
+		}
+	case from.landingRank() == 0:
+		// Only landing on-chain overrides a failure
+		if to.landingRank() < BundleStateProcessed.landingRank() {
+			return
+		}
+	case to.landingRank() <= from.landingRank():
+		return
+	}
+
+	transition := BundleTransition{
+		UUID:      bundle.UUID,
+		LogicalID: bundle.LogicalID,
+		From:      from,
+		To:        to,
+		At:        time.Now(),
+		Slot:      slot,
+		Err:       err,
+	}
+
+	bundle.State = to
+	bundle.Err = err
+	if slot > 0 {
+		bundle.Slot = slot
+	}
+	bundle.Transitions = append(bundle.Transitions, transition)
+
+	for ch := range t.subscribers {
+		select {
+		case ch <- transition:
+		default:
+		}
+	}
+}
+
+// prune forgets bundles whose last transition is older than the retention, in a final state or not.
+// It runs at most once every tenth of the retention.
+func (t *BundleTracker) prune(now time.Time) {
+	if now.Sub(t.lastPrune) < t.retention/10 {
+		return
+	}
+	t.lastPrune = now
+
+	kept := t.bundles[:0]
+	for _, bundle := range t.bundles {
+		last := bundle.Transitions[len(bundle.Transitions)-1]
+		if now.Sub(last.At) < t.retention {
+			kept = append(kept, bundle)
+			continue
+		}
+
+		if t.byUUID[bundle.UUID] == bundle {
+			delete(t.byUUID, bundle.UUID)
+		}
+		for _, signature := range bundle.Signatures {
+			if t.bySignature[signature] == bundle {
+				delete(t.bySignature, signature)
+			}
+		}
+	}
+
+	// Release the pruned bundles held past the end of the kept slice
+	for i := len(kept); i < len(t.bundles); i++ {
+		t.bundles[i] = nil
+	}
+	t.bundles = kept
+}
+
+// snapshot returns a copy of the tracked bundle.
+func (b *TrackedBundle) snapshot() TrackedBundle {
+	snapshot := *b
+	snapshot.Signatures = append([]solana.Signature(nil), b.Signatures...)
+	snapshot.Transitions = append([]BundleTransition(nil), b.Transitions...)
+	return snapshot
+}
+
+// bundleSignatures returns the first signature of every transaction.
+func bundleSignatures(transactions []*solana.Transaction) []solana.Signature {
+	signatures := make([]solana.Signature, 0, len(transactions))
+	for _, tx := range transactions {
+		if len(tx.Signatures) > 0 {
+			signatures = append(signatures, tx.Signatures[0])
+		}
+	}
+	return signatures
+}
+
+// containsState reports whether the state is one of the states.
+func containsState(states []BundleState, state BundleState) bool {
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
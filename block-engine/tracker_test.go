@@ -0,0 +1,114 @@
+package block_engine
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	bundle_pb "github.com/Prophet-Solutions/block-engine-protos/bundle"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func TestBundleTrackerLifecycle(t *testing.T) {
+	txs := newTestBundle(t, solana.NewWallet().PublicKey())
+	tracker := NewBundleTracker(0)
+	transitions, unsubscribe := tracker.Subscribe()
+	defer unsubscribe()
+
+	tracker.Created(txs)
+	tracker.Submitted(txs, "uuid")
+	tracker.ObserveResult(acceptedResult("uuid"))
+	tracker.ObserveResult(&bundle_pb.BundleResult{BundleId: "uuid", Result: &bundle_pb.BundleResult_Processed{
+		Processed: &bundle_pb.Processed{Slot: 7},
+	}})
+	// An earlier state and a failure after landing are ignored
+	tracker.ObserveResult(acceptedResult("uuid"))
+	tracker.ObserveResult(&bundle_pb.BundleResult{BundleId: "uuid", Result: &bundle_pb.BundleResult_Dropped{
+		Dropped: &bundle_pb.Dropped{Reason: bundle_pb.DroppedReason_BlockhashExpired},
+	}})
+	tracker.ObserveSignatureStatuses("uuid", &rpc.GetSignatureStatusesResult{Value: []*rpc.SignatureStatusesResult{
+		{Slot: 8, ConfirmationStatus: rpc.ConfirmationStatusFinalized},
+	}})
+
+	want := []BundleState{
+		BundleStateCreated,
+		BundleStateSubmitted,
+		BundleStateAccepted,
+		BundleStateProcessed,
+		BundleStateFinalized,
+	}
+	for _, state := range want {
+		select {
+		case transition := <-transitions:
+			if transition.To != state {
+				t.Errorf("transition to %s, want %s", transition.To, state)
+			}
+		default:
+			t.Fatalf("missing transition to %s", state)
+		}
+	}
+
+	bundle, ok := tracker.GetBySignature(txs[0].Signatures[0])
+	if !ok || bundle.UUID != "uuid" || bundle.State != BundleStateFinalized || bundle.Slot != 8 {
+		t.Errorf("bundle = %+v", bundle)
+	}
+}
+
+func TestBundleTrackerFailures(t *testing.T) {
+	txs := newTestBundle(t, solana.NewWallet().PublicKey())
+	tracker := NewBundleTracker(0)
+
+	tracker.Submitted(txs, "uuid")
+	tracker.Expired("uuid")
+
+	bundle, _ := tracker.Get("uuid")
+	if bundle.State != BundleStateExpired || !errors.Is(bundle.Err, ErrBlockHeightExceeded) {
+		t.Errorf("bundle = %+v, want expired", bundle)
+	}
+
+	// Landing on-chain overrides the failure
+	tracker.ObserveInflightStatus(&InflightBundleStatus{BundleID: "uuid", Status: InflightBundleLanded})
+	if bundle, _ = tracker.Get("uuid"); bundle.State != BundleStateProcessed || bundle.Err != nil {
+		t.Errorf("bundle = %+v, want processed", bundle)
+	}
+
+	if got := tracker.Bundles(BundleStateExpired); len(got) != 0 {
+		t.Errorf("Bundles(expired) = %v", got)
+	}
+}
+
+func TestBundleTrackerPrunesStaleBundles(t *testing.T) {
+	tipAccount := solana.NewWallet().PublicKey()
+	created, submitted, accepted := newTestBundle(t, tipAccount), newTestBundle(t, tipAccount), newTestBundle(t, tipAccount)
+	tracker := NewBundleTracker(20 * time.Millisecond)
+
+	// None of the bundles reaches a final state
+	tracker.Created(created)
+	tracker.Submitted(submitted, "submitted")
+	tracker.Submitted(accepted, "accepted")
+	tracker.ObserveResult(acceptedResult("accepted"))
+
+	time.Sleep(30 * time.Millisecond)
+
+	// Any entry point prunes, not only Created and Bundles
+	if _, ok := tracker.Get("submitted"); ok {
+		t.Error("stale submitted bundle still tracked")
+	}
+	if _, ok := tracker.GetBySignature(accepted[0].Signatures[0]); ok {
+		t.Error("stale accepted bundle still tracked")
+	}
+	if _, ok := tracker.GetBySignature(created[0].Signatures[0]); ok {
+		t.Error("stale created bundle still tracked")
+	}
+	if len(tracker.bundles) != 0 || len(tracker.byUUID) != 0 || len(tracker.bySignature) != 0 {
+		t.Errorf("indexes not pruned: %d bundles, %d UUIDs, %d signatures",
+			len(tracker.bundles), len(tracker.byUUID), len(tracker.bySignature))
+	}
+
+	// A bundle with a recent transition is kept
+	tracker.Submitted(submitted, "resubmitted")
+	if _, ok := tracker.Get("resubmitted"); !ok {
+		t.Error("recent bundle pruned")
+	}
+}
@@ -1,11 +1,15 @@
 package block_engine
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 
 	block_engine_pb "github.com/Prophet-Solutions/block-engine-protos/block_engine"
+	bundle_pb "github.com/Prophet-Solutions/block-engine-protos/bundle"
 	searcher_pb "github.com/Prophet-Solutions/block-engine-protos/searcher"
-	pkg "github.com/Prophet-Solutions/jito-go/pkg/block-engine"
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	block_engine_pkg "github.com/Prophet-Solutions/jito-go/pkg/block-engine"
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
 	"google.golang.org/grpc"
@@ -17,36 +21,76 @@ type SearcherClient struct {
 	RPCConn                  *rpc.Client                                              // Standard RPC connection
 	JitoRPCConn              *rpc.Client                                              // Jito RPC connection
 	SearcherService          searcher_pb.SearcherServiceClient                        // Searcher service client
-	BundleStreamSubscription searcher_pb.SearcherService_SubscribeBundleResultsClient // Bundle stream subscription
-	AuthenticationService    *pkg.AuthenticationService                               // Authentication service
-	ErrChan                  <-chan error                                             // Error channel
+	BundleStreamSubscription searcher_pb.SearcherService_SubscribeBundleResultsClient // Bundle stream subscription, owned by BundleResultDispatcher
+	BundleResultDispatcher   *BundleResultDispatcher                                  // Routes bundle results to their senders
+	TipAccounts              *TipAccountManager                                       // Cached tip accounts and selection
+	BundleTracker            *BundleTracker                                           // Lifecycle of the bundles sent
+	RateLimiter              *RateLimiter                                             // Client-side rate limiter, nil for no limiting
+	BundleHooks              []BundleHook                                             // Hooks observing the bundles sent, see AddBundleHook
+	AccountLocks             *pkg.AccountLockAnalyzer                                 // Account lock analysis of the pre-flight checks, nil by default to skip it
+	AuthenticationService    *block_engine_pkg.AuthenticationService                  // Authentication service
+	Events                   *pkg.EventBus                                            // Auth, connection and stream events
+	Region                   string                                                   // Location code of the block engine, if known
+}
+
+// JSONRPCClient is a client for the block engine JSON-RPC bundles API.
+// It needs no whitelisted auth keypair, an optional UUID is sent in the x-jito-auth header.
+type JSONRPCClient struct {
+	BlockEngineURL string              // Block engine base URL
+	UUID           string              // Auth UUID sent in the x-jito-auth header, if any
+	HTTPClient     *http.Client        // HTTP client for the transactions API
+	JitoRPCConn    *rpc.Client         // Jito JSON-RPC connection to the bundles API
+	RPCConn        *rpc.Client         // Standard RPC connection
+	Encoding       solana.EncodingType // Transaction encoding, base64 or base58
+	TipAccounts    *TipAccountManager  // Cached tip accounts and selection
+	BundleTracker  *BundleTracker      // Lifecycle of the bundles sent
+	Events         *pkg.EventBus       // Tip account refresh and bundle status poll errors, nil to discard them
 }
 
 // Relayer is a client for interacting with the Block Engine Relayer service.
 type Relayer struct {
 	GRPCConn              *grpc.ClientConn                         // gRPC connection
 	Client                block_engine_pb.BlockEngineRelayerClient // Relayer client
-	AuthenticationService *pkg.AuthenticationService               // Authentication service
-	ErrChan               <-chan error                             // Error channel
+	AuthenticationService *block_engine_pkg.AuthenticationService  // Authentication service
+	Events                *pkg.EventBus                            // Auth, connection and stream events
 }
 
 // Validator is a client for interacting with the Block Engine Validator service.
 type Validator struct {
 	GRPCConn              *grpc.ClientConn                           // gRPC connection
 	Client                block_engine_pb.BlockEngineValidatorClient // Validator client
-	AuthenticationService *pkg.AuthenticationService                 // Authentication service
-	ErrChan               <-chan error                               // Error channel
+	AuthenticationService *block_engine_pkg.AuthenticationService    // Authentication service
+	Events                *pkg.EventBus                              // Auth, connection and stream events
 }
 
 // BundleResponse represents a response from sending a bundle.
 type BundleResponse struct {
 	BundleResponse *searcher_pb.SendBundleResponse // Response from sending bundle
 	Signatures     []solana.Signature              // Signatures of transactions in the bundle
+	Accepted       *BundleAccepted                 // Set once the block engine accepted the bundle
+	Processed      *BundleProcessed                // Set once a leader processed the bundle
+	Finalized      bool                            // Whether the bundle was reported finalized
+}
+
+// BundleAccepted holds the details of a bundle accepted by the block engine.
+type BundleAccepted struct {
+	Slot              uint64 // Slot the bundle was forwarded for
+	ValidatorIdentity string // Identity of the validator the bundle was forwarded to
 }
 
-// BundleRejectionError represents an error when a bundle is rejected.
+// BundleProcessed holds the details of a bundle processed by a leader.
+type BundleProcessed struct {
+	Slot              uint64 // Slot the bundle was processed in
+	ValidatorIdentity string // Identity of the validator that processed the bundle
+	BundleIndex       uint64 // Index of the bundle within the slot
+}
+
+// BundleRejectionError is the common base of the bundle rejection errors. Every typed rejection error
+// unwraps to a BundleRejectionError holding its message, so errors.As with a *BundleRejectionError
+// target matches any rejection, whatever its reason.
 type BundleRejectionError struct {
-	Message string // Error message
+	BundleID string // ID of the rejected bundle, if known
+	Message  string // Error message
 }
 
 // Error implements the error interface for BundleRejectionError.
@@ -54,37 +98,218 @@ func (e BundleRejectionError) Error() string {
 	return e.Message
 }
 
+// Is reports whether target is ErrBundleRejected.
+func (e BundleRejectionError) Is(target error) bool {
+	return target == ErrBundleRejected
+}
+
+// Sentinel errors for bundle rejections, usable with errors.Is.
+// Every rejection error matches ErrBundleRejected and the sentinel of its reason.
+var (
+	ErrBundleRejected          = errors.New("bundle rejected")
+	ErrStateAuctionBidRejected = errors.New("bundle lost state auction")
+	ErrWinningBatchBidRejected = errors.New("bundle won state auction but failed global auction")
+	ErrSimulationFailure       = errors.New("bundle simulation failure")
+	ErrInternalError           = errors.New("bundle internal error")
+	ErrDroppedBundle           = errors.New("bundle dropped")
+)
+
+// StateAuctionBidRejectedError is returned when a bundle lost the state auction.
+type StateAuctionBidRejectedError struct {
+	BundleID             string // ID of the rejected bundle
+	AuctionID            string // ID of the lost auction
+	SimulatedBidLamports uint64 // Simulated tip of the bundle in lamports
+	Message              string // Optional message from the block engine
+}
+
+// Error implements the error interface for StateAuctionBidRejectedError.
+func (e *StateAuctionBidRejectedError) Error() string {
+	return fmt.Sprintf("bundle lost state auction, auction: %s, tip %d lamports%s",
+		e.AuctionID, e.SimulatedBidLamports, formatRejectionMessage(e.Message))
+}
+
+// Is reports whether target is ErrStateAuctionBidRejected or ErrBundleRejected.
+func (e *StateAuctionBidRejectedError) Is(target error) bool {
+	return target == ErrStateAuctionBidRejected || target == ErrBundleRejected
+}
+
+// Unwrap returns the error as a BundleRejectionError.
+func (e *StateAuctionBidRejectedError) Unwrap() error {
+	return BundleRejectionError{BundleID: e.BundleID, Message: e.Error()}
+}
+
+// WinningBatchBidRejectedError is returned when a bundle won the state auction but failed the global auction.
+type WinningBatchBidRejectedError struct {
+	BundleID             string // ID of the rejected bundle
+	AuctionID            string // ID of the lost auction
+	SimulatedBidLamports uint64 // Simulated tip of the bundle in lamports
+	Message              string // Optional message from the block engine
+}
+
+// Error implements the error interface for WinningBatchBidRejectedError.
+func (e *WinningBatchBidRejectedError) Error() string {
+	return fmt.Sprintf("bundle won state auction but failed global auction, auction %s, tip %d lamports%s",
+		e.AuctionID, e.SimulatedBidLamports, formatRejectionMessage(e.Message))
+}
+
+// Is reports whether target is ErrWinningBatchBidRejected or ErrBundleRejected.
+func (e *WinningBatchBidRejectedError) Is(target error) bool {
+	return target == ErrWinningBatchBidRejected || target == ErrBundleRejected
+}
+
+// Unwrap returns the error as a BundleRejectionError.
+func (e *WinningBatchBidRejectedError) Unwrap() error {
+	return BundleRejectionError{BundleID: e.BundleID, Message: e.Error()}
+}
+
+// SimulationFailureError is returned when a bundle failed simulation.
+type SimulationFailureError struct {
+	BundleID    string // ID of the rejected bundle
+	TxSignature string // Signature of the failing transaction
+	Message     string // Simulation failure message
+}
+
+// Error implements the error interface for SimulationFailureError.
+func (e *SimulationFailureError) Error() string {
+	return fmt.Sprintf("bundle simulation failure on tx %s, message: %s", e.TxSignature, e.Message)
+}
+
+// Is reports whether target is ErrSimulationFailure or ErrBundleRejected.
+func (e *SimulationFailureError) Is(target error) bool {
+	return target == ErrSimulationFailure || target == ErrBundleRejected
+}
+
+// Unwrap returns the error as a BundleRejectionError.
+func (e *SimulationFailureError) Unwrap() error {
+	return BundleRejectionError{BundleID: e.BundleID, Message: e.Error()}
+}
+
+// InternalError is returned when a bundle was rejected because of a block engine internal error.
+type InternalError struct {
+	BundleID string // ID of the rejected bundle
+	Message  string // Internal error message
+}
+
+// Error implements the error interface for InternalError.
+func (e *InternalError) Error() string {
+	return fmt.Sprintf("internal error %s", e.Message)
+}
+
+// Is reports whether target is ErrInternalError or ErrBundleRejected.
+func (e *InternalError) Is(target error) bool {
+	return target == ErrInternalError || target == ErrBundleRejected
+}
+
+// Unwrap returns the error as a BundleRejectionError.
+func (e *InternalError) Unwrap() error {
+	return BundleRejectionError{BundleID: e.BundleID, Message: e.Error()}
+}
+
+// DroppedBundleError is returned when a bundle was dropped.
+type DroppedBundleError struct {
+	BundleID string // ID of the dropped bundle
+	Message  string // Reason the bundle was dropped
+}
+
+// Error implements the error interface for DroppedBundleError.
+func (e *DroppedBundleError) Error() string {
+	return fmt.Sprintf("bundle dropped %s", e.Message)
+}
+
+// Is reports whether target is ErrDroppedBundle or ErrBundleRejected.
+func (e *DroppedBundleError) Is(target error) bool {
+	return target == ErrDroppedBundle || target == ErrBundleRejected
+}
+
+// Unwrap returns the error as a BundleRejectionError.
+func (e *DroppedBundleError) Unwrap() error {
+	return BundleRejectionError{BundleID: e.BundleID, Message: e.Error()}
+}
+
 // NewStateAuctionBidRejectedError creates a new error indicating that a bundle lost the state auction.
 func NewStateAuctionBidRejectedError(auction string, tip uint64) error {
-	return BundleRejectionError{
-		Message: fmt.Sprintf("bundle lost state auction, auction: %s, tip %d lamports", auction, tip),
+	return &StateAuctionBidRejectedError{
+		AuctionID:            auction,
+		SimulatedBidLamports: tip,
 	}
 }
 
 // NewWinningBatchBidRejectedError creates a new error indicating that a bundle won the state auction but failed the global auction.
 func NewWinningBatchBidRejectedError(auction string, tip uint64) error {
-	return BundleRejectionError{
-		Message: fmt.Sprintf("bundle won state auction but failed global auction, auction %s, tip %d lamports", auction, tip),
+	return &WinningBatchBidRejectedError{
+		AuctionID:            auction,
+		SimulatedBidLamports: tip,
 	}
 }
 
 // NewSimulationFailureError creates a new error indicating that a bundle failed simulation.
 func NewSimulationFailureError(tx string, message string) error {
-	return BundleRejectionError{
-		Message: fmt.Sprintf("bundle simulation failure on tx %s, message: %s", tx, message),
+	return &SimulationFailureError{
+		TxSignature: tx,
+		Message:     message,
 	}
 }
 
 // NewInternalError creates a new internal error.
 func NewInternalError(message string) error {
-	return BundleRejectionError{
-		Message: fmt.Sprintf("internal error %s", message),
+	return &InternalError{
+		Message: message,
 	}
 }
 
 // NewDroppedBundle creates a new error indicating that a bundle was dropped.
 func NewDroppedBundle(message string) error {
-	return BundleRejectionError{
-		Message: fmt.Sprintf("bundle dropped %s", message),
+	return &DroppedBundleError{
+		Message: message,
+	}
+}
+
+// NewBundleRejectionError converts a rejection of the given bundle into its typed error.
+// It returns nil if the rejection reason is unknown.
+func NewBundleRejectionError(bundleID string, rejected *bundle_pb.Rejected) error {
+	switch rejected.Reason.(type) {
+	case *bundle_pb.Rejected_StateAuctionBidRejected:
+		rejection := rejected.GetStateAuctionBidRejected()
+		return &StateAuctionBidRejectedError{
+			BundleID:             bundleID,
+			AuctionID:            rejection.GetAuctionId(),
+			SimulatedBidLamports: rejection.GetSimulatedBidLamports(),
+			Message:              rejection.GetMsg(),
+		}
+	case *bundle_pb.Rejected_WinningBatchBidRejected:
+		rejection := rejected.GetWinningBatchBidRejected()
+		return &WinningBatchBidRejectedError{
+			BundleID:             bundleID,
+			AuctionID:            rejection.GetAuctionId(),
+			SimulatedBidLamports: rejection.GetSimulatedBidLamports(),
+			Message:              rejection.GetMsg(),
+		}
+	case *bundle_pb.Rejected_SimulationFailure:
+		rejection := rejected.GetSimulationFailure()
+		return &SimulationFailureError{
+			BundleID:    bundleID,
+			TxSignature: rejection.GetTxSignature(),
+			Message:     rejection.GetMsg(),
+		}
+	case *bundle_pb.Rejected_InternalError:
+		return &InternalError{
+			BundleID: bundleID,
+			Message:  rejected.GetInternalError().GetMsg(),
+		}
+	case *bundle_pb.Rejected_DroppedBundle:
+		return &DroppedBundleError{
+			BundleID: bundleID,
+			Message:  rejected.GetDroppedBundle().GetMsg(),
+		}
+	default:
+		return nil
+	}
+}
+
+// formatRejectionMessage formats the optional block engine message of an auction rejection.
+func formatRejectionMessage(message string) string {
+	if message == "" {
+		return ""
 	}
+	return ", message: " + message
 }
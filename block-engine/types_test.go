@@ -0,0 +1,159 @@
+package block_engine
+
+import (
+	"errors"
+	"testing"
+
+	bundle_pb "github.com/Prophet-Solutions/block-engine-protos/bundle"
+)
+
+func TestNewBundleRejectionError(t *testing.T) {
+	msg := "outbid"
+	tests := []struct {
+		name     string
+		rejected *bundle_pb.Rejected
+		sentinel error
+		check    func(t *testing.T, err error)
+	}{
+		{
+			name: "state auction",
+			rejected: &bundle_pb.Rejected{Reason: &bundle_pb.Rejected_StateAuctionBidRejected{
+				StateAuctionBidRejected: &bundle_pb.StateAuctionBidRejected{AuctionId: "auction", SimulatedBidLamports: 1_000, Msg: &msg},
+			}},
+			sentinel: ErrStateAuctionBidRejected,
+			check: func(t *testing.T, err error) {
+				var typed *StateAuctionBidRejectedError
+				if !errors.As(err, &typed) {
+					t.Fatalf("%T is not a *StateAuctionBidRejectedError", err)
+				}
+				if typed.AuctionID != "auction" || typed.SimulatedBidLamports != 1_000 || typed.Message != msg {
+					t.Errorf("unexpected fields %+v", typed)
+				}
+			},
+		},
+		{
+			name: "winning batch",
+			rejected: &bundle_pb.Rejected{Reason: &bundle_pb.Rejected_WinningBatchBidRejected{
+				WinningBatchBidRejected: &bundle_pb.WinningBatchBidRejected{AuctionId: "auction", SimulatedBidLamports: 2_000},
+			}},
+			sentinel: ErrWinningBatchBidRejected,
+			check: func(t *testing.T, err error) {
+				var typed *WinningBatchBidRejectedError
+				if !errors.As(err, &typed) || typed.SimulatedBidLamports != 2_000 {
+					t.Errorf("got %#v, want a *WinningBatchBidRejectedError bidding 2000", err)
+				}
+			},
+		},
+		{
+			name: "simulation failure",
+			rejected: &bundle_pb.Rejected{Reason: &bundle_pb.Rejected_SimulationFailure{
+				SimulationFailure: &bundle_pb.SimulationFailure{TxSignature: "sig", Msg: &msg},
+			}},
+			sentinel: ErrSimulationFailure,
+			check: func(t *testing.T, err error) {
+				var typed *SimulationFailureError
+				if !errors.As(err, &typed) || typed.TxSignature != "sig" {
+					t.Errorf("got %#v, want a *SimulationFailureError for sig", err)
+				}
+			},
+		},
+		{
+			name: "internal error",
+			rejected: &bundle_pb.Rejected{Reason: &bundle_pb.Rejected_InternalError{
+				InternalError: &bundle_pb.InternalError{Msg: "boom"},
+			}},
+			sentinel: ErrInternalError,
+		},
+		{
+			name: "dropped",
+			rejected: &bundle_pb.Rejected{Reason: &bundle_pb.Rejected_DroppedBundle{
+				DroppedBundle: &bundle_pb.DroppedBundle{Msg: "expired"},
+			}},
+			sentinel: ErrDroppedBundle,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewBundleRejectionError("bundle", tt.rejected)
+			if err == nil {
+				t.Fatal("NewBundleRejectionError() = nil")
+			}
+			if !errors.Is(err, tt.sentinel) {
+				t.Errorf("%v does not match %v", err, tt.sentinel)
+			}
+			if !errors.Is(err, ErrBundleRejected) {
+				t.Errorf("%v does not match ErrBundleRejected", err)
+			}
+
+			// Every typed rejection still matches the common base type
+			var base BundleRejectionError
+			if !errors.As(err, &base) {
+				t.Fatalf("%T does not match BundleRejectionError", err)
+			}
+			if base.BundleID != "bundle" || base.Message != err.Error() {
+				t.Errorf("base = %+v, want bundle ID and message %q", base, err.Error())
+			}
+
+			if tt.check != nil {
+				tt.check(t, err)
+			}
+		})
+	}
+
+	if err := NewBundleRejectionError("bundle", &bundle_pb.Rejected{}); err != nil {
+		t.Errorf("unknown reason: got %v, want nil", err)
+	}
+}
+
+func TestRejectionConstructorsMatchBase(t *testing.T) {
+	for _, err := range []error{
+		NewStateAuctionBidRejectedError("auction", 1),
+		NewWinningBatchBidRejectedError("auction", 1),
+		NewSimulationFailureError("sig", "failed"),
+		NewInternalError("boom"),
+		NewDroppedBundle("expired"),
+	} {
+		var base BundleRejectionError
+		if !errors.As(err, &base) || base.Message != err.Error() {
+			t.Errorf("%T does not unwrap to a BundleRejectionError with its message", err)
+		}
+		if !errors.Is(err, ErrBundleRejected) {
+			t.Errorf("%T does not match ErrBundleRejected", err)
+		}
+	}
+}
+
+func TestHandleBundleResult(t *testing.T) {
+	c := &SearcherClient{}
+
+	resp := &BundleResponse{}
+	results := []*bundle_pb.BundleResult{
+		acceptedResult("uuid"),
+		{BundleId: "uuid", Result: &bundle_pb.BundleResult_Processed{
+			Processed: &bundle_pb.Processed{Slot: 7, ValidatorIdentity: "leader", BundleIndex: 2},
+		}},
+		{BundleId: "uuid", Result: &bundle_pb.BundleResult_Finalized{Finalized: &bundle_pb.Finalized{}}},
+	}
+	for _, result := range results {
+		if err := c.handleBundleResult(resp, result); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if resp.Accepted == nil || resp.Accepted.ValidatorIdentity != "validator" {
+		t.Errorf("Accepted = %+v", resp.Accepted)
+	}
+	if resp.Processed == nil || resp.Processed.Slot != 7 || resp.Processed.BundleIndex != 2 {
+		t.Errorf("Processed = %+v", resp.Processed)
+	}
+	if !resp.Finalized {
+		t.Error("Finalized not set")
+	}
+
+	err := c.handleBundleResult(resp, &bundle_pb.BundleResult{BundleId: "uuid", Result: &bundle_pb.BundleResult_Dropped{
+		Dropped: &bundle_pb.Dropped{Reason: bundle_pb.DroppedReason_BlockhashExpired},
+	}})
+	var dropped *DroppedBundleError
+	if !errors.As(err, &dropped) || dropped.BundleID != "uuid" {
+		t.Errorf("dropped result: got %v, want a *DroppedBundleError", err)
+	}
+}
@@ -3,24 +3,73 @@ package block_engine
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
+	auth_pb "github.com/Prophet-Solutions/block-engine-protos/auth"
 	bundle_pb "github.com/Prophet-Solutions/block-engine-protos/bundle"
 	"github.com/Prophet-Solutions/jito-go/pkg"
+	block_engine_pkg "github.com/Prophet-Solutions/jito-go/pkg/block-engine"
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
+	"google.golang.org/grpc"
 )
 
-// waitForSignatureStatuses waits for the signatures of the provided transactions to be confirmed.
-// It repeatedly checks the signature statuses until they are confirmed or a timeout occurs.
-func (c *SearcherClient) waitForSignatureStatuses(
+// createAuthenticatedConnection creates a gRPC connection to the block engine and, if a signer is provided,
+// authenticates for the role. The authentication service is installed on the connection as per-RPC credentials,
+// so every call carries the current access token. The token refresh loop runs until the context is done.
+// A token store given with block_engine_pkg.WithTokenStore is shared with other clients of the same address.
+// Auth and connection events are published on the bus.
+func createAuthenticatedConnection(
 	ctx context.Context,
+	bus *pkg.EventBus,
+	grpcAddr string,
+	signer pkg.Signer,
+	role auth_pb.Role,
+	opts ...grpc.DialOption,
+) (*grpc.ClientConn, *block_engine_pkg.AuthenticationService, error) {
+	var authService *block_engine_pkg.AuthenticationService
+	if signer != nil {
+		authService = block_engine_pkg.NewAuthenticationService(ctx, signer, bus)
+		authService.TokenStoreScope = grpcAddr
+		for _, opt := range opts {
+			if option, ok := opt.(block_engine_pkg.TokenStoreOption); ok {
+				authService.TokenStore = option.Store
+			}
+		}
+		opts = append(opts, grpc.WithPerRPCCredentials(authService))
+	}
+
+	// Create a new gRPC connection using the provided address and options
+	conn, err := pkg.CreateGRPCConnection(ctx, bus, grpcAddr, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Set up authentication if a signer is provided
+	if authService != nil {
+		authService.Connect(conn)
+		if err = authService.AuthenticateAndRefresh(role); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return conn, authService, nil
+}
+
+// waitForSignatureStatuses waits for the signatures of the provided transactions to reach the policy commitment.
+// It repeatedly checks the signature statuses until they are confirmed, one of them failed or a timeout occurs.
+// The timeout follows the policy's last valid block height when set, wall-clock time otherwise.
+func waitForSignatureStatuses(
+	ctx context.Context,
+	rpcClient *rpc.Client,
 	transactions []*solana.Transaction,
+	policy ConfirmationPolicy,
 ) (*rpc.GetSignatureStatusesResult, error) {
 	start := time.Now()
 	for {
 		// Get the statuses of the signatures
-		statuses, err := c.RPCConn.GetSignatureStatuses(
+		statuses, err := rpcClient.GetSignatureStatuses(
 			ctx,
 			false,
 			pkg.BatchExtractSigFromTx(transactions)...,
@@ -29,58 +78,60 @@ func (c *SearcherClient) waitForSignatureStatuses(
 			return nil, err
 		}
 
-		// Check if the signatures are confirmed
-		if pkg.CheckSignatureStatuses(statuses) {
+		// Check if the signatures are confirmed or failed
+		err = pkg.ValidateSignatureStatusesCommitment(statuses, policy.Commitment, policy.FailOnTransactionError)
+		if err == nil {
 			return statuses, nil
 		}
+		if errors.Is(err, pkg.ErrTransactionFailed) {
+			return statuses, err
+		}
 
 		// Check if the operation has timed out
-		if time.Since(start) > SignaturesConfirmationTimeout {
-			return nil, errors.New("operation timed out after 15 seconds")
+		if policy.LastValidBlockHeight > 0 {
+			blockHeight, err := rpcClient.GetBlockHeight(ctx, rpc.CommitmentConfirmed)
+			if err != nil {
+				return nil, err
+			}
+			if blockHeight > policy.LastValidBlockHeight {
+				return nil, ErrBlockHeightExceeded
+			}
+		} else if time.Since(start) > policy.ConfirmationTimeout {
+			return nil, fmt.Errorf("operation timed out after %s", policy.ConfirmationTimeout)
 		}
 
 		// Wait before retrying
-		time.Sleep(SignaturesConfirmationRetryDelay)
-	}
-}
-
-// receiveBundleResult receives a bundle result from the bundle stream subscription.
-// It returns the received bundle result or an error if the reception fails.
-func (c *SearcherClient) receiveBundleResult() (*bundle_pb.BundleResult, error) {
-	bundleResult, err := c.BundleStreamSubscription.Recv()
-	if err != nil {
-		return nil, err
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(policy.PollInterval):
+		}
 	}
-	return bundleResult, nil
 }
 
-// handleBundleResult handles the received bundle result by checking its type and performing the necessary actions.
-// It returns an error if the bundle result indicates a rejection.
-func (c *SearcherClient) handleBundleResult(bundleResult *bundle_pb.BundleResult) error {
-	switch bundleResult.Result.(type) {
+// handleBundleResult handles the received bundle result by checking its type and recording it on the response.
+// It returns the typed rejection error if the bundle result indicates a rejection or a drop.
+func (c *SearcherClient) handleBundleResult(resp *BundleResponse, bundleResult *bundle_pb.BundleResult) error {
+	switch result := bundleResult.Result.(type) {
 	case *bundle_pb.BundleResult_Accepted:
-		// Bundle accepted, no action needed
-		break
+		resp.Accepted = &BundleAccepted{
+			Slot:              result.Accepted.GetSlot(),
+			ValidatorIdentity: result.Accepted.GetValidatorIdentity(),
+		}
 	case *bundle_pb.BundleResult_Rejected:
-		rejected := bundleResult.Result.(*bundle_pb.BundleResult_Rejected)
-		switch rejected.Rejected.Reason.(type) {
-		case *bundle_pb.Rejected_SimulationFailure:
-			rejection := rejected.Rejected.GetSimulationFailure()
-			return NewSimulationFailureError(rejection.TxSignature, rejection.GetMsg())
-		case *bundle_pb.Rejected_StateAuctionBidRejected:
-			rejection := rejected.Rejected.GetStateAuctionBidRejected()
-			return NewStateAuctionBidRejectedError(rejection.AuctionId, rejection.SimulatedBidLamports)
-		case *bundle_pb.Rejected_WinningBatchBidRejected:
-			rejection := rejected.Rejected.GetWinningBatchBidRejected()
-			return NewWinningBatchBidRejectedError(rejection.AuctionId, rejection.SimulatedBidLamports)
-		case *bundle_pb.Rejected_InternalError:
-			rejection := rejected.Rejected.GetInternalError()
-			return NewInternalError(rejection.Msg)
-		case *bundle_pb.Rejected_DroppedBundle:
-			rejection := rejected.Rejected.GetDroppedBundle()
-			return NewDroppedBundle(rejection.Msg)
-		default:
-			return nil
+		return NewBundleRejectionError(bundleResult.GetBundleId(), result.Rejected)
+	case *bundle_pb.BundleResult_Processed:
+		resp.Processed = &BundleProcessed{
+			Slot:              result.Processed.GetSlot(),
+			ValidatorIdentity: result.Processed.GetValidatorIdentity(),
+			BundleIndex:       result.Processed.GetBundleIndex(),
+		}
+	case *bundle_pb.BundleResult_Finalized:
+		resp.Finalized = true
+	case *bundle_pb.BundleResult_Dropped:
+		return &DroppedBundleError{
+			BundleID: bundleResult.GetBundleId(),
+			Message:  result.Dropped.GetReason().String(),
 		}
 	}
 	return nil
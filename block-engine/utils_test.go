@@ -0,0 +1,215 @@
+package block_engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// fakeRPCHandler answers a JSON-RPC method with a result, or an error sent as the JSON-RPC error.
+type fakeRPCHandler func(params []json.RawMessage) (interface{}, error)
+
+// fakeRPC is a Solana JSON-RPC server answering the registered methods.
+type fakeRPC struct {
+	url      string
+	handlers map[string]fakeRPCHandler
+	calls    map[string]int
+	paths    map[string]string      // Request URI of the last call of each method
+	headers  map[string]http.Header // Request headers of the last call of each method
+	header   http.Header            // Headers set on every response
+	mu       sync.Mutex
+}
+
+// newFakeRPC starts a fake JSON-RPC server and returns a client connected to it.
+func newFakeRPC(t *testing.T) (*fakeRPC, *rpc.Client) {
+	t.Helper()
+	f := &fakeRPC{
+		handlers: make(map[string]fakeRPCHandler),
+		calls:    make(map[string]int),
+		paths:    make(map[string]string),
+		headers:  make(map[string]http.Header),
+		header:   make(http.Header),
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		f.mu.Lock()
+		handler, ok := f.handlers[req.Method]
+		f.calls[req.Method]++
+		f.paths[req.Method] = r.URL.RequestURI()
+		f.headers[req.Method] = r.Header.Clone()
+		for key, values := range f.header {
+			w.Header()[key] = values
+		}
+		f.mu.Unlock()
+
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		if !ok {
+			resp["error"] = map[string]interface{}{"code": -32601, "message": "method not found: " + req.Method}
+		} else if result, err := handler(req.Params); err != nil {
+			resp["error"] = map[string]interface{}{"code": -32000, "message": err.Error()}
+		} else {
+			resp["result"] = result
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	f.url = server.URL
+
+	return f, rpc.New(server.URL)
+}
+
+// handle registers the handler of a method.
+func (f *fakeRPC) handle(method string, handler fakeRPCHandler) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.handlers[method] = handler
+}
+
+// count returns the number of calls of a method.
+func (f *fakeRPC) count(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.calls[method]
+}
+
+// request returns the request URI and headers of the last call of a method.
+func (f *fakeRPC) request(method string) (string, http.Header) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.paths[method], f.headers[method]
+}
+
+// signatureStatuses returns a getSignatureStatuses handler reporting every signature with the status.
+// An empty status reports the signatures as unknown.
+func signatureStatuses(status rpc.ConfirmationStatusType, txErr interface{}) fakeRPCHandler {
+	return func(params []json.RawMessage) (interface{}, error) {
+		var signatures []string
+		if err := json.Unmarshal(params[0], &signatures); err != nil {
+			return nil, err
+		}
+
+		value := make([]interface{}, len(signatures))
+		if status != "" {
+			for i := range value {
+				value[i] = map[string]interface{}{
+					"slot":               100,
+					"confirmations":      nil,
+					"err":                txErr,
+					"confirmationStatus": status,
+				}
+			}
+		}
+		return map[string]interface{}{"context": map[string]interface{}{"slot": 100}, "value": value}, nil
+	}
+}
+
+// fastConfirmationPolicy returns a policy polling without delay, for tests.
+func fastConfirmationPolicy() ConfirmationPolicy {
+	return ConfirmationPolicy{
+		Commitment:          rpc.ConfirmationStatusConfirmed,
+		Retries:             2,
+		RetryDelay:          10 * time.Millisecond,
+		ConfirmationTimeout: 50 * time.Millisecond,
+		PollInterval:        5 * time.Millisecond,
+	}
+}
+
+func TestWaitForSignatureStatuses(t *testing.T) {
+	payer := newTestSigner(t)
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{transferInstruction(payer.PublicKey(), 1)},
+		solana.Hash{1},
+		solana.TransactionPayer(payer.PublicKey()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = pkg.PartialSignTransaction(tx, []pkg.Signer{payer}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		status  rpc.ConfirmationStatusType
+		txErr   interface{}
+		policy  func(*ConfirmationPolicy)
+		wantErr error
+		timeout bool
+	}{
+		{name: "confirmed", status: rpc.ConfirmationStatusConfirmed},
+		{name: "finalized satisfies confirmed", status: rpc.ConfirmationStatusFinalized},
+		{name: "processed times out", status: rpc.ConfirmationStatusProcessed, timeout: true},
+		{name: "unknown times out", timeout: true},
+		{
+			name:    "transaction error",
+			status:  rpc.ConfirmationStatusConfirmed,
+			txErr:   map[string]interface{}{"InstructionError": []interface{}{0, "Custom"}},
+			policy:  func(p *ConfirmationPolicy) { p.FailOnTransactionError = true },
+			wantErr: pkg.ErrTransactionFailed,
+		},
+		{
+			name:    "last valid block height",
+			status:  rpc.ConfirmationStatusProcessed,
+			policy:  func(p *ConfirmationPolicy) { p.LastValidBlockHeight = 10 },
+			wantErr: ErrBlockHeightExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := newFakeRPC(t)
+			server.handle("getSignatureStatuses", signatureStatuses(tt.status, tt.txErr))
+			server.handle("getBlockHeight", func([]json.RawMessage) (interface{}, error) { return 11, nil })
+
+			policy := fastConfirmationPolicy()
+			if tt.policy != nil {
+				tt.policy(&policy)
+			}
+
+			statuses, err := waitForSignatureStatuses(context.Background(), client, []*solana.Transaction{tx}, policy)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("err = %v, want %v", err, tt.wantErr)
+				}
+			case tt.timeout:
+				if err == nil {
+					t.Error("wait succeeded, want a timeout")
+				}
+				if server.count("getSignatureStatuses") < 2 {
+					t.Error("statuses were not polled again before the timeout")
+				}
+			default:
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(statuses.Value) != 1 {
+					t.Errorf("got %d statuses, want 1", len(statuses.Value))
+				}
+			}
+		})
+	}
+}
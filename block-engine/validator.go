@@ -7,153 +7,145 @@ import (
 	jito_pb "github.com/Prophet-Solutions/block-engine-protos/block_engine"
 	bundle_pb "github.com/Prophet-Solutions/block-engine-protos/bundle"
 	"github.com/Prophet-Solutions/jito-go/pkg"
-	block_engine_pkg "github.com/Prophet-Solutions/jito-go/pkg/block-engine"
-	"github.com/gagliardetto/solana-go"
 	"google.golang.org/grpc"
 )
 
-// NewValidator initializes a new Validator with the provided context, gRPC address, and Solana private key.
-// It establishes a gRPC connection, sets up authentication if a keyPair is provided, and returns the Validator.
+// NewValidator initializes a new Validator with the provided context, gRPC address, and authentication signer.
+// It establishes a gRPC connection, sets up authentication if a signer is provided, and returns the Validator.
 func NewValidator(
 	ctx context.Context,
 	grpcAddr string,
-	keyPair *solana.PrivateKey,
+	signer pkg.Signer,
 	opts ...grpc.DialOption,
 ) (*Validator, error) {
-	// Channel to handle errors during gRPC connection setup
-	chErr := make(chan error)
+	// Event bus for auth, connection and stream events, shared if given with pkg.WithEventBus
+	bus := pkg.EventBusFromDialOptions(opts)
 
-	// Create a new gRPC connection using the provided address and options
-	conn, err := pkg.CreateGRPCConnection(ctx, chErr, grpcAddr, opts...)
+	// Create an authenticated gRPC connection using the provided address and options
+	conn, authService, err := createAuthenticatedConnection(ctx, bus, grpcAddr, signer, auth_pb.Role_VALIDATOR, opts...)
 	if err != nil {
 		return nil, err
 	}
 
 	// Create a new BlockEngineValidatorClient with the established connection
 	blockEngineValidatorClient := jito_pb.NewBlockEngineValidatorClient(conn)
-	var authService *block_engine_pkg.AuthenticationService
-
-	// Set up authentication if a keyPair is provided
-	if keyPair != nil {
-		authService = block_engine_pkg.NewAuthenticationService(context.Background(), conn, keyPair)
-		if err = authService.AuthenticateAndRefresh(auth_pb.Role_VALIDATOR); err != nil {
-			return nil, err
-		}
-	}
 
 	// Return the initialized Validator
 	return &Validator{
 		GRPCConn:              conn,
 		Client:                blockEngineValidatorClient,
 		AuthenticationService: authService,
-		ErrChan:               chErr,
+		Events:                bus,
 	}, nil
 }
 
 // SubscribePackets subscribes to packet updates from the BlockEngineValidator service.
 // It returns a client for receiving these updates.
 func (v *Validator) SubscribePackets(
+	ctx context.Context,
 	opts ...grpc.CallOption,
 ) (jito_pb.BlockEngineValidator_SubscribePacketsClient, error) {
 	return v.Client.SubscribePackets(
-		v.AuthenticationService.GRPCCtx,
+		ctx,
 		&jito_pb.SubscribePacketsRequest{},
 		opts...,
 	)
 }
 
-// OnPacketSubscription subscribes to packet updates and handles the incoming updates and errors.
-// It returns channels for the updates and errors.
+// OnPacketSubscription subscribes to packet updates and handles the incoming updates.
+// It returns a channel for the updates, closed when the stream ends. The error ending it is published on Events.
 func (v *Validator) OnPacketSubscription(
 	ctx context.Context,
-) (<-chan *jito_pb.SubscribePacketsResponse, <-chan error, error) {
+) (<-chan *jito_pb.SubscribePacketsResponse, error) {
 	// Subscribe to packets
-	sub, err := v.SubscribePackets()
+	sub, err := v.SubscribePackets(ctx)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	// Channels to handle packet updates and errors
+	// Channel to handle packet updates
 	chPackets := make(chan *jito_pb.SubscribePacketsResponse)
-	chErr := make(chan error)
 
-	// Goroutine to receive updates and errors
+	// Goroutine to receive updates until the stream ends
 	go func() {
+		defer close(chPackets)
 		for {
+			resp, err := sub.Recv()
+			if err != nil {
+				if ctx.Err() == nil {
+					v.Events.Publish(pkg.Event{Kind: pkg.EventStreamError, Source: "SubscribePackets", Err: err})
+				}
+				return
+			}
+
 			select {
+			case chPackets <- resp:
 			case <-ctx.Done():
 				return
-			default:
-				resp, err := sub.Recv()
-				if err != nil {
-					chErr <- err
-					continue
-				}
-
-				chPackets <- resp
 			}
 		}
 	}()
 
-	return chPackets, chErr, nil
+	return chPackets, nil
 }
 
 // SubscribeBundles subscribes to bundle updates from the BlockEngineValidator service.
 // It returns a client for receiving these updates.
 func (v *Validator) SubscribeBundles(
+	ctx context.Context,
 	opts ...grpc.CallOption,
 ) (jito_pb.BlockEngineValidator_SubscribeBundlesClient, error) {
 	return v.Client.SubscribeBundles(
-		v.AuthenticationService.GRPCCtx,
+		ctx,
 		&jito_pb.SubscribeBundlesRequest{},
 		opts...,
 	)
 }
 
-// OnBundleSubscription subscribes to bundle updates and handles the incoming updates and errors.
-// It returns channels for the updates and errors.
+// OnBundleSubscription subscribes to bundle updates and handles the incoming updates.
+// It returns a channel for the updates, closed when the stream ends. The error ending it is published on Events.
 func (v *Validator) OnBundleSubscription(ctx context.Context) (
-	<-chan []*bundle_pb.BundleUuid, <-chan error, error) {
+	<-chan []*bundle_pb.BundleUuid, error) {
 	// Subscribe to bundles
-	sub, err := v.SubscribeBundles()
+	sub, err := v.SubscribeBundles(ctx)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	// Channels to handle bundle updates and errors
+	// Channel to handle bundle updates
 	chBundleUuid := make(chan []*bundle_pb.BundleUuid)
-	chErr := make(chan error)
 
-	// Goroutine to receive updates and errors
+	// Goroutine to receive updates until the stream ends
 	go func() {
+		defer close(chBundleUuid)
 		for {
+			resp, err := sub.Recv()
+			if err != nil {
+				if ctx.Err() == nil {
+					v.Events.Publish(pkg.Event{Kind: pkg.EventStreamError, Source: "SubscribeBundles", Err: err})
+				}
+				return
+			}
+
 			select {
+			case chBundleUuid <- resp.Bundles:
 			case <-ctx.Done():
 				return
-			case <-v.AuthenticationService.GRPCCtx.Done():
-				return
-			default:
-				resp, err := sub.Recv()
-				if err != nil {
-					chErr <- err
-					continue
-				}
-
-				chBundleUuid <- resp.Bundles
 			}
 		}
 	}()
 
-	return chBundleUuid, chErr, nil
+	return chBundleUuid, nil
 }
 
 // GetBlockBuilderFeeInfo retrieves the block builder fee information from the BlockEngineValidator service.
 // It returns a BlockBuilderFeeInfoResponse or an error.
 func (v *Validator) GetBlockBuilderFeeInfo(
+	ctx context.Context,
 	opts ...grpc.CallOption,
 ) (*jito_pb.BlockBuilderFeeInfoResponse, error) {
 	return v.Client.GetBlockBuilderFeeInfo(
-		v.AuthenticationService.GRPCCtx,
+		ctx,
 		&jito_pb.BlockBuilderFeeInfoRequest{},
 		opts...,
 	)
@@ -0,0 +1,429 @@
+package pkg
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Constants for the account lock analysis
+const (
+	DefaultInflightLocksTTL     = 90 * time.Second // How long the locks of a bundle in flight are kept without release
+	addressLookupTableMetaSize  = 56               // Size of the address lookup table state before the addresses
+	addressLookupTableStateType = 1                // Type of an initialized address lookup table state
+)
+
+// ErrInvalidLookupTable is returned when an address lookup table cannot be decoded or indexed.
+var ErrInvalidLookupTable = errors.New("invalid address lookup table")
+
+// TransactionAccountLocks are the accounts a transaction locks, including those loaded from lookup tables.
+type TransactionAccountLocks struct {
+	Writable []solana.PublicKey // Accounts locked for writing
+	Readonly []solana.PublicKey // Accounts locked for reading
+	Signers  []solana.PublicKey // Accounts signing the transaction, fee payer first, also in Writable or Readonly
+}
+
+// Count returns the number of accounts the transaction locks.
+func (l *TransactionAccountLocks) Count() int {
+	return len(l.Writable) + len(l.Readonly)
+}
+
+// BundleAccountLocks are the accounts locked by each transaction of a bundle.
+type BundleAccountLocks struct {
+	Transactions []TransactionAccountLocks // Locks of each transaction, in bundle order
+}
+
+// BundleConflict is a contention between a bundle and a bundle in flight.
+type BundleConflict struct {
+	BundleID string             // ID of the bundle in flight
+	Accounts []solana.PublicKey // Accounts written by one bundle and locked by the other
+}
+
+// AccountLockAnalyzer resolves the accounts locked by transactions, fetching address lookup tables over RPC,
+// and flags the lock contentions within a bundle and with the bundles in flight. The signers and fee payers
+// of the transactions, usually the searcher's own wallets, and the excluded accounts, e.g. the tip accounts,
+// are not reported as contended.
+type AccountLockAnalyzer struct {
+	rpcClient *rpc.Client                             // RPC client used to fetch lookup tables
+	lockLimit int                                     // Maximum number of accounts a transaction can lock
+	ttl       time.Duration                           // How long the locks of a bundle in flight are kept
+	excluded  map[solana.PublicKey]struct{}           // Accounts excluded from the contentions, replaced on change
+	tables    map[solana.PublicKey][]solana.PublicKey // Cached lookup table addresses
+	inflight  map[string]*inflightLocks               // Locks of the bundles in flight by bundle ID
+	mu        sync.Mutex                              // Mutex for synchronizing the tables and bundles in flight
+}
+
+// inflightLocks are the writable and readonly accounts of a bundle in flight.
+type inflightLocks struct {
+	writable  map[solana.PublicKey]struct{}
+	readonly  map[solana.PublicKey]struct{}
+	expiresAt time.Time
+}
+
+// NewAccountLockAnalyzer creates an AccountLockAnalyzer fetching lookup tables with the RPC client.
+// Transactions are limited to MaxTransactionAccountLocks accounts and locks in flight kept for DefaultInflightLocksTTL.
+func NewAccountLockAnalyzer(rpcClient *rpc.Client) *AccountLockAnalyzer {
+	return &AccountLockAnalyzer{
+		rpcClient: rpcClient,
+		lockLimit: MaxTransactionAccountLocks,
+		ttl:       DefaultInflightLocksTTL,
+		excluded:  make(map[solana.PublicKey]struct{}),
+		tables:    make(map[solana.PublicKey][]solana.PublicKey),
+		inflight:  make(map[string]*inflightLocks),
+	}
+}
+
+// SetAccountLockLimit sets the maximum number of accounts a transaction can lock,
+// e.g. 128 on clusters where the increased account lock limit is active.
+func (a *AccountLockAnalyzer) SetAccountLockLimit(limit int) *AccountLockAnalyzer {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.lockLimit = limit
+	return a
+}
+
+// SetInflightTTL sets how long the locks of a bundle in flight are kept if it is not released.
+func (a *AccountLockAnalyzer) SetInflightTTL(ttl time.Duration) *AccountLockAnalyzer {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.ttl = ttl
+	return a
+}
+
+// ExcludeAccounts excludes accounts from the contention checks, e.g. the tip accounts every bundle writes.
+func (a *AccountLockAnalyzer) ExcludeAccounts(accounts ...solana.PublicKey) *AccountLockAnalyzer {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// The set is copied on change, as Analyze reads it without the lock
+	var excluded map[solana.PublicKey]struct{}
+	for _, account := range accounts {
+		if _, ok := a.excluded[account]; ok {
+			continue
+		}
+		if excluded == nil {
+			excluded = make(map[solana.PublicKey]struct{}, len(a.excluded)+len(accounts))
+			for account := range a.excluded {
+				excluded[account] = struct{}{}
+			}
+		}
+		excluded[account] = struct{}{}
+	}
+	if excluded != nil {
+		a.excluded = excluded
+	}
+	return a
+}
+
+// ResolveAccounts returns the accounts the transaction locks, fetching the lookup tables it uses when not cached.
+func (a *AccountLockAnalyzer) ResolveAccounts(ctx context.Context, tx *solana.Transaction) (*TransactionAccountLocks, error) {
+	locks := &TransactionAccountLocks{}
+	for i, account := range tx.Message.AccountKeys {
+		if i < int(tx.Message.Header.NumRequiredSignatures) {
+			locks.Signers = append(locks.Signers, account)
+		}
+		if isWritableIndex(tx.Message.Header, len(tx.Message.AccountKeys), i) {
+			locks.Writable = append(locks.Writable, account)
+		} else {
+			locks.Readonly = append(locks.Readonly, account)
+		}
+	}
+
+	for _, lookup := range tx.Message.AddressTableLookups {
+		addresses, err := a.lookupTable(ctx, lookup.AccountKey, lookup.WritableIndexes, lookup.ReadonlyIndexes)
+		if err != nil {
+			return nil, err
+		}
+		for _, index := range lookup.WritableIndexes {
+			locks.Writable = append(locks.Writable, addresses[index])
+		}
+		for _, index := range lookup.ReadonlyIndexes {
+			locks.Readonly = append(locks.Readonly, addresses[index])
+		}
+	}
+
+	return locks, nil
+}
+
+// Analyze resolves the accounts locked by the bundle transactions and returns the findings of the analysis:
+// transactions over the account lock limit or loading an account twice, transactions contending with an
+// earlier transaction of the bundle, whose order then matters, and contentions with the bundles in flight.
+// Signers and excluded accounts are not contended.
+// It returns an error if a lookup table could not be fetched.
+func (a *AccountLockAnalyzer) Analyze(
+	ctx context.Context,
+	transactions []*solana.Transaction,
+) (*BundleAccountLocks, BundleFindings, error) {
+	var findings BundleFindings
+	add := func(severity FindingSeverity, code FindingCode, txIndex int, format string, args ...interface{}) {
+		findings = append(findings, BundleFinding{
+			Severity: severity,
+			Code:     code,
+			TxIndex:  txIndex,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	a.mu.Lock()
+	lockLimit, excluded := a.lockLimit, a.excluded
+	a.mu.Unlock()
+
+	bundle := &BundleAccountLocks{Transactions: make([]TransactionAccountLocks, 0, len(transactions))}
+	for i, tx := range transactions {
+		locks, err := a.ResolveAccounts(ctx, tx)
+		if errors.Is(err, ErrInvalidLookupTable) {
+			add(SeverityError, FindingUnresolvedAccounts, i, "%v", err)
+			bundle.Transactions = append(bundle.Transactions, TransactionAccountLocks{})
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		bundle.Transactions = append(bundle.Transactions, *locks)
+
+		// Check the account lock limit and duplicate accounts
+		if locks.Count() > lockLimit {
+			add(SeverityError, FindingTooManyAccountLocks, i,
+				"transaction locks %d accounts, maximum is %d", locks.Count(), lockLimit)
+		}
+		seen := make(map[solana.PublicKey]struct{}, locks.Count())
+		for _, account := range append(append([]solana.PublicKey{}, locks.Writable...), locks.Readonly...) {
+			if _, ok := seen[account]; ok {
+				add(SeverityError, FindingDuplicateAccount, i, "account %s is loaded more than once", account)
+				continue
+			}
+			seen[account] = struct{}{}
+		}
+
+		// Check the contentions with the earlier transactions of the bundle
+		for j := 0; j < i; j++ {
+			earlier := bundle.Transactions[j]
+			contended := withoutAccounts(
+				contendedAccounts(
+					accountSet(locks.Writable), accountSet(locks.Readonly),
+					accountSet(earlier.Writable), accountSet(earlier.Readonly),
+				),
+				accountSet(locks.Signers), accountSet(earlier.Signers), excluded,
+			)
+			if len(contended) > 0 {
+				add(SeverityWarning, FindingWriteLockConflict, i,
+					"transaction contends with transaction %d on %d accounts, e.g. %s", j, len(contended), contended[0])
+			}
+		}
+	}
+
+	for _, conflict := range a.Conflicts(bundle) {
+		add(SeverityWarning, FindingInflightConflict, -1,
+			"bundle contends with bundle %s in flight on %d accounts, e.g. %s",
+			conflict.BundleID, len(conflict.Accounts), conflict.Accounts[0])
+	}
+
+	return bundle, findings, nil
+}
+
+// Track records the locks of a bundle in flight, until it is released or the in-flight TTL elapsed.
+func (a *AccountLockAnalyzer) Track(bundleID string, locks *BundleAccountLocks) {
+	writable, readonly := locks.sets()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.inflight[bundleID] = &inflightLocks{
+		writable:  writable,
+		readonly:  readonly,
+		expiresAt: time.Now().Add(a.ttl),
+	}
+}
+
+// Release forgets the locks of a bundle that is no longer in flight.
+func (a *AccountLockAnalyzer) Release(bundleID string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.inflight, bundleID)
+}
+
+// Conflicts returns the bundles in flight contending with the bundle on an account,
+// that is an account written by one of the bundles and locked by the other, other than their signers
+// and the excluded accounts.
+func (a *AccountLockAnalyzer) Conflicts(locks *BundleAccountLocks) []BundleConflict {
+	writable, readonly := locks.sets()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	var conflicts []BundleConflict
+	for bundleID, inflight := range a.inflight {
+		if now.After(inflight.expiresAt) {
+			delete(a.inflight, bundleID)
+			continue
+		}
+
+		contended := withoutAccounts(
+			contendedAccounts(writable, readonly, inflight.writable, inflight.readonly),
+			a.excluded,
+		)
+		if len(contended) > 0 {
+			conflicts = append(conflicts, BundleConflict{
+				BundleID: bundleID,
+				Accounts: contended,
+			})
+		}
+	}
+
+	return conflicts
+}
+
+// lookupTable returns the addresses of a lookup table, refetching it when an index is past the cached
+// addresses as the table may have been extended.
+func (a *AccountLockAnalyzer) lookupTable(
+	ctx context.Context,
+	table solana.PublicKey,
+	writableIndexes, readonlyIndexes []uint8,
+) ([]solana.PublicKey, error) {
+	maxIndex := -1
+	for _, index := range append(append([]uint8{}, writableIndexes...), readonlyIndexes...) {
+		if int(index) > maxIndex {
+			maxIndex = int(index)
+		}
+	}
+
+	a.mu.Lock()
+	addresses, ok := a.tables[table]
+	a.mu.Unlock()
+	if ok && maxIndex < len(addresses) {
+		return addresses, nil
+	}
+
+	resp, err := a.rpcClient.GetAccountInfo(ctx, table)
+	if err != nil && !errors.Is(err, rpc.ErrNotFound) {
+		return nil, fmt.Errorf("could not get lookup table %s: %w", table, err)
+	}
+	if resp == nil || resp.Value == nil {
+		return nil, fmt.Errorf("%w: lookup table %s not found", ErrInvalidLookupTable, table)
+	}
+	if !resp.Value.Owner.Equals(AddressLookupTableProgramID) {
+		return nil, fmt.Errorf("%w: account %s is not a lookup table", ErrInvalidLookupTable, table)
+	}
+
+	addresses, err = DecodeAddressLookupTable(resp.Value.Data.GetBinary())
+	if err != nil {
+		return nil, fmt.Errorf("lookup table %s: %w", table, err)
+	}
+	if maxIndex >= len(addresses) {
+		return nil, fmt.Errorf("%w: index %d is past the %d addresses of lookup table %s",
+			ErrInvalidLookupTable, maxIndex, len(addresses), table)
+	}
+
+	a.mu.Lock()
+	a.tables[table] = addresses
+	a.mu.Unlock()
+
+	return addresses, nil
+}
+
+// DecodeAddressLookupTable decodes the addresses of an address lookup table account.
+// The addresses follow a 56 bytes state header starting with the little-endian state type.
+func DecodeAddressLookupTable(data []byte) ([]solana.PublicKey, error) {
+	if len(data) < addressLookupTableMetaSize {
+		return nil, fmt.Errorf("%w: %d bytes is shorter than the table header", ErrInvalidLookupTable, len(data))
+	}
+	if binary.LittleEndian.Uint32(data[:4]) != addressLookupTableStateType {
+		return nil, fmt.Errorf("%w: table is not initialized", ErrInvalidLookupTable)
+	}
+
+	data = data[addressLookupTableMetaSize:]
+	if len(data)%solana.PublicKeyLength != 0 {
+		return nil, fmt.Errorf("%w: addresses are not a multiple of %d bytes", ErrInvalidLookupTable, solana.PublicKeyLength)
+	}
+
+	addresses := make([]solana.PublicKey, 0, len(data)/solana.PublicKeyLength)
+	for i := 0; i < len(data); i += solana.PublicKeyLength {
+		addresses = append(addresses, solana.PublicKeyFromBytes(data[i:i+solana.PublicKeyLength]))
+	}
+	return addresses, nil
+}
+
+// sets returns the accounts written and the accounts only read by the bundle, other than its signers.
+func (l *BundleAccountLocks) sets() (map[solana.PublicKey]struct{}, map[solana.PublicKey]struct{}) {
+	signers := make(map[solana.PublicKey]struct{})
+	for _, tx := range l.Transactions {
+		for _, account := range tx.Signers {
+			signers[account] = struct{}{}
+		}
+	}
+
+	writable := make(map[solana.PublicKey]struct{})
+	readonly := make(map[solana.PublicKey]struct{})
+	for _, tx := range l.Transactions {
+		for _, account := range tx.Writable {
+			if _, ok := signers[account]; !ok {
+				writable[account] = struct{}{}
+			}
+		}
+	}
+	for _, tx := range l.Transactions {
+		for _, account := range tx.Readonly {
+			_, signer := signers[account]
+			_, written := writable[account]
+			if !signer && !written {
+				readonly[account] = struct{}{}
+			}
+		}
+	}
+	return writable, readonly
+}
+
+// accountSet returns the accounts as a set.
+func accountSet(accounts []solana.PublicKey) map[solana.PublicKey]struct{} {
+	set := make(map[solana.PublicKey]struct{}, len(accounts))
+	for _, account := range accounts {
+		set[account] = struct{}{}
+	}
+	return set
+}
+
+// withoutAccounts returns the accounts in none of the sets.
+func withoutAccounts(accounts []solana.PublicKey, sets ...map[solana.PublicKey]struct{}) []solana.PublicKey {
+	kept := accounts[:0]
+	for _, account := range accounts {
+		excluded := false
+		for _, set := range sets {
+			if _, ok := set[account]; ok {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			kept = append(kept, account)
+		}
+	}
+	return kept
+}
+
+// contendedAccounts returns the accounts written by one side and locked by the other.
+func contendedAccounts(writableA, readonlyA, writableB, readonlyB map[solana.PublicKey]struct{}) []solana.PublicKey {
+	var contended []solana.PublicKey
+	for account := range writableA {
+		_, written := writableB[account]
+		_, read := readonlyB[account]
+		if written || read {
+			contended = append(contended, account)
+		}
+	}
+	for account := range readonlyA {
+		if _, ok := writableB[account]; ok {
+			contended = append(contended, account)
+		}
+	}
+	return contended
+}
@@ -0,0 +1,359 @@
+package pkg
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// lookupTableRPC serves getAccountInfo for address lookup tables.
+type lookupTableRPC struct {
+	tables map[string][]byte // Account data of the tables by address
+	calls  int               // Number of getAccountInfo calls
+	mu     sync.Mutex
+}
+
+// newLookupTableRPC returns an RPC client backed by a lookupTableRPC.
+func newLookupTableRPC(t *testing.T) (*lookupTableRPC, *rpc.Client) {
+	t.Helper()
+	server := &lookupTableRPC{tables: make(map[string][]byte)}
+
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var address string
+		if err := json.Unmarshal(req.Params[0], &address); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		server.mu.Lock()
+		server.calls++
+		data, ok := server.tables[address]
+		server.mu.Unlock()
+
+		var value interface{}
+		if ok {
+			value = map[string]interface{}{
+				"data":       []string{base64.StdEncoding.EncodeToString(data), "base64"},
+				"executable": false,
+				"lamports":   1,
+				"owner":      AddressLookupTableProgramID.String(),
+				"rentEpoch":  0,
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"result":  map[string]interface{}{"context": map[string]interface{}{"slot": 1}, "value": value},
+		})
+	}))
+	t.Cleanup(httpServer.Close)
+
+	return server, rpc.New(httpServer.URL)
+}
+
+// setTable serves a lookup table holding the addresses.
+func (s *lookupTableRPC) setTable(table solana.PublicKey, addresses ...solana.PublicKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tables[table.String()] = encodeLookupTable(addresses...)
+}
+
+// count returns the number of getAccountInfo calls.
+func (s *lookupTableRPC) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls
+}
+
+// encodeLookupTable encodes an initialized address lookup table account holding the addresses.
+func encodeLookupTable(addresses ...solana.PublicKey) []byte {
+	data := make([]byte, addressLookupTableMetaSize, addressLookupTableMetaSize+len(addresses)*solana.PublicKeyLength)
+	binary.LittleEndian.PutUint32(data, addressLookupTableStateType)
+	for _, address := range addresses {
+		data = append(data, address.Bytes()...)
+	}
+	return data
+}
+
+// newLockTransaction returns a transaction locking the payer and the accounts for writing, and a program for reading.
+func newLockTransaction(payer solana.PublicKey, writable ...solana.PublicKey) *solana.Transaction {
+	return &solana.Transaction{Message: solana.Message{
+		AccountKeys: append(append([]solana.PublicKey{payer}, writable...), solana.SystemProgramID),
+		Header:      solana.MessageHeader{NumRequiredSignatures: 1, NumReadonlyUnsignedAccounts: 1},
+	}}
+}
+
+// findingCount returns the number of findings with the code.
+func findingCount(findings BundleFindings, code FindingCode) int {
+	n := 0
+	for _, finding := range findings {
+		if finding.Code == code {
+			n++
+		}
+	}
+	return n
+}
+
+func TestDecodeAddressLookupTable(t *testing.T) {
+	first, second := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	addresses, err := DecodeAddressLookupTable(encodeLookupTable(first, second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addresses) != 2 || addresses[0] != first || addresses[1] != second {
+		t.Errorf("DecodeAddressLookupTable() = %v, want [%s %s]", addresses, first, second)
+	}
+
+	uninitialized := encodeLookupTable(first)
+	binary.LittleEndian.PutUint32(uninitialized, 0)
+	for name, data := range map[string][]byte{
+		"short header":  make([]byte, addressLookupTableMetaSize-1),
+		"uninitialized": uninitialized,
+		"misaligned":    encodeLookupTable(first)[:addressLookupTableMetaSize+10],
+	} {
+		if _, err := DecodeAddressLookupTable(data); !errors.Is(err, ErrInvalidLookupTable) {
+			t.Errorf("%s: got %v, want ErrInvalidLookupTable", name, err)
+		}
+	}
+}
+
+func TestAccountLockAnalyzerResolvesLookupTables(t *testing.T) {
+	server, client := newLookupTableRPC(t)
+	table := solana.NewWallet().PublicKey()
+	payer := solana.NewWallet().PublicKey()
+	loaded := []solana.PublicKey{solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()}
+	server.setTable(table, loaded[:2]...)
+
+	tx := newLockTransaction(payer)
+	tx.Message.SetVersion(solana.MessageVersionV0)
+	tx.Message.AddressTableLookups = solana.MessageAddressTableLookupSlice{{
+		AccountKey:      table,
+		WritableIndexes: []uint8{1},
+		ReadonlyIndexes: []uint8{0},
+	}}
+
+	analyzer := NewAccountLockAnalyzer(client)
+	locks, err := analyzer.ResolveAccounts(context.Background(), tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locks.Writable) != 2 || locks.Writable[0] != payer || locks.Writable[1] != loaded[1] {
+		t.Errorf("Writable = %v, want [%s %s]", locks.Writable, payer, loaded[1])
+	}
+	if len(locks.Readonly) != 2 || locks.Readonly[0] != solana.SystemProgramID || locks.Readonly[1] != loaded[0] {
+		t.Errorf("Readonly = %v, want [%s %s]", locks.Readonly, solana.SystemProgramID, loaded[0])
+	}
+	if len(locks.Signers) != 1 || locks.Signers[0] != payer {
+		t.Errorf("Signers = %v, want [%s]", locks.Signers, payer)
+	}
+
+	// Cached tables are only refetched for an index past their addresses, as they may have been extended
+	if _, err = analyzer.ResolveAccounts(context.Background(), tx); err != nil || server.count() != 1 {
+		t.Errorf("cached table fetched %d times, %v", server.count(), err)
+	}
+	server.setTable(table, loaded...)
+	tx.Message.AddressTableLookups[0].WritableIndexes = []uint8{2}
+	locks, err = analyzer.ResolveAccounts(context.Background(), tx)
+	if err != nil || server.count() != 2 || locks.Writable[1] != loaded[2] {
+		t.Errorf("extended table: %v after %d fetches, %v", locks, server.count(), err)
+	}
+
+	// Lookups that cannot be resolved are error findings
+	tx.Message.AddressTableLookups[0].WritableIndexes = []uint8{3}
+	_, findings, err := analyzer.Analyze(context.Background(), []*solana.Transaction{tx})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if findingCount(findings, FindingUnresolvedAccounts) != 1 || findings.Err() == nil {
+		t.Errorf("findings = %v, want an unresolved accounts error", findings)
+	}
+	tx.Message.AddressTableLookups[0].AccountKey = solana.NewWallet().PublicKey()
+	if _, findings, err = analyzer.Analyze(context.Background(), []*solana.Transaction{tx}); err != nil ||
+		findingCount(findings, FindingUnresolvedAccounts) != 1 {
+		t.Errorf("missing table: findings = %v, %v", findings, err)
+	}
+}
+
+func TestAccountLockAnalyzerIntraBundle(t *testing.T) {
+	_, client := newLookupTableRPC(t)
+	shared, excluded := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	first, second := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+
+	tests := []struct {
+		name  string
+		txs   []*solana.Transaction
+		codes map[FindingCode]int
+	}{
+		{
+			name:  "independent transactions",
+			txs:   []*solana.Transaction{newLockTransaction(first), newLockTransaction(second)},
+			codes: map[FindingCode]int{},
+		},
+		{
+			name:  "written by both",
+			txs:   []*solana.Transaction{newLockTransaction(first, shared), newLockTransaction(second, shared)},
+			codes: map[FindingCode]int{FindingWriteLockConflict: 1},
+		},
+		{
+			name:  "same payer in every transaction",
+			txs:   []*solana.Transaction{newLockTransaction(first), newLockTransaction(first), newLockTransaction(first)},
+			codes: map[FindingCode]int{},
+		},
+		{
+			name:  "signer written by another transaction",
+			txs:   []*solana.Transaction{newLockTransaction(first), newLockTransaction(second, first)},
+			codes: map[FindingCode]int{},
+		},
+		{
+			name:  "excluded account written by both",
+			txs:   []*solana.Transaction{newLockTransaction(first, excluded), newLockTransaction(second, excluded)},
+			codes: map[FindingCode]int{},
+		},
+		{
+			name:  "account loaded twice",
+			txs:   []*solana.Transaction{newLockTransaction(first, shared, shared)},
+			codes: map[FindingCode]int{FindingDuplicateAccount: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locks, findings, err := NewAccountLockAnalyzer(client).ExcludeAccounts(excluded).Analyze(context.Background(), tt.txs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(locks.Transactions) != len(tt.txs) {
+				t.Errorf("got locks of %d transactions, want %d", len(locks.Transactions), len(tt.txs))
+			}
+			for _, code := range []FindingCode{FindingWriteLockConflict, FindingDuplicateAccount, FindingTooManyAccountLocks} {
+				if got := findingCount(findings, code); got != tt.codes[code] {
+					t.Errorf("got %d %s findings, want %d: %v", got, code, tt.codes[code], findings)
+				}
+			}
+		})
+	}
+}
+
+func TestAccountLockAnalyzerSamePayer(t *testing.T) {
+	_, client := newLookupTableRPC(t)
+	payer := solana.NewWallet().PublicKey()
+	analyzer := NewAccountLockAnalyzer(client)
+
+	// Two bundles of the same payer touching unrelated accounts
+	first, findings, err := analyzer.Analyze(context.Background(), []*solana.Transaction{
+		newLockTransaction(payer, solana.NewWallet().PublicKey()),
+		newLockTransaction(payer, solana.NewWallet().PublicKey()),
+	})
+	if err != nil || len(findings) != 0 {
+		t.Fatalf("first bundle findings = %v, %v", findings, err)
+	}
+	analyzer.Track("first", first)
+
+	_, findings, err = analyzer.Analyze(context.Background(), []*solana.Transaction{
+		newLockTransaction(payer, solana.NewWallet().PublicKey()),
+	})
+	if err != nil || len(findings) != 0 {
+		t.Errorf("second bundle findings = %v, %v, want none", findings, err)
+	}
+}
+
+func TestAccountLockAnalyzerLockLimit(t *testing.T) {
+	_, client := newLookupTableRPC(t)
+	tx := newLockTransaction(solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey())
+
+	analyzer := NewAccountLockAnalyzer(client).SetAccountLockLimit(2)
+	_, findings, err := analyzer.Analyze(context.Background(), []*solana.Transaction{tx})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if findingCount(findings, FindingTooManyAccountLocks) != 1 || findings.Err() == nil {
+		t.Errorf("findings = %v, want a too many account locks error", findings)
+	}
+
+	analyzer.SetAccountLockLimit(3)
+	if _, findings, _ = analyzer.Analyze(context.Background(), []*solana.Transaction{tx}); len(findings) != 0 {
+		t.Errorf("findings at the limit = %v", findings)
+	}
+}
+
+func TestAccountLockAnalyzerInflight(t *testing.T) {
+	_, client := newLookupTableRPC(t)
+	shared := solana.NewWallet().PublicKey()
+	analyzer := NewAccountLockAnalyzer(client)
+
+	inflight, _, err := analyzer.Analyze(context.Background(), []*solana.Transaction{
+		newLockTransaction(solana.NewWallet().PublicKey(), shared),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	analyzer.Track("inflight", inflight)
+
+	contending := []*solana.Transaction{newLockTransaction(solana.NewWallet().PublicKey(), shared)}
+	locks, findings, err := analyzer.Analyze(context.Background(), contending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if findingCount(findings, FindingInflightConflict) != 1 {
+		t.Errorf("findings = %v, want an inflight conflict", findings)
+	}
+	conflicts := analyzer.Conflicts(locks)
+	if len(conflicts) != 1 || conflicts[0].BundleID != "inflight" || len(conflicts[0].Accounts) != 1 || conflicts[0].Accounts[0] != shared {
+		t.Errorf("Conflicts() = %+v, want inflight on %s", conflicts, shared)
+	}
+
+	// Bundles of the same payer only contend on the other accounts they write
+	payer := solana.NewWallet().PublicKey()
+	if conflicts = analyzer.Conflicts(&BundleAccountLocks{Transactions: []TransactionAccountLocks{
+		{Writable: []solana.PublicKey{payer, shared}, Signers: []solana.PublicKey{payer}},
+	}}); len(conflicts) != 1 || len(conflicts[0].Accounts) != 1 || conflicts[0].Accounts[0] != shared {
+		t.Errorf("Conflicts() = %+v, want inflight on %s", conflicts, shared)
+	}
+
+	// Bundles only reading the same readonly accounts do not contend
+	if conflicts = analyzer.Conflicts(&BundleAccountLocks{Transactions: []TransactionAccountLocks{
+		{Readonly: []solana.PublicKey{solana.SystemProgramID}},
+	}}); len(conflicts) != 0 {
+		t.Errorf("readonly bundle conflicts: %+v", conflicts)
+	}
+
+	analyzer.Release("inflight")
+	if conflicts = analyzer.Conflicts(locks); len(conflicts) != 0 {
+		t.Errorf("released bundle conflicts: %+v", conflicts)
+	}
+
+	// Excluded accounts, e.g. the tip accounts, do not contend
+	analyzer.Track("inflight", inflight)
+	analyzer.ExcludeAccounts(shared)
+	if conflicts = analyzer.Conflicts(locks); len(conflicts) != 0 {
+		t.Errorf("excluded account conflicts: %+v", conflicts)
+	}
+	analyzer.Release("inflight")
+
+	// Locks are forgotten once the TTL elapsed
+	analyzer.SetInflightTTL(time.Millisecond)
+	analyzer.Track("expiring", inflight)
+	time.Sleep(5 * time.Millisecond)
+	if conflicts = analyzer.Conflicts(locks); len(conflicts) != 0 {
+		t.Errorf("expired bundle conflicts: %+v", conflicts)
+	}
+}
@@ -0,0 +1,117 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+
+	jito_pb "github.com/Prophet-Solutions/block-engine-protos/packet"
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+// Errors returned when checking a backrun against its target
+var (
+	ErrBackrunTargetNotSigned   = errors.New("backrun target is not fully signed")
+	ErrBackrunTargetExpired     = errors.New("backrun target blockhash expired")
+	ErrBackrunNoWritableOverlap = errors.New("backrun transactions do not touch the target's writable accounts")
+)
+
+// BackrunTarget is an observed transaction to backrun, sent unmodified at the start of the bundle.
+type BackrunTarget struct {
+	Transaction *solana.Transaction // Target transaction
+	Writable    []solana.PublicKey  // Writable accounts of the target, including those loaded from lookup tables when known
+}
+
+// NewBackrunTarget creates a BackrunTarget from a transaction. Only the writable accounts of the static
+// account keys are known, accounts the target loads from address lookup tables are not.
+func NewBackrunTarget(tx *solana.Transaction) *BackrunTarget {
+	return &BackrunTarget{
+		Transaction: tx,
+		Writable:    WritableAccountKeys(tx),
+	}
+}
+
+// NewBackrunTargetFromBytes creates a BackrunTarget from a transaction in its wire format.
+func NewBackrunTargetFromBytes(data []byte) (*BackrunTarget, error) {
+	tx := &solana.Transaction{}
+	if err := tx.UnmarshalWithDecoder(bin.NewBorshDecoder(data)); err != nil {
+		return nil, fmt.Errorf("could not decode backrun target: %w", err)
+	}
+	return NewBackrunTarget(tx), nil
+}
+
+// NewBackrunTargetFromPacket creates a BackrunTarget from a protobuf packet, e.g. one received from a relayer.
+func NewBackrunTargetFromPacket(packet *jito_pb.Packet) (*BackrunTarget, error) {
+	return NewBackrunTargetFromBytes(packet.GetData())
+}
+
+// VerifySignatures checks that every required signer of the target signed it with a valid signature.
+// It returns an error wrapping ErrBackrunTargetNotSigned otherwise.
+func (t *BackrunTarget) VerifySignatures() error {
+	tx := t.Transaction
+	message, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("could not serialize backrun target message: %w", err)
+	}
+
+	numSigners := int(tx.Message.Header.NumRequiredSignatures)
+	if numSigners == 0 || len(tx.Signatures) < numSigners || len(tx.Message.AccountKeys) < numSigners {
+		return fmt.Errorf("%w: %d signatures for %d required signers",
+			ErrBackrunTargetNotSigned, len(tx.Signatures), numSigners)
+	}
+	for i := 0; i < numSigners; i++ {
+		signer := tx.Message.AccountKeys[i]
+		if tx.Signatures[i].IsZero() {
+			return fmt.Errorf("%w: missing signature for signer %s", ErrBackrunTargetNotSigned, signer)
+		}
+		if !tx.Signatures[i].Verify(signer, message) {
+			return fmt.Errorf("%w: invalid signature for signer %s", ErrBackrunTargetNotSigned, signer)
+		}
+	}
+
+	return nil
+}
+
+// CheckOverlap checks that at least one of the transactions references a writable account of the target,
+// ignoring the given accounts, e.g. the tip accounts. Only the static account keys of the transactions are
+// taken into account. It returns ErrBackrunNoWritableOverlap otherwise.
+func (t *BackrunTarget) CheckOverlap(transactions []*solana.Transaction, ignored ...solana.PublicKey) error {
+	writable := make(map[solana.PublicKey]struct{}, len(t.Writable))
+	for _, account := range t.Writable {
+		writable[account] = struct{}{}
+	}
+	for _, account := range ignored {
+		delete(writable, account)
+	}
+
+	for _, tx := range transactions {
+		for _, account := range tx.Message.AccountKeys {
+			if _, ok := writable[account]; ok {
+				return nil
+			}
+		}
+	}
+
+	return ErrBackrunNoWritableOverlap
+}
+
+// WritableAccountKeys returns the static account keys the transaction locks for writing.
+func WritableAccountKeys(tx *solana.Transaction) []solana.PublicKey {
+	var writable []solana.PublicKey
+	for i, account := range tx.Message.AccountKeys {
+		if isWritableIndex(tx.Message.Header, len(tx.Message.AccountKeys), i) {
+			writable = append(writable, account)
+		}
+	}
+	return writable
+}
+
+// isWritableIndex reports whether the static account key at the index is writable. Account keys are
+// ordered writable signers, readonly signers, writable non-signers, readonly non-signers.
+func isWritableIndex(header solana.MessageHeader, numKeys int, index int) bool {
+	numSigners := int(header.NumRequiredSignatures)
+	if index < numSigners {
+		return index < numSigners-int(header.NumReadonlySignedAccounts)
+	}
+	return index-numSigners < numKeys-numSigners-int(header.NumReadonlyUnsignedAccounts)
+}
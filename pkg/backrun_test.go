@@ -0,0 +1,121 @@
+package pkg
+
+import (
+	"errors"
+	"testing"
+
+	jito_pb "github.com/Prophet-Solutions/block-engine-protos/packet"
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestNewBackrunTargetFromBytes(t *testing.T) {
+	payer := newTestKey(t)
+	recipient := solana.NewWallet().PublicKey()
+	tx := newTransferTransaction(t, payer, recipient, 1_000, solana.Hash{1})
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, decode := range map[string]func() (*BackrunTarget, error){
+		"bytes":  func() (*BackrunTarget, error) { return NewBackrunTargetFromBytes(data) },
+		"packet": func() (*BackrunTarget, error) { return NewBackrunTargetFromPacket(&jito_pb.Packet{Data: data}) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			target, err := decode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if target.Transaction.Signatures[0] != tx.Signatures[0] {
+				t.Errorf("decoded signature %s, want %s", target.Transaction.Signatures[0], tx.Signatures[0])
+			}
+			// The payer and the recipient are writable, the system program is not
+			if len(target.Writable) != 2 || target.Writable[0] != payer.PublicKey() || target.Writable[1] != recipient {
+				t.Errorf("Writable = %v, want [%s %s]", target.Writable, payer.PublicKey(), recipient)
+			}
+			if err = target.VerifySignatures(); err != nil {
+				t.Errorf("VerifySignatures() = %v", err)
+			}
+		})
+	}
+
+	if _, err = NewBackrunTargetFromBytes(data[:10]); err == nil {
+		t.Error("truncated transaction decoded")
+	}
+}
+
+func TestBackrunTargetVerifySignatures(t *testing.T) {
+	payer := newTestKey(t)
+	recipient := solana.NewWallet().PublicKey()
+
+	tests := []struct {
+		name   string
+		tamper func(tx *solana.Transaction)
+	}{
+		{name: "missing signature", tamper: func(tx *solana.Transaction) { tx.Signatures[0] = solana.Signature{} }},
+		{name: "no signatures", tamper: func(tx *solana.Transaction) { tx.Signatures = nil }},
+		{name: "modified message", tamper: func(tx *solana.Transaction) { tx.Message.RecentBlockhash = solana.Hash{2} }},
+		{name: "signature of another key", tamper: func(tx *solana.Transaction) {
+			tx.Signatures[0] = newTransferTransaction(t, newTestKey(t), recipient, 1_000, solana.Hash{1}).Signatures[0]
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := newTransferTransaction(t, payer, recipient, 1_000, solana.Hash{1})
+			tt.tamper(tx)
+			if err := NewBackrunTarget(tx).VerifySignatures(); !errors.Is(err, ErrBackrunTargetNotSigned) {
+				t.Errorf("VerifySignatures() = %v, want ErrBackrunTargetNotSigned", err)
+			}
+		})
+	}
+}
+
+func TestBackrunTargetCheckOverlap(t *testing.T) {
+	target := NewBackrunTarget(newTransferTransaction(t, newTestKey(t), solana.NewWallet().PublicKey(), 1_000, solana.Hash{1}))
+	writable := target.Writable[1]
+	tipAccount := solana.NewWallet().PublicKey()
+	searcher := newTestKey(t)
+
+	tests := []struct {
+		name      string
+		recipient solana.PublicKey
+		ignored   []solana.PublicKey
+		want      error
+	}{
+		{name: "touches a writable account", recipient: writable},
+		{name: "touches only readonly accounts", recipient: solana.NewWallet().PublicKey(), want: ErrBackrunNoWritableOverlap},
+		{name: "touches an ignored account", recipient: writable, ignored: []solana.PublicKey{writable}, want: ErrBackrunNoWritableOverlap},
+		{name: "touches another ignored account", recipient: writable, ignored: []solana.PublicKey{tipAccount}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The system program of the backrun is readonly in the target
+			tx := newTransferTransaction(t, searcher, tt.recipient, 1, solana.Hash{1})
+			if err := target.CheckOverlap([]*solana.Transaction{tx}, tt.ignored...); !errors.Is(err, tt.want) {
+				t.Errorf("CheckOverlap() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWritableAccountKeys(t *testing.T) {
+	keys := []solana.PublicKey{
+		solana.NewWallet().PublicKey(), // writable signer
+		solana.NewWallet().PublicKey(), // readonly signer
+		solana.NewWallet().PublicKey(), // writable non-signer
+		solana.NewWallet().PublicKey(), // readonly non-signer
+	}
+	tx := &solana.Transaction{Message: solana.Message{
+		AccountKeys: keys,
+		Header: solana.MessageHeader{
+			NumRequiredSignatures:       2,
+			NumReadonlySignedAccounts:   1,
+			NumReadonlyUnsignedAccounts: 1,
+		},
+	}}
+
+	writable := WritableAccountKeys(tx)
+	if len(writable) != 2 || writable[0] != keys[0] || writable[1] != keys[2] {
+		t.Errorf("WritableAccountKeys() = %v, want [%s %s]", writable, keys[0], keys[2])
+	}
+}
@@ -0,0 +1,61 @@
+package block_engine_pkg
+
+import (
+	"fmt"
+	"time"
+)
+
+// AuthEventType is the kind of outcome an AuthEvent reports.
+type AuthEventType int
+
+// Authentication outcomes
+const (
+	AuthEventAuthenticated          AuthEventType = iota // Initial challenge authentication succeeded
+	AuthEventAuthenticationFailed                        // Initial challenge authentication failed
+	AuthEventRefreshed                                   // Access token refreshed with the refresh token
+	AuthEventRefreshFailed                               // Access token refresh failed, it is retried with backoff
+	AuthEventReauthenticated                             // Challenge authentication rerun before the refresh token expired
+	AuthEventReauthenticationFailed                      // Challenge authentication rerun failed, it is retried with backoff
+	AuthEventStopped                                     // Refresh loop stopped as its context is done
+)
+
+// String returns the name of the event type.
+func (t AuthEventType) String() string {
+	switch t {
+	case AuthEventAuthenticated:
+		return "authenticated"
+	case AuthEventAuthenticationFailed:
+		return "authentication_failed"
+	case AuthEventRefreshed:
+		return "refreshed"
+	case AuthEventRefreshFailed:
+		return "refresh_failed"
+	case AuthEventReauthenticated:
+		return "reauthenticated"
+	case AuthEventReauthenticationFailed:
+		return "reauthentication_failed"
+	case AuthEventStopped:
+		return "stopped"
+	default:
+		return fmt.Sprintf("auth_event(%d)", int(t))
+	}
+}
+
+// AuthEvent is an outcome of the authentication and token refresh logic.
+type AuthEvent struct {
+	Type                  AuthEventType // Kind of outcome
+	Time                  time.Time     // Time of the outcome
+	AccessTokenExpiresAt  time.Time     // Expiration of the current access token
+	RefreshTokenExpiresAt time.Time     // Expiration of the current refresh token
+	Attempt               int           // Consecutive failed attempts, 0 on success
+	RetryIn               time.Duration // Delay before the next attempt of a failure
+	Err                   error         // Error of a failure
+}
+
+// String returns a human-readable representation of the event.
+func (e AuthEvent) String() string {
+	if e.Err != nil {
+		return fmt.Sprintf("auth %s (attempt %d, retry in %s): %v", e.Type, e.Attempt, e.RetryIn, e.Err)
+	}
+	return fmt.Sprintf("auth %s, access token expires at %s", e.Type, e.AccessTokenExpiresAt.Format(time.RFC3339))
+}
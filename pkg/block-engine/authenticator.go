@@ -3,57 +3,138 @@ package block_engine_pkg
 import (
 	"context"
 	"fmt"
-	"log"
+	"math/rand"
+	"strings"
 	"sync"
 	"time"
 
 	jito_pb "github.com/Prophet-Solutions/block-engine-protos/auth"
-	"github.com/gagliardetto/solana-go"
-	"github.com/mr-tron/base58"
+	"github.com/Prophet-Solutions/jito-go/pkg"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Constants for the token refresh loop
+const (
+	AccessTokenRefreshMargin = 15 * time.Second // How long before its expiration the access token is refreshed
+	RefreshTokenReauthMargin = 1 * time.Minute  // How long before its expiration the refresh token is replaced by re-authenticating
+	AuthRetryMinBackoff      = 1 * time.Second  // Delay before the first retry of a failed refresh
+	AuthRetryMaxBackoff      = 1 * time.Minute  // Maximum delay between retries of a failed refresh
+	AuthRenewalTimeout       = 20 * time.Second // Deadline of the calls renewing the tokens, shorter than DefaultTokenLockTimeout
 )
 
 // AuthenticationService handles the authentication logic for interacting with the gRPC services.
+// It implements credentials.PerRPCCredentials, attaching the current access token to every call of the
+// gRPC connection it is installed on, so token rotation is invisible to callers.
 type AuthenticationService struct {
-	AuthService jito_pb.AuthServiceClient // Client for authentication service
-	GRPCCtx     context.Context           // Context for gRPC operations
-	KeyPair     *solana.PrivateKey        // Private key for signing challenges
-	BearerToken string                    // Bearer token for authorization
-	ExpiresAt   int64                     // Expiration time for the token
-	ErrChan     chan error                // Channel for error handling
-	mu          sync.Mutex                // Mutex for synchronizing token updates
+	AuthService      jito_pb.AuthServiceClient // Client for authentication service
+	Signer           pkg.Signer                // Signer of the authentication challenges
+	BearerToken      string                    // Bearer token for authorization
+	ExpiresAt        int64                     // Expiration time for the token
+	RefreshExpiresAt int64                     // Expiration time for the refresh token
+	EventBus         *pkg.EventBus             // Bus the outcomes of authentication and refresh are published on
+	TokenStore       TokenStore                // Shares the tokens with other instances, nil to authenticate alone
+	TokenStoreScope  string                    // Scope of the stored tokens, e.g. the block engine address
+	ctx              context.Context           // Lifetime of the refresh loop
+	role             jito_pb.Role              // Role authenticated for
+	refreshToken     string                    // Current refresh token
+	mu               sync.Mutex                // Mutex for synchronizing token updates
 }
 
 // NewAuthenticationService creates a new instance of AuthenticationService.
+// The token refresh loop runs until ctx is done. The service must be installed on the gRPC connection
+// with grpc.WithPerRPCCredentials, and AuthService set to a client of that connection, see Connect.
+// Outcomes are published on the bus as pkg.EventAuth events, the bus may be nil to discard them.
 func NewAuthenticationService(
 	ctx context.Context,
-	grpcConn *grpc.ClientConn,
-	keyPair *solana.PrivateKey,
+	signer pkg.Signer,
+	bus *pkg.EventBus,
 ) *AuthenticationService {
 	return &AuthenticationService{
-		AuthService: jito_pb.NewAuthServiceClient(grpcConn),
-		GRPCCtx:     ctx,
-		KeyPair:     keyPair,
-		ErrChan:     make(chan error, 1),
-		mu:          sync.Mutex{},
+		Signer:   signer,
+		EventBus: bus,
+		ctx:      ctx,
+		mu:       sync.Mutex{},
+	}
+}
+
+// Connect sets AuthService to a client of the gRPC connection the service is installed on.
+func (as *AuthenticationService) Connect(grpcConn *grpc.ClientConn) {
+	as.AuthService = jito_pb.NewAuthServiceClient(grpcConn)
+}
+
+// GetRequestMetadata returns the authorization header with the current access token.
+// It implements credentials.PerRPCCredentials. Calls to the authentication service itself,
+// and calls made before the first authentication, carry no token.
+func (as *AuthenticationService) GetRequestMetadata(_ context.Context, uri ...string) (map[string]string, error) {
+	for _, u := range uri {
+		if strings.HasSuffix(u, "/"+jito_pb.AuthService_ServiceDesc.ServiceName) {
+			return nil, nil
+		}
 	}
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	if as.BearerToken == "" {
+		return nil, nil
+	}
+	return map[string]string{"authorization": "Bearer " + as.BearerToken}, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials.
+// Tokens are also sent over insecure connections, as http:// endpoints are supported.
+func (as *AuthenticationService) RequireTransportSecurity() bool {
+	return false
 }
 
 // AuthenticateAndRefresh handles the authentication and token refresh logic.
+// It authenticates with the challenge flow, then keeps the access token fresh in the background with the
+// refresh token, and reruns the challenge flow before the refresh token itself expires. Failures are retried
+// with jittered exponential backoff. Every outcome is published on EventBus with its AuthEvent as payload.
 func (as *AuthenticationService) AuthenticateAndRefresh(role jito_pb.Role) error {
+	as.role = role
+	if err := as.authenticate(""); err != nil {
+		as.publish(AuthEventAuthenticationFailed, 0, 0, err)
+		return err
+	}
+	as.publish(AuthEventAuthenticated, 0, 0, nil)
+
+	go as.refreshLoop()
+
+	return nil
+}
+
+// authenticate obtains a new access and refresh token pair. With a token store, a pair stored by another
+// instance is reused if both tokens are still valid and the refresh token is not the rejected one, and
+// the challenge flow only runs when there is none.
+func (as *AuthenticationService) authenticate(rejected string) error {
+	if as.TokenStore == nil {
+		return as.withRenewalTimeout(as.challenge)
+	}
+
+	return as.renewShared(func(tokens *StoredTokens) bool {
+		return tokens.RefreshToken != rejected &&
+			time.Until(time.Unix(tokens.AccessTokenExpiresAt, 0)) > AccessTokenRefreshMargin &&
+			time.Until(time.Unix(tokens.RefreshTokenExpiresAt, 0)) > RefreshTokenReauthMargin
+	}, as.challenge)
+}
+
+// challenge runs the challenge, signature and token flow, and stores the access and refresh tokens.
+func (as *AuthenticationService) challenge(ctx context.Context) error {
 	// Generate authentication challenge
-	respChallenge, err := as.AuthService.GenerateAuthChallenge(as.GRPCCtx,
+	respChallenge, err := as.AuthService.GenerateAuthChallenge(ctx,
 		&jito_pb.GenerateAuthChallengeRequest{
-			Role:   role,
-			Pubkey: as.KeyPair.PublicKey().Bytes(),
+			Role:   as.role,
+			Pubkey: as.Signer.PublicKey().Bytes(),
 		},
 	)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to generate auth challenge: %w", err)
 	}
 
-	challenge := fmt.Sprintf("%s-%s", as.KeyPair.PublicKey().String(), respChallenge.GetChallenge())
+	challenge := fmt.Sprintf("%s-%s", as.Signer.PublicKey().String(), respChallenge.GetChallenge())
 
 	// Generate signature for the challenge
 	sig, err := as.generateChallengeSignature([]byte(challenge))
@@ -62,72 +143,289 @@ func (as *AuthenticationService) AuthenticateAndRefresh(role jito_pb.Role) error
 	}
 
 	// Generate authentication tokens
-	respToken, err := as.AuthService.GenerateAuthTokens(as.GRPCCtx, &jito_pb.GenerateAuthTokensRequest{
+	respToken, err := as.AuthService.GenerateAuthTokens(ctx, &jito_pb.GenerateAuthTokensRequest{
 		Challenge:       challenge,
 		SignedChallenge: sig,
-		ClientPubkey:    as.KeyPair.PublicKey().Bytes(),
+		ClientPubkey:    as.Signer.PublicKey().Bytes(),
 	})
 	if err != nil {
+		return fmt.Errorf("failed to generate auth tokens: %w", err)
+	}
+
+	// Store the new tokens
+	as.updateAccessToken(respToken.AccessToken)
+	as.updateRefreshToken(respToken.RefreshToken)
+
+	return nil
+}
+
+// refresh obtains a new access token. With a token store, an access token stored by another instance
+// is reused if it expires later than the current one, and the access token is only refreshed when there is none.
+func (as *AuthenticationService) refresh() error {
+	if as.TokenStore == nil {
+		return as.withRenewalTimeout(as.refreshAccessToken)
+	}
+
+	as.mu.Lock()
+	expiresAt := as.ExpiresAt
+	as.mu.Unlock()
+
+	return as.renewShared(func(tokens *StoredTokens) bool {
+		return tokens.AccessTokenExpiresAt > expiresAt &&
+			time.Until(time.Unix(tokens.AccessTokenExpiresAt, 0)) > AccessTokenRefreshMargin &&
+			time.Until(time.Unix(tokens.RefreshTokenExpiresAt, 0)) > RefreshTokenReauthMargin
+	}, as.refreshAccessToken)
+}
+
+// renewShared renews the tokens through the token store. Stored tokens are adopted if fresh, otherwise
+// renew runs under the store lock and its tokens are saved, so a single instance renews at a time.
+// Unreadable stored tokens are treated as missing, as renewing overwrites them.
+func (as *AuthenticationService) renewShared(
+	fresh func(tokens *StoredTokens) bool,
+	renew func(ctx context.Context) error,
+) error {
+	key := as.tokenStoreKey()
+	if tokens, err := as.TokenStore.Load(key); err == nil && tokens != nil && fresh(tokens) {
+		as.setTokens(tokens)
+		return nil
+	}
+
+	unlock, err := as.TokenStore.Lock(as.ctx, key)
+	if err != nil {
+		return fmt.Errorf("could not lock token store: %w", err)
+	}
+	defer unlock()
+
+	// Another instance may have renewed the tokens while we waited for the lock
+	if tokens, err := as.TokenStore.Load(key); err == nil && tokens != nil && fresh(tokens) {
+		as.setTokens(tokens)
+		return nil
+	}
+
+	if err = as.withRenewalTimeout(renew); err != nil {
 		return err
 	}
 
-	// Update authorization metadata with the new token
-	as.updateAuthorizationMetadata(respToken.AccessToken)
-
-	// Goroutine to continuously refresh the access token before it expires
-	go func() {
-		for {
-			select {
-			case <-as.GRPCCtx.Done():
-				as.ErrChan <- as.GRPCCtx.Err()
-				return
-			default:
-				resp, err := as.AuthService.RefreshAccessToken(as.GRPCCtx, &jito_pb.RefreshAccessTokenRequest{
-					RefreshToken: respToken.RefreshToken.Value,
-				})
-				if err != nil {
-					as.ErrChan <- fmt.Errorf("failed to refresh access token: %w", err)
-					time.Sleep(1 * time.Minute) // Retry after 1 minute on failure
-					continue
-				}
-
-				as.updateAuthorizationMetadata(resp.AccessToken)
-				sleepDuration := time.Until(resp.AccessToken.ExpiresAtUtc.AsTime()) - 15*time.Second
-				if sleepDuration > 0 {
-					time.Sleep(sleepDuration)
-				}
+	// The renewed tokens are in use even if they could not be shared
+	if err = as.TokenStore.Save(key, as.storedTokens()); err != nil {
+		as.reportError(fmt.Errorf("could not save tokens to token store: %w", err))
+	}
+	return nil
+}
+
+// refreshAccessToken refreshes the access token with the current refresh token.
+func (as *AuthenticationService) refreshAccessToken(ctx context.Context) error {
+	as.mu.Lock()
+	refreshToken := as.refreshToken
+	as.mu.Unlock()
+
+	resp, err := as.AuthService.RefreshAccessToken(ctx, &jito_pb.RefreshAccessTokenRequest{
+		RefreshToken: refreshToken,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to refresh access token: %w", err)
+	}
+
+	as.updateAccessToken(resp.AccessToken)
+	return nil
+}
+
+// withRenewalTimeout runs the renewal with a context bounded by AuthRenewalTimeout, so a renewal holding
+// the token store lock releases it before the other instances stop waiting for it.
+func (as *AuthenticationService) withRenewalTimeout(renew func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(as.ctx, AuthRenewalTimeout)
+	defer cancel()
+
+	return renew(ctx)
+}
+
+// refreshLoop renews the access token before it expires until the context is done. The access token is
+// refreshed with the refresh token, unless the refresh token expires soon or was rejected, in which case
+// the challenge flow is rerun.
+func (as *AuthenticationService) refreshLoop() {
+	reauthenticate := false
+	attempt := 0
+	for {
+		delay := as.untilRenewal()
+		if attempt > 0 {
+			delay = authRetryBackoff(attempt)
+		}
+
+		select {
+		case <-as.ctx.Done():
+			as.publish(AuthEventStopped, 0, 0, as.ctx.Err())
+			return
+		case <-time.After(delay):
+		}
+
+		if reauthenticate || as.refreshTokenExpiring() {
+			rejected := ""
+			if reauthenticate {
+				rejected = as.currentRefreshToken()
 			}
+			if err := as.authenticate(rejected); err != nil {
+				attempt++
+				as.publish(AuthEventReauthenticationFailed, attempt, authRetryBackoff(attempt), err)
+				continue
+			}
+			reauthenticate = false
+			attempt = 0
+			as.publish(AuthEventReauthenticated, 0, 0, nil)
+			continue
 		}
-	}()
 
-	// Wait for the bearer token to be set
-	for {
-		if as.BearerToken != "" {
-			break
+		if err := as.refresh(); err != nil {
+			attempt++
+			// A rejected refresh token will not be accepted on retry
+			if code := status.Code(err); code == codes.Unauthenticated || code == codes.PermissionDenied {
+				reauthenticate = true
+			}
+			as.publish(AuthEventRefreshFailed, attempt, authRetryBackoff(attempt), err)
+			continue
 		}
-		log.Println("Waiting for challenge to solve.")
-		time.Sleep(1 * time.Second)
+		attempt = 0
+		as.publish(AuthEventRefreshed, 0, 0, nil)
 	}
+}
 
-	return nil
+// untilRenewal returns the delay before the access token must be refreshed or the refresh token replaced.
+func (as *AuthenticationService) untilRenewal() time.Duration {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	delay := time.Until(time.Unix(as.ExpiresAt, 0)) - AccessTokenRefreshMargin
+	if as.RefreshExpiresAt > 0 {
+		if reauth := time.Until(time.Unix(as.RefreshExpiresAt, 0)) - RefreshTokenReauthMargin; reauth < delay {
+			delay = reauth
+		}
+	}
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+// refreshTokenExpiring reports whether the refresh token is missing or expires within RefreshTokenReauthMargin.
+func (as *AuthenticationService) refreshTokenExpiring() bool {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	if as.refreshToken == "" {
+		return true
+	}
+	return as.RefreshExpiresAt > 0 && time.Until(time.Unix(as.RefreshExpiresAt, 0)) <= RefreshTokenReauthMargin
+}
+
+// publish publishes an outcome on the event bus.
+func (as *AuthenticationService) publish(eventType AuthEventType, attempt int, retryIn time.Duration, err error) {
+	as.mu.Lock()
+	event := AuthEvent{
+		Type:                  eventType,
+		Time:                  time.Now(),
+		AccessTokenExpiresAt:  time.Unix(as.ExpiresAt, 0),
+		RefreshTokenExpiresAt: time.Unix(as.RefreshExpiresAt, 0),
+		Attempt:               attempt,
+		RetryIn:               retryIn,
+		Err:                   err,
+	}
+	as.mu.Unlock()
+
+	as.EventBus.Publish(pkg.Event{
+		Kind:    pkg.EventAuth,
+		Time:    event.Time,
+		Source:  as.eventSource(),
+		Err:     err,
+		Payload: event,
+	})
+}
+
+// reportError publishes an error that is not an outcome of authentication or refresh on the event bus.
+func (as *AuthenticationService) reportError(err error) {
+	as.EventBus.Publish(pkg.Event{
+		Kind:   pkg.EventAuth,
+		Source: as.eventSource(),
+		Err:    err,
+	})
+}
+
+// eventSource returns the source of the published events, e.g. auth/SEARCHER.
+func (as *AuthenticationService) eventSource() string {
+	return "auth/" + as.role.String()
+}
+
+// updateRefreshToken stores the refresh token and its expiration.
+func (as *AuthenticationService) updateRefreshToken(token *jito_pb.Token) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	as.refreshToken = token.GetValue()
+	as.RefreshExpiresAt = token.GetExpiresAtUtc().GetSeconds()
+}
+
+// currentRefreshToken returns the current refresh token.
+func (as *AuthenticationService) currentRefreshToken() string {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	return as.refreshToken
+}
+
+// setTokens adopts tokens from the token store.
+func (as *AuthenticationService) setTokens(tokens *StoredTokens) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	as.BearerToken = tokens.AccessToken
+	as.ExpiresAt = tokens.AccessTokenExpiresAt
+	as.refreshToken = tokens.RefreshToken
+	as.RefreshExpiresAt = tokens.RefreshTokenExpiresAt
+}
+
+// storedTokens returns the current tokens for the token store.
+func (as *AuthenticationService) storedTokens() *StoredTokens {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	return &StoredTokens{
+		AccessToken:           as.BearerToken,
+		AccessTokenExpiresAt:  as.ExpiresAt,
+		RefreshToken:          as.refreshToken,
+		RefreshTokenExpiresAt: as.RefreshExpiresAt,
+	}
+}
+
+// tokenStoreKey returns the key of the tokens in the token store, made of the scope, identity and role.
+func (as *AuthenticationService) tokenStoreKey() string {
+	return fmt.Sprintf("%s/%s/%s", as.TokenStoreScope, as.Signer.PublicKey(), as.role)
+}
+
+// authRetryBackoff returns the jittered exponential delay before a retry, between half and all of
+// AuthRetryMinBackoff doubled for each previous attempt, up to AuthRetryMaxBackoff.
+func authRetryBackoff(attempt int) time.Duration {
+	backoff := AuthRetryMaxBackoff
+	if attempt < 32 {
+		if d := AuthRetryMinBackoff << (attempt - 1); d > 0 && d < AuthRetryMaxBackoff {
+			backoff = d
+		}
+	}
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
 }
 
-// updateAuthorizationMetadata updates the gRPC context with the new authorization token.
-func (as *AuthenticationService) updateAuthorizationMetadata(token *jito_pb.Token) {
+// updateAccessToken stores the access token attached to the calls and its expiration.
+func (as *AuthenticationService) updateAccessToken(token *jito_pb.Token) {
 	as.mu.Lock()
 	defer as.mu.Unlock()
 
-	as.GRPCCtx = metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token.Value))
 	as.BearerToken = token.Value
 	as.ExpiresAt = token.ExpiresAtUtc.Seconds
 }
 
-// generateChallengeSignature generates a signature for the given challenge using the private key.
+// generateChallengeSignature generates a signature for the given challenge using the signer.
 func (as *AuthenticationService) generateChallengeSignature(challenge []byte) ([]byte, error) {
-	sig, err := as.KeyPair.Sign(challenge)
+	sig, err := as.Signer.Sign(challenge)
 	if err != nil {
 		return nil, err
 	}
 
-	return base58.Decode(sig.String())
+	return sig[:], nil
 }
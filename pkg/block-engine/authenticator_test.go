@@ -0,0 +1,515 @@
+package block_engine_pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	jito_pb "github.com/Prophet-Solutions/block-engine-protos/auth"
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	"github.com/gagliardetto/solana-go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeAuthService is an auth service issuing numbered tokens. The nth challenge flow issues access-n and
+// refresh-n, the nth refresh issues refreshed-n.
+type fakeAuthService struct {
+	challengeErr    func(n int) error         // Error of the nth challenge, if any
+	refreshErr      func(n int) error         // Error of the nth refresh, if any
+	accessTTL       func(n int) time.Duration // Lifetime of the access token of the nth challenge
+	refreshTTL      func(n int) time.Duration // Lifetime of the refresh token of the nth challenge
+	challenges      int                       // Challenges generated
+	refreshes       int                       // Access tokens refreshed
+	refreshedTokens []string                  // Refresh tokens the access token was refreshed with
+	deadlines       []time.Duration           // Time left before the deadline of each call, 0 without one
+	mu              sync.Mutex
+}
+
+func (s *fakeAuthService) GenerateAuthChallenge(
+	ctx context.Context,
+	_ *jito_pb.GenerateAuthChallengeRequest,
+	_ ...grpc.CallOption,
+) (*jito_pb.GenerateAuthChallengeResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.challenges++
+	s.recordDeadline(ctx)
+	if s.challengeErr != nil {
+		if err := s.challengeErr(s.challenges); err != nil {
+			return nil, err
+		}
+	}
+	return &jito_pb.GenerateAuthChallengeResponse{Challenge: fmt.Sprint(s.challenges)}, nil
+}
+
+func (s *fakeAuthService) GenerateAuthTokens(
+	context.Context,
+	*jito_pb.GenerateAuthTokensRequest,
+	...grpc.CallOption,
+) (*jito_pb.GenerateAuthTokensResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := s.challenges
+	return &jito_pb.GenerateAuthTokensResponse{
+		AccessToken:  newTestToken(fmt.Sprintf("access-%d", n), s.accessTTL(n)),
+		RefreshToken: newTestToken(fmt.Sprintf("refresh-%d", n), s.refreshTTL(n)),
+	}, nil
+}
+
+func (s *fakeAuthService) RefreshAccessToken(
+	ctx context.Context,
+	in *jito_pb.RefreshAccessTokenRequest,
+	_ ...grpc.CallOption,
+) (*jito_pb.RefreshAccessTokenResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refreshes++
+	s.recordDeadline(ctx)
+	s.refreshedTokens = append(s.refreshedTokens, in.RefreshToken)
+	if s.refreshErr != nil {
+		if err := s.refreshErr(s.refreshes); err != nil {
+			return nil, err
+		}
+	}
+	return &jito_pb.RefreshAccessTokenResponse{
+		AccessToken: newTestToken(fmt.Sprintf("refreshed-%d", s.refreshes), time.Hour),
+	}, nil
+}
+
+// recordDeadline records the time left before the deadline of a call.
+func (s *fakeAuthService) recordDeadline(ctx context.Context) {
+	var left time.Duration
+	if deadline, ok := ctx.Deadline(); ok {
+		left = time.Until(deadline)
+	}
+	s.deadlines = append(s.deadlines, left)
+}
+
+// counts returns the number of challenges and refreshes.
+func (s *fakeAuthService) counts() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.challenges, s.refreshes
+}
+
+// newTestToken returns a token expiring after the lifetime.
+func newTestToken(value string, ttl time.Duration) *jito_pb.Token {
+	return &jito_pb.Token{Value: value, ExpiresAtUtc: timestamppb.New(time.Now().Add(ttl))}
+}
+
+// ttl returns a token lifetime function returning the first lifetime for the first token, then the second one.
+func ttl(first, then time.Duration) func(int) time.Duration {
+	return func(n int) time.Duration {
+		if n == 1 {
+			return first
+		}
+		return then
+	}
+}
+
+// newTestAuthService returns an authentication service backed by the fake auth service, publishing on a bus,
+// and the subscription to its auth events.
+func newTestAuthService(t *testing.T, service *fakeAuthService) (*AuthenticationService, *pkg.EventSubscription) {
+	t.Helper()
+
+	key, err := solana.NewRandomPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	bus := pkg.NewEventBus()
+	sub := bus.Subscribe(0, pkg.EventAuth)
+	as := NewAuthenticationService(ctx, pkg.NewMemorySigner(key), bus)
+	as.AuthService = service
+	return as, sub
+}
+
+// nextAuthEvent returns the next auth event published.
+func nextAuthEvent(t *testing.T, sub *pkg.EventSubscription) AuthEvent {
+	t.Helper()
+
+	select {
+	case event := <-sub.C:
+		authEvent, ok := event.Payload.(AuthEvent)
+		if !ok {
+			t.Fatalf("event %v has no AuthEvent payload", event)
+		}
+		if event.Source != "auth/SEARCHER" || event.Err != authEvent.Err {
+			t.Errorf("event = %+v", event)
+		}
+		return authEvent
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an auth event")
+		return AuthEvent{}
+	}
+}
+
+// expectAuthEvents checks the types of the next auth events.
+func expectAuthEvents(t *testing.T, sub *pkg.EventSubscription, types ...AuthEventType) []AuthEvent {
+	t.Helper()
+
+	events := make([]AuthEvent, 0, len(types))
+	for _, want := range types {
+		event := nextAuthEvent(t, sub)
+		if event.Type != want {
+			t.Fatalf("got auth event %v, want %s", event, want)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// bearerToken returns the token the service attaches to calls.
+func bearerToken(t *testing.T, as *AuthenticationService) string {
+	t.Helper()
+
+	md, err := as.GetRequestMetadata(context.Background(), "https://block-engine/searcher.SearcherService")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return md["authorization"]
+}
+
+func TestAuthenticateAndRefresh(t *testing.T) {
+	// The first access token expires within the refresh margin
+	service := &fakeAuthService{accessTTL: ttl(time.Second, time.Hour), refreshTTL: ttl(time.Hour, time.Hour)}
+	as, sub := newTestAuthService(t, service)
+
+	if err := as.AuthenticateAndRefresh(jito_pb.Role_SEARCHER); err != nil {
+		t.Fatal(err)
+	}
+	events := expectAuthEvents(t, sub, AuthEventAuthenticated, AuthEventRefreshed)
+	if events[1].Attempt != 0 || time.Until(events[1].AccessTokenExpiresAt) < 30*time.Minute {
+		t.Errorf("refreshed event = %+v", events[1])
+	}
+
+	if got := bearerToken(t, as); got != "Bearer refreshed-1" {
+		t.Errorf("authorization = %q, want the refreshed token", got)
+	}
+	if challenges, _ := service.counts(); challenges != 1 {
+		t.Errorf("ran %d challenges, want 1", challenges)
+	}
+	if service.refreshedTokens[0] != "refresh-1" {
+		t.Errorf("refreshed with %q, want refresh-1", service.refreshedTokens[0])
+	}
+}
+
+func TestAuthenticateAndRefreshReauthenticatesBeforeRefreshTokenExpiry(t *testing.T) {
+	// The first refresh token expires within the re-authentication margin
+	service := &fakeAuthService{accessTTL: ttl(time.Hour, time.Hour), refreshTTL: ttl(30*time.Second, time.Hour)}
+	as, sub := newTestAuthService(t, service)
+
+	if err := as.AuthenticateAndRefresh(jito_pb.Role_SEARCHER); err != nil {
+		t.Fatal(err)
+	}
+	events := expectAuthEvents(t, sub, AuthEventAuthenticated, AuthEventReauthenticated)
+	if time.Until(events[1].RefreshTokenExpiresAt) < 30*time.Minute {
+		t.Errorf("refresh token of the re-authentication expires at %s", events[1].RefreshTokenExpiresAt)
+	}
+
+	challenges, refreshes := service.counts()
+	if challenges != 2 || refreshes != 0 {
+		t.Errorf("ran %d challenges and %d refreshes, want 2 and 0", challenges, refreshes)
+	}
+	if got := bearerToken(t, as); got != "Bearer access-2" {
+		t.Errorf("authorization = %q, want access-2", got)
+	}
+}
+
+func TestAuthenticateAndRefreshReauthenticatesAfterRejectedRefresh(t *testing.T) {
+	service := &fakeAuthService{
+		accessTTL:  ttl(time.Second, time.Hour),
+		refreshTTL: ttl(time.Hour, time.Hour),
+		refreshErr: func(int) error { return status.Error(codes.Unauthenticated, "refresh token expired") },
+	}
+	as, sub := newTestAuthService(t, service)
+
+	if err := as.AuthenticateAndRefresh(jito_pb.Role_SEARCHER); err != nil {
+		t.Fatal(err)
+	}
+	events := expectAuthEvents(t, sub, AuthEventAuthenticated, AuthEventRefreshFailed, AuthEventReauthenticated)
+
+	failed := events[1]
+	if failed.Attempt != 1 || status.Code(failed.Err) != codes.Unauthenticated {
+		t.Errorf("refresh failure = %+v", failed)
+	}
+	if failed.RetryIn < AuthRetryMinBackoff/2 || failed.RetryIn > AuthRetryMinBackoff {
+		t.Errorf("first retry in %s, want between %s and %s", failed.RetryIn, AuthRetryMinBackoff/2, AuthRetryMinBackoff)
+	}
+	if got := bearerToken(t, as); got != "Bearer access-2" {
+		t.Errorf("authorization = %q, want access-2", got)
+	}
+}
+
+func TestAuthenticateAndRefreshBacksOff(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	service := &fakeAuthService{
+		accessTTL:  ttl(time.Hour, time.Hour),
+		refreshTTL: ttl(30*time.Second, time.Hour),
+		challengeErr: func(n int) error {
+			if n > 1 {
+				return unavailable
+			}
+			return nil
+		},
+	}
+	as, sub := newTestAuthService(t, service)
+
+	if err := as.AuthenticateAndRefresh(jito_pb.Role_SEARCHER); err != nil {
+		t.Fatal(err)
+	}
+	events := expectAuthEvents(t, sub,
+		AuthEventAuthenticated, AuthEventReauthenticationFailed, AuthEventReauthenticationFailed)
+
+	for i, event := range events[1:] {
+		attempt := i + 1
+		backoff := AuthRetryMinBackoff << i
+		if event.Attempt != attempt || !errors.Is(event.Err, unavailable) {
+			t.Errorf("failure %d = %+v", attempt, event)
+		}
+		if event.RetryIn < backoff/2 || event.RetryIn > backoff {
+			t.Errorf("failure %d retries in %s, want between %s and %s", attempt, event.RetryIn, backoff/2, backoff)
+		}
+	}
+
+	// The current tokens stay in use while re-authentication fails
+	if got := bearerToken(t, as); got != "Bearer access-1" {
+		t.Errorf("authorization = %q, want access-1", got)
+	}
+}
+
+func TestAuthenticateAndRefreshFailure(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	service := &fakeAuthService{challengeErr: func(int) error { return unavailable }}
+	as, sub := newTestAuthService(t, service)
+
+	if err := as.AuthenticateAndRefresh(jito_pb.Role_SEARCHER); !errors.Is(err, unavailable) {
+		t.Fatalf("AuthenticateAndRefresh() = %v, want %v", err, unavailable)
+	}
+	if event := expectAuthEvents(t, sub, AuthEventAuthenticationFailed)[0]; !errors.Is(event.Err, unavailable) {
+		t.Errorf("event = %+v", event)
+	}
+}
+
+func TestAuthenticateAndRefreshStops(t *testing.T) {
+	key, err := solana.NewRandomPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus := pkg.NewEventBus()
+	sub := bus.Subscribe(0, pkg.EventAuth)
+	ctx, cancel := context.WithCancel(context.Background())
+	as := NewAuthenticationService(ctx, pkg.NewMemorySigner(key), bus)
+	as.AuthService = &fakeAuthService{accessTTL: ttl(time.Hour, time.Hour), refreshTTL: ttl(time.Hour, time.Hour)}
+
+	if err = as.AuthenticateAndRefresh(jito_pb.Role_SEARCHER); err != nil {
+		t.Fatal(err)
+	}
+	expectAuthEvents(t, sub, AuthEventAuthenticated)
+
+	cancel()
+	if event := expectAuthEvents(t, sub, AuthEventStopped)[0]; !errors.Is(event.Err, context.Canceled) {
+		t.Errorf("stopped event = %+v", event)
+	}
+}
+
+func TestAuthRetryBackoff(t *testing.T) {
+	for attempt := 1; attempt <= 40; attempt++ {
+		want := AuthRetryMaxBackoff
+		if attempt < 10 {
+			if d := AuthRetryMinBackoff << (attempt - 1); d < want {
+				want = d
+			}
+		}
+		for i := 0; i < 20; i++ {
+			if got := authRetryBackoff(attempt); got < want/2 || got > want {
+				t.Fatalf("authRetryBackoff(%d) = %s, want between %s and %s", attempt, got, want/2, want)
+			}
+		}
+	}
+}
+
+func TestGetRequestMetadata(t *testing.T) {
+	authURI := "https://block-engine/" + jito_pb.AuthService_ServiceDesc.ServiceName
+	searcherURI := "https://block-engine/searcher.SearcherService"
+
+	tests := []struct {
+		name  string
+		token string
+		uri   string
+		want  string
+	}{
+		{name: "before authentication", uri: searcherURI},
+		{name: "authenticated", token: "access", uri: searcherURI, want: "Bearer access"},
+		{name: "auth service", token: "access", uri: authURI},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			as := NewAuthenticationService(context.Background(), nil, nil)
+			as.BearerToken = tt.token
+
+			md, err := as.GetRequestMetadata(context.Background(), tt.uri)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if md["authorization"] != tt.want || (tt.want == "" && md != nil) {
+				t.Errorf("GetRequestMetadata() = %v, want authorization %q", md, tt.want)
+			}
+		})
+	}
+}
+
+// serverCall is a call received by the test server.
+type serverCall struct {
+	method        string   // Full method called
+	authorization []string // Authorization headers of the call
+	deadline      bool     // Whether the call has a deadline
+}
+
+// newCredentialsTestConn returns a connection with the service installed as per-RPC credentials, to a
+// server recording the calls it receives on the channel.
+func newCredentialsTestConn(t *testing.T, as *AuthenticationService) (*grpc.ClientConn, <-chan serverCall) {
+	t.Helper()
+
+	calls := make(chan serverCall, 16)
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+		ctx := stream.Context()
+		md, _ := metadata.FromIncomingContext(ctx)
+		method, _ := grpc.Method(ctx)
+		_, deadline := ctx.Deadline()
+		calls <- serverCall{method: method, authorization: md.Get("authorization"), deadline: deadline}
+
+		if err := stream.RecvMsg(&emptypb.Empty{}); err != nil {
+			return err
+		}
+		return stream.SendMsg(&emptypb.Empty{})
+	}))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///block-engine",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithPerRPCCredentials(as),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, calls
+}
+
+func TestPerRPCCredentials(t *testing.T) {
+	as := NewAuthenticationService(context.Background(), nil, nil)
+	conn, calls := newCredentialsTestConn(t, as)
+	searcherMethod := "/searcher.SearcherService/GetTipAccounts"
+	authMethod := "/" + jito_pb.AuthService_ServiceDesc.ServiceName + "/RefreshAccessToken"
+
+	invoke := func(ctx context.Context, method string) serverCall {
+		t.Helper()
+
+		if err := conn.Invoke(ctx, method, &emptypb.Empty{}, &emptypb.Empty{}); err != nil {
+			t.Fatalf("Invoke(%s) = %v", method, err)
+		}
+		call := <-calls
+		if call.method != method {
+			t.Fatalf("server received %s, want %s", call.method, method)
+		}
+		return call
+	}
+
+	// Tokens rotated between calls are attached to the next call
+	for _, token := range []string{"access-1", "access-2"} {
+		as.updateAccessToken(newTestToken(token, time.Hour))
+		if call := invoke(context.Background(), searcherMethod); len(call.authorization) != 1 ||
+			call.authorization[0] != "Bearer "+token {
+			t.Errorf("authorization = %v, want Bearer %s", call.authorization, token)
+		}
+	}
+
+	if call := invoke(context.Background(), authMethod); len(call.authorization) != 0 {
+		t.Errorf("auth service call carries authorization %v", call.authorization)
+	}
+
+	// The deadline of the caller's context is sent with the call
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if call := invoke(ctx, searcherMethod); !call.deadline {
+		t.Error("caller deadline not propagated")
+	}
+
+	// A cancelled caller's context cancels the call
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := conn.Invoke(cancelled, searcherMethod, &emptypb.Empty{}, &emptypb.Empty{}); status.Code(err) != codes.Canceled {
+		t.Errorf("Invoke() with a cancelled context = %v, want %s", err, codes.Canceled)
+	}
+}
+
+func TestAuthenticateAndRefreshUndrainedEvents(t *testing.T) {
+	tests := []struct {
+		name string
+		bus  bool
+	}{
+		{name: "no bus"},
+		{name: "undrained subscription", bus: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := solana.NewRandomPrivateKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var bus *pkg.EventBus
+			if tt.bus {
+				bus = pkg.NewEventBus()
+				defer bus.Subscribe(1, pkg.EventAuth).Close()
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// Every refresh token expires within the re-authentication margin, so the loop re-authenticates
+			// and publishes without pause
+			service := &fakeAuthService{accessTTL: ttl(time.Hour, time.Hour), refreshTTL: ttl(time.Second, time.Second)}
+			as := NewAuthenticationService(ctx, pkg.NewMemorySigner(key), bus)
+			as.AuthService = service
+			if err = as.AuthenticateAndRefresh(jito_pb.Role_SEARCHER); err != nil {
+				t.Fatal(err)
+			}
+
+			for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+				if challenges, _ := service.counts(); challenges >= 10 {
+					break
+				}
+				time.Sleep(time.Millisecond)
+			}
+			if challenges, _ := service.counts(); challenges < 10 {
+				t.Fatalf("refresh loop stalled after %d challenges", challenges)
+			}
+			if tt.bus && bus.Stats().Dropped == 0 {
+				t.Error("no auth events dropped on the undrained subscription")
+			}
+		})
+	}
+}
@@ -8,6 +8,12 @@ const (
 	TKO = "https://tokyo.mainnet.block-engine.jito.wtf"     // Tokyo endpoint
 )
 
+// Constants for block engine JSON-RPC paths
+const (
+	BundlesPath      = "/api/v1/bundles"      // JSON-RPC bundles API path
+	TransactionsPath = "/api/v1/transactions" // JSON-RPC transactions API path
+)
+
 // GetEndpoint returns the endpoint URL for the given location code.
 // It returns an empty string if the location code is not recognized.
 func GetEndpoint(location string) string {
@@ -24,3 +30,20 @@ func GetEndpoint(location string) string {
 		return ""
 	}
 }
+
+// GetLocation returns the location code for a region name reported by the block engine,
+// such as the next leader region. It returns an empty string if the region is not recognized.
+func GetLocation(region string) string {
+	switch region {
+	case "amsterdam":
+		return "AMS"
+	case "frankfurt":
+		return "FRA"
+	case "ny":
+		return "NYC"
+	case "tokyo":
+		return "TKO"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || windows)
+
+package block_engine_pkg
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// tryLockFile reports that file locking is not supported on this platform.
+func tryLockFile(*os.File) (bool, error) {
+	return false, fmt.Errorf("file token store locking is not supported on %s", runtime.GOOS)
+}
+
+// unlockFile does nothing, as no lock can be taken on this platform.
+func unlockFile(*os.File) error {
+	return nil
+}
@@ -0,0 +1,226 @@
+package block_engine_pkg
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Constants for the file token store
+const (
+	DefaultTokenLockTimeout  = 30 * time.Second       // Maximum wait for a lock held by another process
+	DefaultTokenPollInterval = 100 * time.Millisecond // Delay between attempts to take a held lock
+)
+
+// ErrTokenLockTimeout is returned when the lock of a FileTokenStore is held by another process for longer than LockTimeout.
+var ErrTokenLockTimeout = errors.New("timed out waiting for token store lock")
+
+// StoredTokens is an access and refresh token pair shared through a TokenStore.
+type StoredTokens struct {
+	AccessToken           string `json:"access_token"`             // Access token value
+	AccessTokenExpiresAt  int64  `json:"access_token_expires_at"`  // Expiration time of the access token
+	RefreshToken          string `json:"refresh_token"`            // Refresh token value
+	RefreshTokenExpiresAt int64  `json:"refresh_token_expires_at"` // Expiration time of the refresh token
+}
+
+// TokenStore shares the tokens of an authenticated identity between AuthenticationService instances,
+// possibly in different processes, so only one of them performs the challenge flow or refreshes the
+// access token while the others reuse its tokens.
+type TokenStore interface {
+	// Load returns the tokens stored under the key, nil if there are none.
+	Load(key string) (*StoredTokens, error)
+	// Save stores the tokens under the key.
+	Save(key string, tokens *StoredTokens) error
+	// Lock acquires the exclusive right to renew the tokens stored under the key, waiting until the context
+	// is done. The returned function releases it.
+	Lock(ctx context.Context, key string) (func(), error)
+}
+
+// TokenStoreOption is a dial option installing a TokenStore on the authentication service of a client,
+// see WithTokenStore.
+type TokenStoreOption struct {
+	grpc.EmptyDialOption
+	Store TokenStore // Token store of the authentication service
+}
+
+// WithTokenStore returns a dial option making the authentication service of the searcher, relayer or
+// validator client share its tokens through the store.
+func WithTokenStore(store TokenStore) grpc.DialOption {
+	return TokenStoreOption{Store: store}
+}
+
+// MemoryTokenStore is a TokenStore shared by the clients of one process.
+type MemoryTokenStore struct {
+	tokens map[string]StoredTokens  // Stored tokens indexed by key
+	locks  map[string]chan struct{} // Renewal locks indexed by key
+	mu     sync.Mutex               // Mutex for synchronizing the maps
+}
+
+// NewMemoryTokenStore creates a new MemoryTokenStore.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{
+		tokens: make(map[string]StoredTokens),
+		locks:  make(map[string]chan struct{}),
+	}
+}
+
+// Load returns the tokens stored under the key, nil if there are none.
+func (s *MemoryTokenStore) Load(key string) (*StoredTokens, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, ok := s.tokens[key]
+	if !ok {
+		return nil, nil
+	}
+	return &tokens, nil
+}
+
+// Save stores the tokens under the key.
+func (s *MemoryTokenStore) Save(key string, tokens *StoredTokens) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[key] = *tokens
+	return nil
+}
+
+// Lock acquires the exclusive right to renew the tokens stored under the key.
+func (s *MemoryTokenStore) Lock(ctx context.Context, key string) (func(), error) {
+	s.mu.Lock()
+	lock, ok := s.locks[key]
+	if !ok {
+		lock = make(chan struct{}, 1)
+		s.locks[key] = lock
+	}
+	s.mu.Unlock()
+
+	select {
+	case lock <- struct{}{}:
+		return func() { <-lock }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// FileTokenStore is a TokenStore shared by the processes of a host through a directory.
+// Each key is stored in its own file, readable by the owner only. Renewals are serialized with an exclusive
+// lock on a lock file per key, flock on Unix and LockFileEx on Windows, which the operating system releases
+// when its process exits, so a crashed process never leaves the lock behind.
+type FileTokenStore struct {
+	Dir          string        // Directory of the token and lock files
+	LockTimeout  time.Duration // Maximum wait for a lock held by another process, 0 to wait until the context is done
+	PollInterval time.Duration // Delay between attempts to take a held lock
+}
+
+// NewFileTokenStore creates a FileTokenStore in the directory, creating it if needed.
+func NewFileTokenStore(dir string) (*FileTokenStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("could not create token store directory: %w", err)
+	}
+
+	return &FileTokenStore{
+		Dir:          dir,
+		LockTimeout:  DefaultTokenLockTimeout,
+		PollInterval: DefaultTokenPollInterval,
+	}, nil
+}
+
+// Load returns the tokens stored under the key, nil if there are none.
+func (s *FileTokenStore) Load(key string) (*StoredTokens, error) {
+	data, err := os.ReadFile(s.path(key) + ".json")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := &StoredTokens{}
+	if err = json.Unmarshal(data, tokens); err != nil {
+		return nil, fmt.Errorf("invalid token file: %w", err)
+	}
+	return tokens, nil
+}
+
+// Save stores the tokens under the key. The file is replaced atomically, so readers never see a partial write.
+func (s *FileTokenStore) Save(key string, tokens *StoredTokens) error {
+	data, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.Dir, ".tokens-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(key)+".json")
+}
+
+// Lock acquires the exclusive right to renew the tokens stored under the key by locking its lock file.
+// It polls while another process holds the lock, and returns ErrTokenLockTimeout after LockTimeout.
+// Lock files are never removed, so every process locks the same file.
+func (s *FileTokenStore) Lock(ctx context.Context, key string) (func(), error) {
+	f, err := os.OpenFile(s.path(key)+".lock", os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open lock file: %w", err)
+	}
+
+	var timeout <-chan time.Time
+	if s.LockTimeout > 0 {
+		timer := time.NewTimer(s.LockTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for {
+		locked, err := tryLockFile(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("could not lock file: %w", err)
+		}
+		if locked {
+			var once sync.Once
+			return func() {
+				once.Do(func() {
+					unlockFile(f)
+					f.Close()
+				})
+			}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			f.Close()
+			return nil, ctx.Err()
+		case <-timeout:
+			f.Close()
+			return nil, ErrTokenLockTimeout
+		case <-time.After(s.PollInterval):
+		}
+	}
+}
+
+// path returns the path of the files of the key, without extension.
+func (s *FileTokenStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.Dir, hex.EncodeToString(sum[:]))
+}
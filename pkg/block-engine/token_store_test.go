@@ -0,0 +1,210 @@
+package block_engine_pkg
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	jito_pb "github.com/Prophet-Solutions/block-engine-protos/auth"
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	"github.com/gagliardetto/solana-go"
+)
+
+// newTestFileTokenStore returns a file token store in the directory, polling quickly.
+func newTestFileTokenStore(t *testing.T, dir string, lockTimeout time.Duration) *FileTokenStore {
+	t.Helper()
+
+	store, err := NewFileTokenStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.LockTimeout = lockTimeout
+	store.PollInterval = 5 * time.Millisecond
+	return store
+}
+
+// lockWithin tries to take the lock of the key within the delay.
+func lockWithin(store TokenStore, key string, delay time.Duration) (func(), error) {
+	ctx, cancel := context.WithTimeout(context.Background(), delay)
+	defer cancel()
+
+	return store.Lock(ctx, key)
+}
+
+func TestTokenStores(t *testing.T) {
+	tests := []struct {
+		name  string
+		store func(t *testing.T) TokenStore
+	}{
+		{name: "memory", store: func(*testing.T) TokenStore { return NewMemoryTokenStore() }},
+		{name: "file", store: func(t *testing.T) TokenStore { return newTestFileTokenStore(t, t.TempDir(), 0) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := tt.store(t)
+			key := "block-engine/identity/SEARCHER"
+
+			if tokens, err := store.Load(key); tokens != nil || err != nil {
+				t.Fatalf("Load() of a missing key = %v, %v, want nil", tokens, err)
+			}
+			want := StoredTokens{AccessToken: "access", AccessTokenExpiresAt: 1, RefreshToken: "refresh", RefreshTokenExpiresAt: 2}
+			if err := store.Save(key, &want); err != nil {
+				t.Fatal(err)
+			}
+			if tokens, err := store.Load(key); err != nil || tokens == nil || *tokens != want {
+				t.Fatalf("Load() = %v, %v, want %v", tokens, err, want)
+			}
+
+			unlock, err := lockWithin(store, key, time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = lockWithin(store, key, 50*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Lock() of a held lock = %v, want %v", err, context.DeadlineExceeded)
+			}
+			if other, err := lockWithin(store, "other", time.Second); err != nil {
+				t.Errorf("Lock() of another key = %v", err)
+			} else {
+				other()
+			}
+
+			unlock()
+			unlock, err = lockWithin(store, key, time.Second)
+			if err != nil {
+				t.Fatalf("Lock() after release = %v", err)
+			}
+			unlock()
+		})
+	}
+}
+
+func TestFileTokenStoreLock(t *testing.T) {
+	dir := t.TempDir()
+	key := "block-engine/identity/SEARCHER"
+	holder := newTestFileTokenStore(t, dir, 0)
+	waiter := newTestFileTokenStore(t, dir, 100*time.Millisecond)
+	lockPath := holder.path(key) + ".lock"
+
+	// A lock file left by a crashed process does not hold the lock, whatever its age
+	if err := os.WriteFile(lockPath, []byte("crashed"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	unlock, err := lockWithin(holder, key, time.Second)
+	if err != nil {
+		t.Fatalf("Lock() with a leftover lock file = %v", err)
+	}
+
+	// A held lock is never taken over, even when it is older than the lock timeout
+	old := time.Now().Add(-time.Hour)
+	if err = os.Chtimes(lockPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = waiter.Lock(context.Background(), key); !errors.Is(err, ErrTokenLockTimeout) {
+		t.Fatalf("Lock() of a held lock = %v, want %v", err, ErrTokenLockTimeout)
+	}
+
+	// Releasing twice is harmless, and the lock file stays in place for the next holder
+	unlock()
+	unlock()
+	if _, err = os.Stat(lockPath); err != nil {
+		t.Errorf("lock file removed on release: %v", err)
+	}
+	if unlock, err = waiter.Lock(context.Background(), key); err != nil {
+		t.Fatalf("Lock() after release = %v", err)
+	}
+
+	// A process exiting without releasing the lock releases it with its file descriptors
+	f, err := os.OpenFile(lockPath, os.O_RDWR, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+	if locked, err := tryLockFile(f); !locked || err != nil {
+		t.Fatalf("tryLockFile() = %v, %v", locked, err)
+	}
+	f.Close()
+	if unlock, err = waiter.Lock(context.Background(), key); err != nil {
+		t.Fatalf("Lock() after the holder exited = %v", err)
+	}
+	unlock()
+}
+
+func TestFileTokenStoreFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not enforced on windows")
+	}
+	store := newTestFileTokenStore(t, filepath.Join(t.TempDir(), "tokens"), 0)
+
+	if err := store.Save("key", &StoredTokens{AccessToken: "access"}); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(store.path("key") + ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o600 {
+		t.Errorf("token file mode = %o, want 600", mode)
+	}
+
+	if err = os.WriteFile(store.path("corrupt")+".json", []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = store.Load("corrupt"); err == nil {
+		t.Error("Load() of a corrupt token file succeeded")
+	}
+}
+
+func TestSharedAuthentication(t *testing.T) {
+	key, err := solana.NewRandomPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := &fakeAuthService{accessTTL: ttl(time.Hour, time.Hour), refreshTTL: ttl(time.Hour, time.Hour)}
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Processes starting together with the same identity run a single challenge flow
+	const instances = 5
+	services := make([]*AuthenticationService, instances)
+	errs := make([]error, instances)
+	var wg sync.WaitGroup
+	for i := range services {
+		as := NewAuthenticationService(ctx, pkg.NewMemorySigner(key), nil)
+		as.AuthService = service
+		as.TokenStore = newTestFileTokenStore(t, dir, DefaultTokenLockTimeout)
+		as.TokenStoreScope = "block-engine"
+		services[i] = as
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = services[i].AuthenticateAndRefresh(jito_pb.Role_SEARCHER)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, as := range services {
+		if errs[i] != nil {
+			t.Fatalf("instance %d: %v", i, errs[i])
+		}
+		if got := bearerToken(t, as); got != "Bearer access-1" {
+			t.Errorf("instance %d authorization = %q, want the shared access-1", i, got)
+		}
+	}
+	if challenges, _ := service.counts(); challenges != 1 {
+		t.Errorf("ran %d challenges, want 1", challenges)
+	}
+
+	// Renewal calls are bounded so the lock is released before waiters give up
+	for _, left := range service.deadlines {
+		if left <= 0 || left > AuthRenewalTimeout || AuthRenewalTimeout >= DefaultTokenLockTimeout {
+			t.Errorf("renewal call deadline in %s, want within %s", left, AuthRenewalTimeout)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// LatestBlockhash is a recent blockhash with the last block height it is valid at.
+type LatestBlockhash struct {
+	Blockhash            solana.Hash // Recent blockhash
+	LastValidBlockHeight uint64      // Last block height the blockhash is valid at
+}
+
+// BlockhashSource provides recent blockhashes, e.g. from an RPC node or a Yellowstone Geyser stream.
+type BlockhashSource interface {
+	LatestBlockhash(ctx context.Context) (*LatestBlockhash, error)
+}
+
+// RPCBlockhashSource is a BlockhashSource backed by the getLatestBlockhash RPC method.
+type RPCBlockhashSource struct {
+	Client     *rpc.Client        // RPC client
+	Commitment rpc.CommitmentType // Commitment of the blockhash
+}
+
+// NewRPCBlockhashSource creates a BlockhashSource fetching blockhashes from the RPC client at the given commitment.
+func NewRPCBlockhashSource(client *rpc.Client, commitment rpc.CommitmentType) *RPCBlockhashSource {
+	return &RPCBlockhashSource{
+		Client:     client,
+		Commitment: commitment,
+	}
+}
+
+// LatestBlockhash fetches the latest blockhash from the RPC node.
+func (s *RPCBlockhashSource) LatestBlockhash(ctx context.Context) (*LatestBlockhash, error) {
+	resp, err := s.Client.GetLatestBlockhash(ctx, s.Commitment)
+	if err != nil {
+		return nil, fmt.Errorf("could not get latest blockhash: %w", err)
+	}
+
+	return &LatestBlockhash{
+		Blockhash:            resp.Value.Blockhash,
+		LastValidBlockHeight: resp.Value.LastValidBlockHeight,
+	}, nil
+}
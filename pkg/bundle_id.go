@@ -0,0 +1,37 @@
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// DeriveBundleID computes the ID the block engine assigns to a bundle from the first signature of each of its
+// transactions, in bundle order. The block engine hashes the base58 signatures joined with "," using SHA-256
+// and hex-encodes the digest, so the ID is known before the bundle is sent.
+func DeriveBundleID(signatures []solana.Signature) string {
+	encoded := make([]string, 0, len(signatures))
+	for _, signature := range signatures {
+		encoded = append(encoded, signature.String())
+	}
+
+	digest := sha256.Sum256([]byte(strings.Join(encoded, ",")))
+	return hex.EncodeToString(digest[:])
+}
+
+// DeriveBundleIDFromTransactions computes the bundle ID of the transactions, see DeriveBundleID.
+// It returns an error if a transaction is not signed.
+func DeriveBundleIDFromTransactions(transactions []*solana.Transaction) (string, error) {
+	signatures := make([]solana.Signature, 0, len(transactions))
+	for i, tx := range transactions {
+		if len(tx.Signatures) == 0 {
+			return "", fmt.Errorf("transaction %d has no signature", i)
+		}
+		signatures = append(signatures, tx.Signatures[0])
+	}
+
+	return DeriveBundleID(signatures), nil
+}
@@ -0,0 +1,76 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// The vectors pin the block engine's derivation (derive_bundle_id in jito-solana): the base58 first
+// signatures joined with "," in bundle order, hashed with SHA-256 and hex-encoded in lowercase.
+// They were computed with an implementation independent of this package.
+func TestDeriveBundleID(t *testing.T) {
+	var ascending, ones solana.Signature
+	for i := range ascending {
+		ascending[i] = byte(i)
+		ones[i] = 0xff
+	}
+
+	tests := []struct {
+		name       string
+		signatures []solana.Signature
+		expected   string
+	}{
+		{
+			name:     "empty bundle hashes the empty string",
+			expected: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		},
+		{
+			name:       "zero signature",
+			signatures: []solana.Signature{{}},
+			expected:   "3138bb9bc78df27c473ecfd1410f7bd45ebac1f59cf3ff9cfe4db77aab7aedd3",
+		},
+		{
+			name:       "single transaction",
+			signatures: []solana.Signature{ascending},
+			expected:   "e589a40ca2b00c80d649dddb65a6e3d20586f1bee9222114eab21dcf2641d61b",
+		},
+		{
+			name:       "two transactions joined with a comma",
+			signatures: []solana.Signature{ascending, ones},
+			expected:   "82bfa7894b5eed0f84380371ffa607ac4133b845dd80a30cf0506101cdd71a53",
+		},
+		{
+			name:       "bundle order matters",
+			signatures: []solana.Signature{ones, ascending},
+			expected:   "62def433dc0cc4ceae41fddbf0311a36caa5e5b483e51459c8ae706fab9ca265",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DeriveBundleID(tt.signatures); got != tt.expected {
+				t.Errorf("DeriveBundleID() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDeriveBundleIDFromTransactions(t *testing.T) {
+	payer := newTestKey(t)
+	recipient := solana.NewWallet().PublicKey()
+	first := newTransferTransaction(t, payer, recipient, 1, solana.Hash{1})
+	second := newTransferTransaction(t, payer, recipient, 2, solana.Hash{1})
+
+	id, err := DeriveBundleIDFromTransactions([]*solana.Transaction{first, second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := DeriveBundleID([]solana.Signature{first.Signatures[0], second.Signatures[0]}); id != want {
+		t.Errorf("DeriveBundleIDFromTransactions() = %s, want %s", id, want)
+	}
+
+	unsigned := &solana.Transaction{Message: first.Message}
+	if _, err = DeriveBundleIDFromTransactions([]*solana.Transaction{first, unsigned}); err == nil {
+		t.Error("unsigned transaction accepted")
+	}
+}
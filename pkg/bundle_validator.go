@@ -0,0 +1,222 @@
+package pkg
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// FindingSeverity is the severity of a bundle validation finding.
+type FindingSeverity int
+
+// Severities of bundle validation findings
+const (
+	SeverityWarning FindingSeverity = iota // The bundle can be sent but is likely to misbehave
+	SeverityError                          // The bundle will be rejected and must not be sent
+)
+
+// String returns the name of the severity.
+func (s FindingSeverity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+// FindingCode identifies the rule a bundle validation finding was raised by.
+type FindingCode string
+
+// Codes of bundle validation findings
+const (
+	FindingEmptyBundle         FindingCode = "empty_bundle"           // The bundle has no transactions
+	FindingTooManyTransactions FindingCode = "too_many_transactions"  // The bundle exceeds MaxBundleTransactions
+	FindingInvalidTransaction  FindingCode = "invalid_transaction"    // The transaction cannot be serialized
+	FindingTransactionTooLarge FindingCode = "transaction_too_large"  // The transaction exceeds MaxTransactionSize
+	FindingMissingSignature    FindingCode = "missing_signature"      // A required signer did not sign
+	FindingInvalidSignature    FindingCode = "invalid_signature"      // A signature does not verify
+	FindingDuplicateSignature  FindingCode = "duplicate_signature"    // A signature appears more than once in the bundle
+	FindingBlockhashMismatch   FindingCode = "blockhash_mismatch"     // Transactions use different recent blockhashes
+	FindingMissingTip          FindingCode = "missing_tip"            // No transaction tips a current tip account
+	FindingUnresolvedAccounts  FindingCode = "unresolved_accounts"    // The lookup table accounts cannot be resolved
+	FindingTooManyAccountLocks FindingCode = "too_many_account_locks" // The transaction exceeds the account lock limit
+	FindingDuplicateAccount    FindingCode = "duplicate_account"      // The transaction loads an account twice
+	FindingWriteLockConflict   FindingCode = "write_lock_conflict"    // The transaction contends with an earlier one of the bundle
+	FindingInflightConflict    FindingCode = "inflight_conflict"      // The bundle contends with a bundle in flight
+)
+
+// BundleFinding is a single result of bundle validation.
+type BundleFinding struct {
+	Severity FindingSeverity // Severity of the finding
+	Code     FindingCode     // Rule that raised the finding
+	TxIndex  int             // Index of the offending transaction, -1 for bundle-level findings
+	Message  string          // Human-readable description
+}
+
+// String returns a human-readable representation of the finding.
+func (f BundleFinding) String() string {
+	if f.TxIndex < 0 {
+		return fmt.Sprintf("%s: %s: %s", f.Severity, f.Code, f.Message)
+	}
+	return fmt.Sprintf("%s: %s: transaction %d: %s", f.Severity, f.Code, f.TxIndex, f.Message)
+}
+
+// BundleFindings is the list of findings produced by ValidateBundle.
+type BundleFindings []BundleFinding
+
+// HasErrors reports whether any finding has error severity.
+func (f BundleFindings) HasErrors() bool {
+	for _, finding := range f {
+		if finding.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Err returns a BundleValidationError holding the findings if any of them has error severity, nil otherwise.
+func (f BundleFindings) Err() error {
+	if !f.HasErrors() {
+		return nil
+	}
+	return &BundleValidationError{Findings: f}
+}
+
+// BundleValidationError is returned when a bundle has error-level validation findings.
+type BundleValidationError struct {
+	Findings BundleFindings // All findings of the validation, including warnings
+}
+
+// Error implements the error interface for BundleValidationError.
+func (e *BundleValidationError) Error() string {
+	var messages []string
+	for _, finding := range e.Findings {
+		if finding.Severity == SeverityError {
+			messages = append(messages, finding.String())
+		}
+	}
+	return "bundle validation failed: " + strings.Join(messages, "; ")
+}
+
+// ValidateBundle runs the pre-flight checks Jito applies to bundles and returns every finding.
+// It checks the transaction count, the serialized size and signatures of each transaction, duplicate
+// signatures, blockhash consistency and, when tipAccounts is not empty, that a tip goes to one of them.
+func ValidateBundle(transactions []*solana.Transaction, tipAccounts []solana.PublicKey) BundleFindings {
+	var findings BundleFindings
+	add := func(severity FindingSeverity, code FindingCode, txIndex int, format string, args ...interface{}) {
+		findings = append(findings, BundleFinding{
+			Severity: severity,
+			Code:     code,
+			TxIndex:  txIndex,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	// Check the bundle-level transaction count
+	if len(transactions) == 0 {
+		add(SeverityError, FindingEmptyBundle, -1, "bundle has no transactions")
+		return findings
+	}
+	if len(transactions) > MaxBundleTransactions {
+		add(SeverityError, FindingTooManyTransactions, -1,
+			"bundle has %d transactions, maximum is %d", len(transactions), MaxBundleTransactions)
+	}
+
+	seenSignatures := make(map[solana.Signature]int)
+	for i, tx := range transactions {
+		// Check the wire size of the transaction
+		size, err := SerializedTransactionSize(tx)
+		if err != nil {
+			add(SeverityError, FindingInvalidTransaction, i, "could not serialize transaction: %v", err)
+			continue
+		}
+		if size > MaxTransactionSize {
+			add(SeverityError, FindingTransactionTooLarge, i,
+				"transaction is %d bytes, maximum is %d", size, MaxTransactionSize)
+		}
+
+		// Check that every required signer signed the transaction
+		message, err := tx.Message.MarshalBinary()
+		if err != nil {
+			add(SeverityError, FindingInvalidTransaction, i, "could not serialize message: %v", err)
+			continue
+		}
+		numSigners := int(tx.Message.Header.NumRequiredSignatures)
+		for j := 0; j < numSigners && j < len(tx.Message.AccountKeys); j++ {
+			signer := tx.Message.AccountKeys[j]
+			if j >= len(tx.Signatures) || tx.Signatures[j].IsZero() {
+				add(SeverityError, FindingMissingSignature, i, "missing signature for signer %s", signer)
+				continue
+			}
+			if !tx.Signatures[j].Verify(signer, message) {
+				add(SeverityError, FindingInvalidSignature, i, "invalid signature for signer %s", signer)
+			}
+		}
+
+		// Check that no signature is used twice within the bundle
+		for _, sig := range tx.Signatures {
+			if sig.IsZero() {
+				continue
+			}
+			if first, ok := seenSignatures[sig]; ok {
+				add(SeverityError, FindingDuplicateSignature, i,
+					"signature %s already used by transaction %d", sig, first)
+				continue
+			}
+			seenSignatures[sig] = i
+		}
+
+		// Check that all transactions share the blockhash of the first one
+		if i > 0 && !tx.Message.RecentBlockhash.Equals(transactions[0].Message.RecentBlockhash) {
+			add(SeverityWarning, FindingBlockhashMismatch, i,
+				"recent blockhash %s differs from %s used by transaction 0",
+				tx.Message.RecentBlockhash, transactions[0].Message.RecentBlockhash)
+		}
+	}
+
+	// Check that the bundle tips one of the current tip accounts
+	if len(tipAccounts) > 0 && ExtractTipLamports(transactions, tipAccounts) == 0 {
+		add(SeverityError, FindingMissingTip, -1, "no transaction transfers a tip to a current tip account")
+	}
+
+	return findings
+}
+
+// ExtractTipLamports returns the total amount of lamports the transactions transfer to the given tip accounts.
+// Only System program transfers whose accounts are part of the static account keys are taken into account.
+func ExtractTipLamports(transactions []*solana.Transaction, tipAccounts []solana.PublicKey) uint64 {
+	tipSet := make(map[solana.PublicKey]struct{}, len(tipAccounts))
+	for _, account := range tipAccounts {
+		tipSet[account] = struct{}{}
+	}
+
+	var total uint64
+	for _, tx := range transactions {
+		keys := tx.Message.AccountKeys
+		for _, instruction := range tx.Message.Instructions {
+			if int(instruction.ProgramIDIndex) >= len(keys) ||
+				!keys[instruction.ProgramIDIndex].Equals(solana.SystemProgramID) {
+				continue
+			}
+
+			// A System transfer is the u32 instruction index 2 followed by the u64 amount
+			data := instruction.Data
+			if len(data) != 12 || binary.LittleEndian.Uint32(data[:4]) != 2 || len(instruction.Accounts) < 2 {
+				continue
+			}
+			if int(instruction.Accounts[1]) >= len(keys) {
+				continue
+			}
+			if _, ok := tipSet[keys[instruction.Accounts[1]]]; ok {
+				total += binary.LittleEndian.Uint64(data[4:])
+			}
+		}
+	}
+
+	return total
+}
@@ -0,0 +1,174 @@
+package pkg
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+)
+
+// newTestKey returns a fresh random private key.
+func newTestKey(t *testing.T) solana.PrivateKey {
+	t.Helper()
+	key, err := solana.NewRandomPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// newTransferTransaction returns a transaction from payer transferring lamports to the recipient,
+// signed by payer.
+func newTransferTransaction(t *testing.T, payer solana.PrivateKey, recipient solana.PublicKey, lamports uint64, blockhash solana.Hash) *solana.Transaction {
+	t.Helper()
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{system.NewTransferInstruction(lamports, payer.PublicKey(), recipient).Build()},
+		blockhash,
+		solana.TransactionPayer(payer.PublicKey()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = PartialSignTransaction(tx, []Signer{payer}); err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+// findingCodes returns the codes of the findings.
+func findingCodes(findings BundleFindings) map[FindingCode]FindingSeverity {
+	codes := make(map[FindingCode]FindingSeverity, len(findings))
+	for _, finding := range findings {
+		codes[finding.Code] = finding.Severity
+	}
+	return codes
+}
+
+func TestValidateBundle(t *testing.T) {
+	payer := newTestKey(t)
+	tipAccount := solana.NewWallet().PublicKey()
+	other := solana.NewWallet().PublicKey()
+	blockhash := solana.Hash{1}
+
+	valid := func() *solana.Transaction {
+		return newTransferTransaction(t, payer, tipAccount, 1_000, blockhash)
+	}
+
+	tests := []struct {
+		name         string
+		transactions func() []*solana.Transaction
+		want         FindingCode
+		severity     FindingSeverity
+	}{
+		{"empty", func() []*solana.Transaction { return nil }, FindingEmptyBundle, SeverityError},
+		{"too many transactions", func() []*solana.Transaction {
+			txs := make([]*solana.Transaction, MaxBundleTransactions+1)
+			for i := range txs {
+				txs[i] = newTransferTransaction(t, payer, tipAccount, uint64(i+1), blockhash)
+			}
+			return txs
+		}, FindingTooManyTransactions, SeverityError},
+		{"missing signature", func() []*solana.Transaction {
+			tx := valid()
+			tx.Signatures[0] = solana.Signature{}
+			return []*solana.Transaction{tx}
+		}, FindingMissingSignature, SeverityError},
+		{"invalid signature", func() []*solana.Transaction {
+			tx := valid()
+			tx.Signatures[0][0] ^= 0xff
+			return []*solana.Transaction{tx}
+		}, FindingInvalidSignature, SeverityError},
+		{"duplicate signature", func() []*solana.Transaction {
+			tx := valid()
+			return []*solana.Transaction{tx, tx}
+		}, FindingDuplicateSignature, SeverityError},
+		{"blockhash mismatch", func() []*solana.Transaction {
+			return []*solana.Transaction{valid(), newTransferTransaction(t, payer, other, 1, solana.Hash{2})}
+		}, FindingBlockhashMismatch, SeverityWarning},
+		{"missing tip", func() []*solana.Transaction {
+			return []*solana.Transaction{newTransferTransaction(t, payer, other, 1_000, blockhash)}
+		}, FindingMissingTip, SeverityError},
+		{"transaction too large", func() []*solana.Transaction {
+			tx := valid()
+			tx.Message.Instructions[0].Data = make([]byte, MaxTransactionSize)
+			if err := PartialSignTransaction(tx, []Signer{payer}); err != nil {
+				t.Fatal(err)
+			}
+			return []*solana.Transaction{tx}
+		}, FindingTransactionTooLarge, SeverityError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := ValidateBundle(tt.transactions(), []solana.PublicKey{tipAccount})
+			severity, ok := findingCodes(findings)[tt.want]
+			if !ok {
+				t.Fatalf("findings %v do not include %s", findings, tt.want)
+			}
+			if severity != tt.severity {
+				t.Errorf("%s has severity %s, want %s", tt.want, severity, tt.severity)
+			}
+			if got := findings.HasErrors(); got != (tt.severity == SeverityError) {
+				t.Errorf("HasErrors() = %v", got)
+			}
+		})
+	}
+}
+
+func TestValidateBundleValid(t *testing.T) {
+	payer := newTestKey(t)
+	tipAccount := solana.NewWallet().PublicKey()
+
+	txs := []*solana.Transaction{
+		newTransferTransaction(t, payer, solana.NewWallet().PublicKey(), 1, solana.Hash{1}),
+		newTransferTransaction(t, payer, tipAccount, 1_000, solana.Hash{1}),
+	}
+	findings := ValidateBundle(txs, []solana.PublicKey{tipAccount})
+	if len(findings) != 0 {
+		t.Errorf("valid bundle has findings: %v", findings)
+	}
+	if err := findings.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+
+	// Without tip accounts the tip is not checked
+	txs = txs[:1]
+	if findings = ValidateBundle(txs, nil); len(findings) != 0 {
+		t.Errorf("bundle without tip accounts has findings: %v", findings)
+	}
+}
+
+func TestBundleFindingsErr(t *testing.T) {
+	findings := BundleFindings{
+		{Severity: SeverityWarning, Code: FindingBlockhashMismatch, TxIndex: 1, Message: "warning"},
+		{Severity: SeverityError, Code: FindingMissingTip, TxIndex: -1, Message: "no tip"},
+	}
+
+	var validationErr *BundleValidationError
+	if err := findings.Err(); !errors.As(err, &validationErr) {
+		t.Fatalf("Err() = %v, want a *BundleValidationError", err)
+	}
+	if len(validationErr.Findings) != 2 {
+		t.Errorf("error holds %d findings, want 2", len(validationErr.Findings))
+	}
+	if want := "bundle validation failed: error: missing_tip: no tip"; validationErr.Error() != want {
+		t.Errorf("Error() = %q, want %q", validationErr.Error(), want)
+	}
+	if err := findings[:1].Err(); err != nil {
+		t.Errorf("warnings only: Err() = %v, want nil", err)
+	}
+}
+
+func TestExtractTipLamports(t *testing.T) {
+	payer := newTestKey(t)
+	tipAccounts := []solana.PublicKey{solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()}
+
+	txs := []*solana.Transaction{
+		newTransferTransaction(t, payer, tipAccounts[0], 1_000, solana.Hash{1}),
+		newTransferTransaction(t, payer, solana.NewWallet().PublicKey(), 7, solana.Hash{1}),
+		newTransferTransaction(t, payer, tipAccounts[1], 500, solana.Hash{1}),
+	}
+	if got := ExtractTipLamports(txs, tipAccounts); got != 1_500 {
+		t.Errorf("ExtractTipLamports() = %d, want 1500", got)
+	}
+}
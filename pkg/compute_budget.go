@@ -0,0 +1,156 @@
+package pkg
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// Constants for the compute budget of transactions
+const (
+	MaxComputeUnitLimit                = 1_400_000 // Maximum compute unit limit of a transaction
+	DefaultInstructionComputeUnitLimit = 200_000   // Compute units granted per instruction without a SetComputeUnitLimit
+	setComputeUnitLimitDiscriminator   = 2         // Compute budget instruction setting the unit limit, followed by a u32
+	setComputeUnitPriceDiscriminator   = 3         // Compute budget instruction setting the unit price, followed by a u64
+)
+
+// NewSetComputeUnitLimitInstruction creates a compute budget instruction setting the compute unit limit.
+func NewSetComputeUnitLimitInstruction(units uint32) solana.Instruction {
+	return solana.NewInstruction(ComputeBudgetProgramID, solana.AccountMetaSlice{}, encodeComputeUnitLimit(units))
+}
+
+// NewSetComputeUnitPriceInstruction creates a compute budget instruction setting the compute unit price
+// in micro-lamports.
+func NewSetComputeUnitPriceInstruction(microLamports uint64) solana.Instruction {
+	return solana.NewInstruction(ComputeBudgetProgramID, solana.AccountMetaSlice{}, encodeComputeUnitPrice(microLamports))
+}
+
+// GetComputeUnitLimit returns the compute unit limit set by the transaction, if any.
+func GetComputeUnitLimit(tx *solana.Transaction) (uint32, bool) {
+	data, ok := findComputeBudgetInstruction(tx, setComputeUnitLimitDiscriminator, 5)
+	if !ok {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint32(data[1:5]), true
+}
+
+// GetComputeUnitPrice returns the compute unit price in micro-lamports set by the transaction, if any.
+func GetComputeUnitPrice(tx *solana.Transaction) (uint64, bool) {
+	data, ok := findComputeBudgetInstruction(tx, setComputeUnitPriceDiscriminator, 9)
+	if !ok {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint64(data[1:9]), true
+}
+
+// RequestedComputeUnitLimit returns the compute unit limit the transaction runs with: the one it sets,
+// or DefaultInstructionComputeUnitLimit per instruction other than compute budget ones, up to MaxComputeUnitLimit.
+func RequestedComputeUnitLimit(tx *solana.Transaction) uint32 {
+	if units, ok := GetComputeUnitLimit(tx); ok {
+		return units
+	}
+
+	var units uint32
+	for _, instruction := range tx.Message.Instructions {
+		if !isComputeBudgetInstruction(tx, instruction) {
+			units += DefaultInstructionComputeUnitLimit
+		}
+	}
+	if units > MaxComputeUnitLimit {
+		units = MaxComputeUnitLimit
+	}
+	return units
+}
+
+// SetComputeUnitLimit rewrites the SetComputeUnitLimit instruction of the transaction, or inserts one
+// at the start of the transaction. The transaction must be signed again.
+func SetComputeUnitLimit(tx *solana.Transaction, units uint32) error {
+	return setComputeBudgetInstruction(tx, encodeComputeUnitLimit(units))
+}
+
+// SetComputeUnitPrice rewrites the SetComputeUnitPrice instruction of the transaction, or inserts one
+// at the start of the transaction. The transaction must be signed again.
+func SetComputeUnitPrice(tx *solana.Transaction, microLamports uint64) error {
+	return setComputeBudgetInstruction(tx, encodeComputeUnitPrice(microLamports))
+}
+
+// setComputeBudgetInstruction replaces the data of the compute budget instruction with the same discriminator,
+// or inserts the instruction, adding the compute budget program to the static account keys if needed.
+func setComputeBudgetInstruction(tx *solana.Transaction, data []byte) error {
+	msg := &tx.Message
+	for i, instruction := range msg.Instructions {
+		if isComputeBudgetInstruction(tx, instruction) && len(instruction.Data) > 0 && instruction.Data[0] == data[0] {
+			msg.Instructions[i].Data = data
+			return nil
+		}
+	}
+
+	programIndex := -1
+	for i, key := range msg.AccountKeys {
+		if key.Equals(ComputeBudgetProgramID) {
+			programIndex = i
+			break
+		}
+	}
+
+	if programIndex < 0 {
+		if len(msg.AccountKeys) >= 256 {
+			return errors.New("transaction has no room for the compute budget program")
+		}
+
+		// Programs are readonly non-signers, which come last in the static account keys
+		programIndex = len(msg.AccountKeys)
+		msg.AccountKeys = append(msg.AccountKeys, ComputeBudgetProgramID)
+		msg.Header.NumReadonlyUnsignedAccounts++
+
+		// Accounts loaded from lookup tables are indexed after the static account keys
+		for i := range msg.Instructions {
+			for j, index := range msg.Instructions[i].Accounts {
+				if int(index) >= programIndex {
+					msg.Instructions[i].Accounts[j] = index + 1
+				}
+			}
+		}
+	}
+
+	instruction := solana.CompiledInstruction{
+		ProgramIDIndex: uint16(programIndex),
+		Accounts:       []uint16{},
+		Data:           data,
+	}
+	msg.Instructions = append([]solana.CompiledInstruction{instruction}, msg.Instructions...)
+	return nil
+}
+
+// findComputeBudgetInstruction returns the data of the compute budget instruction with the discriminator.
+func findComputeBudgetInstruction(tx *solana.Transaction, discriminator byte, size int) ([]byte, bool) {
+	for _, instruction := range tx.Message.Instructions {
+		if isComputeBudgetInstruction(tx, instruction) && len(instruction.Data) >= size && instruction.Data[0] == discriminator {
+			return instruction.Data, true
+		}
+	}
+	return nil, false
+}
+
+// isComputeBudgetInstruction reports whether the instruction invokes the compute budget program.
+func isComputeBudgetInstruction(tx *solana.Transaction, instruction solana.CompiledInstruction) bool {
+	index := int(instruction.ProgramIDIndex)
+	return index < len(tx.Message.AccountKeys) && tx.Message.AccountKeys[index].Equals(ComputeBudgetProgramID)
+}
+
+// encodeComputeUnitLimit encodes the data of a SetComputeUnitLimit instruction.
+func encodeComputeUnitLimit(units uint32) []byte {
+	data := make([]byte, 5)
+	data[0] = setComputeUnitLimitDiscriminator
+	binary.LittleEndian.PutUint32(data[1:], units)
+	return data
+}
+
+// encodeComputeUnitPrice encodes the data of a SetComputeUnitPrice instruction.
+func encodeComputeUnitPrice(microLamports uint64) []byte {
+	data := make([]byte, 9)
+	data[0] = setComputeUnitPriceDiscriminator
+	binary.LittleEndian.PutUint64(data[1:], microLamports)
+	return data
+}
@@ -0,0 +1,195 @@
+package pkg
+
+import (
+	"fmt"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+)
+
+// instructionMetas round-trips the transaction through its wire format and returns the program and account
+// metas of each instruction not invoking the compute budget program, resolving lookups with the tables.
+func instructionMetas(t *testing.T, tx *solana.Transaction, tables map[solana.PublicKey]solana.PublicKeySlice) []string {
+	t.Helper()
+
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &solana.Transaction{}
+	if err = decoded.UnmarshalWithDecoder(bin.NewBorshDecoder(data)); err != nil {
+		t.Fatal(err)
+	}
+	if tables != nil {
+		if err = decoded.Message.SetAddressTables(tables); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var metas []string
+	for _, instruction := range decoded.Message.Instructions {
+		if isComputeBudgetInstruction(decoded, instruction) {
+			continue
+		}
+		accounts, err := instruction.ResolveInstructionAccounts(&decoded.Message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		described := fmt.Sprint(decoded.Message.AccountKeys[instruction.ProgramIDIndex])
+		for _, account := range accounts {
+			described += fmt.Sprintf(" %s:signer=%v:writable=%v", account.PublicKey, account.IsSigner, account.IsWritable)
+		}
+		metas = append(metas, described+" "+fmt.Sprint(instruction.Data))
+	}
+	return metas
+}
+
+// checkComputeBudget checks the compute budget the transaction sets and that it holds one instruction of each kind.
+func checkComputeBudget(t *testing.T, tx *solana.Transaction, units uint32, microLamports uint64) {
+	t.Helper()
+
+	if got, ok := GetComputeUnitLimit(tx); !ok || got != units {
+		t.Errorf("GetComputeUnitLimit() = %d, %v, want %d", got, ok, units)
+	}
+	if got, ok := GetComputeUnitPrice(tx); !ok || got != microLamports {
+		t.Errorf("GetComputeUnitPrice() = %d, %v, want %d", got, ok, microLamports)
+	}
+	if got := RequestedComputeUnitLimit(tx); got != units {
+		t.Errorf("RequestedComputeUnitLimit() = %d, want %d", got, units)
+	}
+
+	programs, instructions := 0, 0
+	for _, key := range tx.Message.AccountKeys {
+		if key.Equals(ComputeBudgetProgramID) {
+			programs++
+		}
+	}
+	for _, instruction := range tx.Message.Instructions {
+		if isComputeBudgetInstruction(tx, instruction) {
+			instructions++
+		}
+	}
+	if programs != 1 || instructions != 2 {
+		t.Errorf("got %d compute budget programs and %d instructions, want 1 and 2", programs, instructions)
+	}
+}
+
+func TestSetComputeBudgetLegacy(t *testing.T) {
+	payer := newTestKey(t)
+	tx := newTransferTransaction(t, payer, solana.NewWallet().PublicKey(), 1_000, solana.Hash{1})
+	metas := instructionMetas(t, tx, nil)
+
+	if got := RequestedComputeUnitLimit(tx); got != DefaultInstructionComputeUnitLimit {
+		t.Errorf("default RequestedComputeUnitLimit() = %d, want %d", got, DefaultInstructionComputeUnitLimit)
+	}
+
+	// Insert both instructions, then rewrite them
+	for _, budget := range []struct {
+		units         uint32
+		microLamports uint64
+	}{{50_000, 1_000}, {60_000, 2_000}} {
+		if err := SetComputeUnitLimit(tx, budget.units); err != nil {
+			t.Fatal(err)
+		}
+		if err := SetComputeUnitPrice(tx, budget.microLamports); err != nil {
+			t.Fatal(err)
+		}
+		checkComputeBudget(t, tx, budget.units, budget.microLamports)
+		if got := instructionMetas(t, tx, nil); fmt.Sprint(got) != fmt.Sprint(metas) {
+			t.Errorf("instructions after setting the compute budget = %v, want %v", got, metas)
+		}
+	}
+
+	// The rewritten transaction is signed again by the same signers
+	if err := PartialSignTransaction(tx, []Signer{payer}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.VerifySignatures(); err != nil {
+		t.Errorf("VerifySignatures() = %v", err)
+	}
+}
+
+func TestSetComputeBudgetExistingProgram(t *testing.T) {
+	payer := newTestKey(t)
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{
+			NewSetComputeUnitPriceInstruction(500),
+			system.NewTransferInstruction(1_000, payer.PublicKey(), solana.NewWallet().PublicKey()).Build(),
+		},
+		solana.Hash{1},
+		solana.TransactionPayer(payer.PublicKey()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := len(tx.Message.AccountKeys)
+	metas := instructionMetas(t, tx, nil)
+
+	// The limit is inserted reusing the compute budget program key
+	if err = SetComputeUnitLimit(tx, 30_000); err != nil {
+		t.Fatal(err)
+	}
+	checkComputeBudget(t, tx, 30_000, 500)
+	if len(tx.Message.AccountKeys) != keys {
+		t.Errorf("got %d account keys, want %d", len(tx.Message.AccountKeys), keys)
+	}
+	if got := instructionMetas(t, tx, nil); fmt.Sprint(got) != fmt.Sprint(metas) {
+		t.Errorf("instructions = %v, want %v", got, metas)
+	}
+}
+
+func TestSetComputeBudgetV0WithLookups(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+	program := solana.NewWallet().PublicKey()
+	table := solana.NewWallet().PublicKey()
+	written, read := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	tables := map[solana.PublicKey]solana.PublicKeySlice{table: {written, read}}
+
+	// The instruction uses the payer, then the writable and the readonly looked up accounts
+	tx := &solana.Transaction{
+		Signatures: []solana.Signature{{}},
+		Message: solana.Message{
+			AccountKeys: solana.PublicKeySlice{payer, program},
+			Header: solana.MessageHeader{
+				NumRequiredSignatures:       1,
+				NumReadonlyUnsignedAccounts: 1,
+			},
+			RecentBlockhash: solana.Hash{1},
+			Instructions: []solana.CompiledInstruction{{
+				ProgramIDIndex: 1,
+				Accounts:       []uint16{0, 2, 3},
+				Data:           []byte{1, 2, 3},
+			}},
+			AddressTableLookups: solana.MessageAddressTableLookupSlice{{
+				AccountKey:      table,
+				WritableIndexes: []uint8{0},
+				ReadonlyIndexes: []uint8{1},
+			}},
+		},
+	}
+	tx.Message.SetVersion(solana.MessageVersionV0)
+	metas := instructionMetas(t, tx, tables)
+
+	for _, budget := range []struct {
+		units         uint32
+		microLamports uint64
+	}{{80_000, 10}, {90_000, 20}} {
+		if err := SetComputeUnitLimit(tx, budget.units); err != nil {
+			t.Fatal(err)
+		}
+		if err := SetComputeUnitPrice(tx, budget.microLamports); err != nil {
+			t.Fatal(err)
+		}
+		checkComputeBudget(t, tx, budget.units, budget.microLamports)
+		if got := instructionMetas(t, tx, tables); fmt.Sprint(got) != fmt.Sprint(metas) {
+			t.Errorf("instructions after setting the compute budget = %v, want %v", got, metas)
+		}
+	}
+
+	// The looked up accounts are shifted past the compute budget program appended to the static keys
+	if got := tx.Message.Instructions[len(tx.Message.Instructions)-1].Accounts; fmt.Sprint(got) != "[0 3 4]" {
+		t.Errorf("instruction accounts = %v, want [0 3 4]", got)
+	}
+}
@@ -2,6 +2,15 @@ package pkg
 
 import "github.com/gagliardetto/solana-go"
 
+// Constants for transaction and bundle limits enforced by Jito
+const (
+	MaxTransactionSize         = 1232 // Maximum serialized size of a transaction in bytes
+	MaxBundleTransactions      = 5    // Maximum number of transactions in a bundle
+	MaxTransactionAccountLocks = 64   // Maximum number of accounts a transaction can lock
+)
+
 var (
-	MemoPublicKey = solana.MustPublicKeyFromBase58("MemoSq4gqABAXKb96qnH8TysNcWxMyWCqXgDLGmfcHr")
+	MemoPublicKey               = solana.MustPublicKeyFromBase58("MemoSq4gqABAXKb96qnH8TysNcWxMyWCqXgDLGmfcHr")
+	AddressLookupTableProgramID = solana.MustPublicKeyFromBase58("AddressLookupTab1e1111111111111111111111111")
+	ComputeBudgetProgramID      = solana.MustPublicKeyFromBase58("ComputeBudget111111111111111111111111111111")
 )
@@ -0,0 +1,229 @@
+package pkg
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// DefaultEventSubscriptionCapacity is the number of events buffered per subscription if none is given.
+const DefaultEventSubscriptionCapacity = 64
+
+// EventKind is the kind of event published on an EventBus.
+type EventKind int
+
+// Event kinds
+const (
+	EventAuth            EventKind = iota // Outcome of authentication or token refresh, Payload holds the details
+	EventConnectionState                  // gRPC connection state change, State holds the new state
+	EventConnectionError                  // gRPC connection error, e.g. a failed redial
+	EventStreamError                      // Error that ended a stream
+	EventResultDropped                    // Result dropped because its consumer was not draining, Payload holds it
+	EventRefreshError                     // Error of a background refresh or poll, e.g. of the tip accounts
+)
+
+// String returns the name of the event kind.
+func (k EventKind) String() string {
+	switch k {
+	case EventAuth:
+		return "auth"
+	case EventConnectionState:
+		return "connection_state"
+	case EventConnectionError:
+		return "connection_error"
+	case EventStreamError:
+		return "stream_error"
+	case EventResultDropped:
+		return "result_dropped"
+	case EventRefreshError:
+		return "refresh_error"
+	default:
+		return fmt.Sprintf("event(%d)", int(k))
+	}
+}
+
+// Event is an auth, connection or stream event of a client.
+type Event struct {
+	Kind    EventKind          // Kind of event
+	Time    time.Time          // Time of the event
+	Source  string             // Component reporting the event, e.g. the gRPC address or the stream method
+	State   connectivity.State // New connection state of an EventConnectionState
+	Err     error              // Error of the event, if any
+	Payload interface{}        // Kind-specific details, e.g. the block_engine_pkg.AuthEvent of an EventAuth
+}
+
+// String returns a human-readable representation of the event.
+func (e Event) String() string {
+	switch {
+	case e.Kind == EventConnectionState:
+		return fmt.Sprintf("%s %s: %s", e.Kind, e.Source, e.State)
+	case e.Err != nil:
+		return fmt.Sprintf("%s %s: %v", e.Kind, e.Source, e.Err)
+	case e.Payload != nil:
+		return fmt.Sprintf("%s %s: %v", e.Kind, e.Source, e.Payload)
+	default:
+		return fmt.Sprintf("%s %s", e.Kind, e.Source)
+	}
+}
+
+// EventBusStats holds the delivery counters of an EventBus.
+type EventBusStats struct {
+	Published uint64 // Events published
+	Delivered uint64 // Events delivered to subscriptions
+	Dropped   uint64 // Events dropped because a subscription was full
+}
+
+// EventBus delivers the events of clients to their subscribers. Publishing never blocks: events are
+// dropped and counted for subscriptions that are not drained, so reporting goroutines never stall.
+// A nil EventBus discards every event.
+type EventBus struct {
+	subscriptions map[uint64]*EventSubscription // Subscriptions indexed by ID
+	nextID        uint64                        // ID of the next subscription
+	published     atomic.Uint64                 // Events published
+	delivered     atomic.Uint64                 // Events delivered to subscriptions
+	dropped       atomic.Uint64                 // Events dropped because a subscription was full
+	mu            sync.RWMutex                  // Mutex for synchronizing the subscriptions
+}
+
+// EventSubscription receives the events of an EventBus on its channel.
+type EventSubscription struct {
+	C       <-chan Event           // Channel the events are delivered on, closed by Close
+	ch      chan Event             // Writable side of C
+	kinds   map[EventKind]struct{} // Kinds of events delivered, all if empty
+	dropped atomic.Uint64          // Events dropped because the channel was full
+	bus     *EventBus              // Bus the subscription belongs to
+	id      uint64                 // ID of the subscription in the bus
+	once    sync.Once              // Guards closing the subscription
+}
+
+// NewEventBus creates a new EventBus.
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subscriptions: make(map[uint64]*EventSubscription),
+	}
+}
+
+// Subscribe returns a subscription receiving the events of the given kinds, or of every kind if none is given.
+// Up to capacity events are buffered, DefaultEventSubscriptionCapacity if zero.
+func (b *EventBus) Subscribe(capacity int, kinds ...EventKind) *EventSubscription {
+	if capacity <= 0 {
+		capacity = DefaultEventSubscriptionCapacity
+	}
+
+	ch := make(chan Event, capacity)
+	s := &EventSubscription{
+		C:     ch,
+		ch:    ch,
+		kinds: make(map[EventKind]struct{}, len(kinds)),
+		bus:   b,
+	}
+	for _, kind := range kinds {
+		s.kinds[kind] = struct{}{}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s.id = b.nextID
+	b.nextID++
+	b.subscriptions[s.id] = s
+	return s
+}
+
+// Handle calls the handler with the events of the given kinds, or of every kind if none is given,
+// from a goroutine of its own until the returned subscription is closed. Events are dropped while
+// the handler falls more than DefaultEventSubscriptionCapacity events behind.
+func (b *EventBus) Handle(handler func(Event), kinds ...EventKind) *EventSubscription {
+	s := b.Subscribe(DefaultEventSubscriptionCapacity, kinds...)
+	go func() {
+		for event := range s.C {
+			handler(event)
+		}
+	}()
+	return s
+}
+
+// Publish delivers the event to every subscription of its kind without blocking.
+// The time of the event is set if missing.
+func (b *EventBus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	b.published.Add(1)
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, s := range b.subscriptions {
+		if len(s.kinds) > 0 {
+			if _, ok := s.kinds[event.Kind]; !ok {
+				continue
+			}
+		}
+
+		select {
+		case s.ch <- event:
+			b.delivered.Add(1)
+		default:
+			s.dropped.Add(1)
+			b.dropped.Add(1)
+		}
+	}
+}
+
+// Stats returns the delivery counters of the bus.
+func (b *EventBus) Stats() EventBusStats {
+	if b == nil {
+		return EventBusStats{}
+	}
+	return EventBusStats{
+		Published: b.published.Load(),
+		Delivered: b.delivered.Load(),
+		Dropped:   b.dropped.Load(),
+	}
+}
+
+// Dropped returns the number of events dropped because the subscription was not drained.
+func (s *EventSubscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close removes the subscription from the bus and closes its channel.
+func (s *EventSubscription) Close() {
+	s.once.Do(func() {
+		s.bus.mu.Lock()
+		defer s.bus.mu.Unlock()
+
+		delete(s.bus.subscriptions, s.id)
+		close(s.ch)
+	})
+}
+
+// EventBusOption is a dial option giving a client the EventBus to publish its events on, see WithEventBus.
+type EventBusOption struct {
+	grpc.EmptyDialOption
+	Bus *EventBus // Event bus of the client
+}
+
+// WithEventBus returns a dial option making a client publish its events on the bus instead of a bus of its own,
+// e.g. so several clients share one bus, or events published while connecting are not missed.
+func WithEventBus(bus *EventBus) grpc.DialOption {
+	return EventBusOption{Bus: bus}
+}
+
+// EventBusFromDialOptions returns the bus given with WithEventBus, or a new EventBus if there is none.
+func EventBusFromDialOptions(opts []grpc.DialOption) *EventBus {
+	for _, opt := range opts {
+		if option, ok := opt.(EventBusOption); ok && option.Bus != nil {
+			return option.Bus
+		}
+	}
+	return NewEventBus()
+}
@@ -0,0 +1,240 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// receiveEvent returns the next event of the subscription.
+func receiveEvent(t *testing.T, sub *EventSubscription) Event {
+	t.Helper()
+
+	select {
+	case event := <-sub.C:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an event")
+		return Event{}
+	}
+}
+
+// checkNoEvent checks the subscription has no pending event.
+func checkNoEvent(t *testing.T, sub *EventSubscription) {
+	t.Helper()
+
+	select {
+	case event, ok := <-sub.C:
+		if ok {
+			t.Errorf("unexpected event %v", event)
+		}
+	default:
+	}
+}
+
+func TestEventBusSubscribe(t *testing.T) {
+	bus := NewEventBus()
+	auth := bus.Subscribe(0, EventAuth)
+	connection := bus.Subscribe(0, EventConnectionState, EventConnectionError)
+	all := bus.Subscribe(0)
+
+	published := []Event{
+		{Kind: EventAuth, Source: "auth/SEARCHER"},
+		{Kind: EventConnectionError, Source: "block-engine:443", Err: errors.New("unavailable")},
+		{Kind: EventStreamError, Source: "/searcher.SearcherService/SubscribeBundleResults"},
+	}
+	for _, event := range published {
+		bus.Publish(event)
+	}
+
+	if event := receiveEvent(t, auth); event.Kind != EventAuth {
+		t.Errorf("auth subscription received %v", event)
+	}
+	checkNoEvent(t, auth)
+	if event := receiveEvent(t, connection); event.Kind != EventConnectionError || event.Err == nil {
+		t.Errorf("connection subscription received %v", event)
+	}
+	checkNoEvent(t, connection)
+	for _, want := range published {
+		if event := receiveEvent(t, all); event.Kind != want.Kind || event.Source != want.Source {
+			t.Errorf("subscription to every kind received %v, want %v", event, want)
+		}
+	}
+
+	if stats := bus.Stats(); stats != (EventBusStats{Published: 3, Delivered: 5}) {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
+
+func TestEventBusPublishTime(t *testing.T) {
+	bus := NewEventBus()
+	sub := bus.Subscribe(0)
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	before := time.Now()
+	bus.Publish(Event{Kind: EventAuth})
+	if event := receiveEvent(t, sub); event.Time.Before(before) || event.Time.After(time.Now()) {
+		t.Errorf("event time = %s, want the publication time", event.Time)
+	}
+
+	bus.Publish(Event{Kind: EventAuth, Time: at})
+	if event := receiveEvent(t, sub); !event.Time.Equal(at) {
+		t.Errorf("event time = %s, want %s", event.Time, at)
+	}
+}
+
+func TestEventBusDropsWithoutBlocking(t *testing.T) {
+	bus := NewEventBus()
+	full := bus.Subscribe(1)
+	drained := bus.Subscribe(10)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 3; i++ {
+			bus.Publish(Event{Kind: EventConnectionError})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Publish() blocked on a subscription that is not drained")
+	}
+
+	if full.Dropped() != 2 || drained.Dropped() != 0 {
+		t.Errorf("dropped %d and %d events, want 2 and 0", full.Dropped(), drained.Dropped())
+	}
+	if stats := bus.Stats(); stats != (EventBusStats{Published: 3, Delivered: 4, Dropped: 2}) {
+		t.Errorf("Stats() = %+v", stats)
+	}
+	if len(drained.C) != 3 {
+		t.Errorf("drained subscription holds %d events, want 3", len(drained.C))
+	}
+}
+
+func TestEventBusHandle(t *testing.T) {
+	bus := NewEventBus()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var handled atomic.Int64
+	var once sync.Once
+	sub := bus.Handle(func(event Event) {
+		if event.Kind != EventStreamError {
+			t.Errorf("handled %v, want a stream error", event)
+		}
+		once.Do(func() { close(started) })
+		<-release
+		handled.Add(1)
+	}, EventStreamError)
+	defer sub.Close()
+
+	// While the handler blocks on the first event, the next ones are buffered, then dropped
+	bus.Publish(Event{Kind: EventAuth})
+	bus.Publish(Event{Kind: EventStreamError})
+	<-started
+	for i := 0; i < DefaultEventSubscriptionCapacity+10; i++ {
+		bus.Publish(Event{Kind: EventStreamError})
+	}
+	if sub.Dropped() != 10 {
+		t.Errorf("dropped %d events while the handler was blocked, want 10", sub.Dropped())
+	}
+
+	close(release)
+	want := int64(DefaultEventSubscriptionCapacity + 1)
+	for deadline := time.Now().Add(5 * time.Second); handled.Load() < want && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	if got := handled.Load(); got != want {
+		t.Errorf("handled %d events, want %d", got, want)
+	}
+}
+
+func TestEventSubscriptionClose(t *testing.T) {
+	bus := NewEventBus()
+	sub := bus.Subscribe(0)
+
+	sub.Close()
+	sub.Close()
+	if _, ok := <-sub.C; ok {
+		t.Error("channel of a closed subscription is open")
+	}
+
+	bus.Publish(Event{Kind: EventAuth})
+	if stats := bus.Stats(); stats.Delivered != 0 || stats.Dropped != 0 {
+		t.Errorf("Stats() after closing the subscription = %+v", stats)
+	}
+}
+
+func TestNilEventBus(t *testing.T) {
+	var bus *EventBus
+	bus.Publish(Event{Kind: EventAuth})
+	if stats := bus.Stats(); stats != (EventBusStats{}) {
+		t.Errorf("Stats() of a nil bus = %+v", stats)
+	}
+}
+
+func TestEventBusFromDialOptions(t *testing.T) {
+	bus := NewEventBus()
+
+	if got := EventBusFromDialOptions([]grpc.DialOption{grpc.WithUserAgent("test"), WithEventBus(bus)}); got != bus {
+		t.Error("EventBusFromDialOptions() did not return the given bus")
+	}
+	if got := EventBusFromDialOptions([]grpc.DialOption{WithEventBus(nil)}); got == nil || got == bus {
+		t.Errorf("EventBusFromDialOptions() without a bus = %p, want a new bus", got)
+	}
+}
+
+func TestEventString(t *testing.T) {
+	tests := []struct {
+		event Event
+		want  string
+	}{
+		{Event{Kind: EventConnectionState, Source: "block-engine:443", State: connectivity.Ready}, "connection_state block-engine:443: READY"},
+		{Event{Kind: EventConnectionError, Source: "block-engine:443", Err: errors.New("unavailable")}, "connection_error block-engine:443: unavailable"},
+		{Event{Kind: EventAuth, Source: "auth/SEARCHER", Payload: "refreshed"}, "auth auth/SEARCHER: refreshed"},
+		{Event{Kind: EventStreamError, Source: "/method"}, "stream_error /method"},
+		{Event{Kind: EventRefreshError, Source: "GetTipAccounts", Err: errors.New("unavailable")}, "refresh_error GetTipAccounts: unavailable"},
+		{Event{Kind: EventResultDropped, Source: "NYC", Payload: "uuid"}, "result_dropped NYC: uuid"},
+		{Event{Kind: EventKind(9)}, "event(9) "},
+	}
+	for _, tt := range tests {
+		if got := tt.event.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestCreateGRPCConnectionPublishesState(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	go server.Serve(listener)
+	defer server.Stop()
+
+	bus := NewEventBus()
+	sub := bus.Subscribe(0, EventConnectionState)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if _, err = CreateGRPCConnection(ctx, bus, "http://"+listener.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		event := receiveEvent(t, sub)
+		if event.Source != listener.Addr().String() {
+			t.Errorf("event source = %s, want %s", event.Source, listener.Addr())
+		}
+		if event.State == connectivity.Ready {
+			break
+		}
+	}
+}
@@ -23,11 +23,12 @@ var kacp = keepalive.ClientParameters{
 }
 
 // CreateGRPCConnection creates and manages a gRPC connection with specified options and error handling.
-// It takes a context, an error channel, the gRPC address, and additional gRPC dial options.
+// It takes a context, an event bus, the gRPC address, and additional gRPC dial options.
+// Connection state changes and errors are published on the bus, which may be nil to discard them.
 // It returns a gRPC client connection or an error if the connection setup fails.
 func CreateGRPCConnection(
 	ctx context.Context,
-	chErr chan error,
+	bus *EventBus,
 	grpcAddr string,
 	opts ...grpc.DialOption,
 ) (*grpc.ClientConn, error) {
@@ -83,15 +84,20 @@ func CreateGRPCConnection(
 	// Monitor the connection state in a goroutine
 	go func() {
 		var retries int
+		lastState := conn.GetState()
 		for {
 			select {
 			case <-ctx.Done():
-				if err = conn.Close(); err != nil {
-					chErr <- err
+				if err := conn.Close(); err != nil {
+					publishConnectionError(bus, address, err)
 				}
 				return
 			default:
 				state := conn.GetState()
+				if state != lastState {
+					lastState = state
+					bus.Publish(Event{Kind: EventConnectionState, Source: address, State: state})
+				}
 				if state == connectivity.Ready {
 					retries = 0
 					time.Sleep(1 * time.Second)
@@ -110,14 +116,14 @@ func CreateGRPCConnection(
 						conn.Close()
 						conn, err = grpc.DialContext(ctx, address, opts...)
 						if err != nil {
-							chErr <- err
+							publishConnectionError(bus, address, err)
 						}
 						retries = 0
 					}
 				} else if state == connectivity.Shutdown {
 					conn, err = grpc.DialContext(ctx, address, opts...)
 					if err != nil {
-						chErr <- err
+						publishConnectionError(bus, address, err)
 					}
 					retries = 0
 				}
@@ -132,3 +138,8 @@ func CreateGRPCConnection(
 
 	return conn, nil
 }
+
+// publishConnectionError publishes an error of the connection to the address on the bus.
+func publishConnectionError(bus *EventBus, address string, err error) {
+	bus.Publish(Event{Kind: EventConnectionError, Source: address, Err: err})
+}
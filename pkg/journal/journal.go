@@ -0,0 +1,370 @@
+package journal_pkg
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Constants for the journal files
+const (
+	DefaultMaxSegmentSize = 64 << 20   // Size after which the active segment is rotated
+	SegmentExtension      = ".journal" // Extension of the segment files
+	tempExtension         = ".tmp"     // Extension of segments being written by a compaction
+)
+
+// ErrJournalClosed is returned when appending to or querying a closed journal.
+var ErrJournalClosed = errors.New("journal closed")
+
+// RetentionPolicy configures how long journaled bundles are kept.
+type RetentionPolicy struct {
+	MaxAge  time.Duration // Age after which closed segments and compacted bundles are deleted, 0 to keep them
+	MaxSize int64         // Total size of the segments after which the oldest are deleted, 0 for no limit
+}
+
+// Config configures a Journal.
+type Config struct {
+	Dir            string          // Directory of the segment files, created if missing
+	MaxSegmentSize int64           // Size after which the active segment is rotated, DefaultMaxSegmentSize if zero
+	SyncWrites     bool            // Whether every append is flushed to disk before returning, appends may be lost on a crash otherwise
+	Retention      RetentionPolicy // Retention applied on open and on every rotation
+}
+
+// Journal is an append-only bundle journal on local disk. Records are written as JSON lines prefixed with
+// their CRC-32 to a sequence of segment files, the last of which is the active one. On open, a record torn
+// by a crash is detected by its checksum and truncated from the active segment.
+//
+// Appends only wait for a query or a compaction when they rotate the active segment. The locks are
+// taken in the order appendMu, mu, indexMu.
+type Journal struct {
+	config     Config                   // Journal configuration
+	active     *os.File                 // Active segment
+	activeSeq  uint64                   // Sequence number of the active segment, changed with appendMu and mu held
+	activeSize int64                    // Size of the active segment
+	closed     bool                     // Whether the journal was closed, changed with appendMu and mu held
+	index      map[uint64]*segmentIndex // Bundles recorded in each segment, built lazily for closed segments
+	appendMu   sync.Mutex               // Mutex for synchronizing the writes to the active segment
+	mu         sync.Mutex               // Mutex for synchronizing the segment files
+	indexMu    sync.Mutex               // Mutex for synchronizing the index
+}
+
+// NewJournal opens the journal in the configured directory, recovering the active segment.
+func NewJournal(config Config) (*Journal, error) {
+	if config.Dir == "" {
+		return nil, errors.New("journal directory is required")
+	}
+	if config.MaxSegmentSize <= 0 {
+		config.MaxSegmentSize = DefaultMaxSegmentSize
+	}
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("could not create journal directory: %w", err)
+	}
+
+	j := &Journal{
+		config: config,
+		index:  make(map[uint64]*segmentIndex),
+	}
+	if err := j.removeTemp(); err != nil {
+		return nil, err
+	}
+
+	segments, err := j.segments()
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		if err = j.openSegment(1); err != nil {
+			return nil, err
+		}
+	} else {
+		if err = j.recover(segments[len(segments)-1]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = j.applyRetention(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Append writes a record to the active segment, rotating it once it reached the maximum segment size.
+// The record time is set to the current time if zero.
+func (j *Journal) Append(record *Record) error {
+	if record.Time.IsZero() {
+		record.Time = time.Now()
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("could not encode journal record: %w", err)
+	}
+	line := encodeLine(data)
+
+	j.appendMu.Lock()
+	defer j.appendMu.Unlock()
+
+	if j.closed {
+		return ErrJournalClosed
+	}
+	if j.activeSize > 0 && j.activeSize+int64(len(line)) > j.config.MaxSegmentSize {
+		j.mu.Lock()
+		err = j.rotate()
+		j.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Index the record before writing it, a query reading the segment meanwhile must not skip it
+	j.indexMu.Lock()
+	j.index[j.activeSeq].add(record)
+	j.indexMu.Unlock()
+
+	n, err := j.active.Write(line)
+	j.activeSize += int64(n)
+	if err != nil {
+		return fmt.Errorf("could not write journal record: %w", err)
+	}
+	if j.config.SyncWrites {
+		if err = j.active.Sync(); err != nil {
+			return fmt.Errorf("could not sync journal: %w", err)
+		}
+	}
+	return nil
+}
+
+// Sync flushes the active segment to disk.
+func (j *Journal) Sync() error {
+	j.appendMu.Lock()
+	defer j.appendMu.Unlock()
+
+	if j.closed {
+		return ErrJournalClosed
+	}
+	return j.active.Sync()
+}
+
+// Close flushes and closes the active segment.
+func (j *Journal) Close() error {
+	j.appendMu.Lock()
+	defer j.appendMu.Unlock()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.closed {
+		return nil
+	}
+	j.closed = true
+
+	if err := j.active.Sync(); err != nil {
+		j.active.Close()
+		return err
+	}
+	return j.active.Close()
+}
+
+// rotate closes the active segment, opens the next one and applies the retention policy.
+// It must be called with appendMu and mu held.
+func (j *Journal) rotate() error {
+	if err := j.active.Sync(); err != nil {
+		return fmt.Errorf("could not sync journal: %w", err)
+	}
+	if err := j.active.Close(); err != nil {
+		return err
+	}
+	if err := j.openSegment(j.activeSeq + 1); err != nil {
+		return err
+	}
+	return j.applyRetention()
+}
+
+// openSegment creates the segment with the sequence number and makes it the active one.
+func (j *Journal) openSegment(seq uint64) error {
+	file, err := os.OpenFile(j.segmentPath(seq), os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("could not create journal segment: %w", err)
+	}
+	if err = syncDir(j.config.Dir); err != nil {
+		file.Close()
+		return err
+	}
+
+	j.active = file
+	j.activeSeq = seq
+	j.activeSize = 0
+	j.setIndex(seq, newSegmentIndex())
+	return nil
+}
+
+// recover opens the last segment as the active one, truncating any record torn by a crash.
+func (j *Journal) recover(seq uint64) error {
+	path := j.segmentPath(seq)
+	index := newSegmentIndex()
+	valid, err := readSegment(path, func(record *Record) bool {
+		index.add(record)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("could not open journal segment: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if info.Size() > valid {
+		if err = file.Truncate(valid); err != nil {
+			file.Close()
+			return fmt.Errorf("could not truncate torn journal record: %w", err)
+		}
+		if err = file.Sync(); err != nil {
+			file.Close()
+			return err
+		}
+	}
+
+	j.active = file
+	j.activeSeq = seq
+	j.activeSize = valid
+	j.setIndex(seq, index)
+	return nil
+}
+
+// segments returns the sequence numbers of the segment files, oldest first.
+func (j *Journal) segments() ([]uint64, error) {
+	files, err := os.ReadDir(j.config.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not list journal segments: %w", err)
+	}
+
+	var segments []uint64
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, SegmentExtension) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, SegmentExtension), 10, 64)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, seq)
+	}
+
+	sort.Slice(segments, func(a, b int) bool { return segments[a] < segments[b] })
+	return segments, nil
+}
+
+// removeTemp removes the segments left by a compaction interrupted by a crash.
+func (j *Journal) removeTemp() error {
+	files, err := os.ReadDir(j.config.Dir)
+	if err != nil {
+		return fmt.Errorf("could not list journal segments: %w", err)
+	}
+
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), tempExtension) {
+			if err = os.Remove(filepath.Join(j.config.Dir, file.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// segmentPath returns the path of the segment with the sequence number.
+func (j *Journal) segmentPath(seq uint64) string {
+	return filepath.Join(j.config.Dir, fmt.Sprintf("%020d%s", seq, SegmentExtension))
+}
+
+// encodeLine encodes a record as a line holding its hex CRC-32 and its JSON.
+func encodeLine(data []byte) []byte {
+	line := make([]byte, 0, len(data)+10)
+	line = append(line, fmt.Sprintf("%08x ", crc32.ChecksumIEEE(data))...)
+	line = append(line, data...)
+	return append(line, '\n')
+}
+
+// decodeLine decodes a line, reporting false if it is torn or corrupted.
+func decodeLine(line []byte) (*Record, bool) {
+	if len(line) < 10 || line[8] != ' ' || line[len(line)-1] != '\n' {
+		return nil, false
+	}
+
+	checksum, err := strconv.ParseUint(string(line[:8]), 16, 32)
+	if err != nil {
+		return nil, false
+	}
+	data := line[9 : len(line)-1]
+	if crc32.ChecksumIEEE(data) != uint32(checksum) {
+		return nil, false
+	}
+
+	record := &Record{}
+	if err = json.Unmarshal(data, record); err != nil {
+		return nil, false
+	}
+	return record, true
+}
+
+// readSegment calls fn on every record of a segment until it returns false. Reading stops at the first
+// torn or corrupted record. It returns the size of the valid records read.
+func readSegment(path string, fn func(*Record) bool) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("could not open journal segment: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var valid int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			record, ok := decodeLine(line)
+			if !ok {
+				return valid, nil
+			}
+			valid += int64(len(line))
+			if !fn(record) {
+				return valid, nil
+			}
+		}
+		if err == io.EOF {
+			return valid, nil
+		}
+		if err != nil {
+			return valid, fmt.Errorf("could not read journal segment: %w", err)
+		}
+	}
+}
+
+// syncDir flushes a directory so that created, renamed and removed files survive a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	// Some platforms do not support syncing directories
+	if err = d.Sync(); err != nil && !errors.Is(err, os.ErrInvalid) {
+		return fmt.Errorf("could not sync journal directory: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+package journal_pkg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"testing"
+	"time"
+)
+
+// newTestJournal opens a journal in a temporary directory.
+func newTestJournal(t *testing.T, config Config) *Journal {
+	t.Helper()
+
+	if config.Dir == "" {
+		config.Dir = t.TempDir()
+	}
+	j, err := NewJournal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { j.Close() })
+	return j
+}
+
+// appendBundle journals a bundle sent at the time, with one result setting its outcome.
+func appendBundle(t *testing.T, j *Journal, uuid string, sentAt time.Time, outcome Outcome) {
+	t.Helper()
+
+	records := []*Record{
+		{Kind: RecordSent, Time: sentAt, UUID: uuid, Signatures: []string{"sig-" + uuid}},
+		{Kind: RecordResult, Time: sentAt.Add(time.Second), UUID: uuid, Result: &Result{State: "accepted"}, Outcome: outcome},
+	}
+	for _, record := range records {
+		if err := j.Append(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestEncodeLine(t *testing.T) {
+	data := []byte(`{"kind":"sent"}`)
+	line := encodeLine(data)
+
+	want := fmt.Sprintf("%08x %s\n", crc32.ChecksumIEEE(data), data)
+	if string(line) != want {
+		t.Fatalf("encodeLine() = %q, want %q", line, want)
+	}
+	record, ok := decodeLine(line)
+	if !ok || record.Kind != RecordSent {
+		t.Fatalf("decodeLine() = %+v, %v", record, ok)
+	}
+
+	corrupted := bytes.Replace(line, []byte("sent"), []byte("sant"), 1)
+	for name, line := range map[string][]byte{
+		"torn":        line[:len(line)-3],
+		"no newline":  line[:len(line)-1],
+		"corrupted":   corrupted,
+		"no checksum": append([]byte("zzzzzzzz "), data...),
+		"short":       []byte("00\n"),
+	} {
+		if _, ok := decodeLine(line); ok {
+			t.Errorf("%s line decoded", name)
+		}
+	}
+}
+
+func TestJournalRecoversTornTail(t *testing.T) {
+	dir := t.TempDir()
+	j := newTestJournal(t, Config{Dir: dir})
+	now := time.Now()
+	appendBundle(t, j, "first", now, OutcomeLanded)
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a crash in the middle of a write
+	path := j.segmentPath(1)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := encodeLine([]byte(`{"kind":"result","uuid":"first","outcome":"dropped"}`))
+	if _, err = file.Write(line[:len(line)/2]); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	j = newTestJournal(t, Config{Dir: dir})
+	if recovered, err := os.Stat(path); err != nil || recovered.Size() != info.Size() {
+		t.Fatalf("torn record not truncated: %v, %v", recovered, err)
+	}
+
+	appendBundle(t, j, "second", now.Add(time.Minute), OutcomeLanded)
+	entries, err := j.Query(Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].UUID != "first" || entries[0].Outcome != OutcomeLanded || entries[1].UUID != "second" {
+		t.Errorf("entries = %+v", entries)
+	}
+}
+
+func TestJournalQuery(t *testing.T) {
+	// Segments small enough to rotate on every bundle
+	dir := t.TempDir()
+	j := newTestJournal(t, Config{Dir: dir, MaxSegmentSize: 256})
+	start := time.Now().Truncate(time.Second)
+	outcomes := []Outcome{OutcomeLanded, OutcomeRejected, OutcomeDropped, OutcomeLanded}
+	for i, outcome := range outcomes {
+		appendBundle(t, j, fmt.Sprintf("bundle-%d", i), start.Add(time.Duration(i)*time.Minute), outcome)
+	}
+	// A later result of the first bundle, in the active segment
+	if err := j.Append(&Record{Kind: RecordConfirmed, UUID: "bundle-0", Outcome: OutcomeFailed, Error: "failed"}); err != nil {
+		t.Fatal(err)
+	}
+
+	uuids := func(entries []Entry) []string {
+		var uuids []string
+		for _, entry := range entries {
+			uuids = append(uuids, entry.UUID)
+		}
+		return uuids
+	}
+	tests := []struct {
+		name  string
+		query Query
+		want  []string
+	}{
+		{name: "all", query: Query{}, want: []string{"bundle-0", "bundle-1", "bundle-2", "bundle-3"}},
+		{name: "uuid", query: Query{UUID: "bundle-2"}, want: []string{"bundle-2"}},
+		{name: "signature", query: Query{Signature: "sig-bundle-1"}, want: []string{"bundle-1"}},
+		{name: "unknown signature", query: Query{Signature: "unknown"}, want: nil},
+		{name: "outcomes", query: Query{Outcomes: []Outcome{OutcomeRejected, OutcomeFailed}}, want: []string{"bundle-0", "bundle-1"}},
+		{name: "time range", query: Query{From: start.Add(time.Minute), To: start.Add(3 * time.Minute)}, want: []string{"bundle-1", "bundle-2"}},
+		{name: "limit", query: Query{Limit: 2}, want: []string{"bundle-0", "bundle-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := j.Query(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := uuids(entries); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Query() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Events of a bundle spread over segments are collapsed
+	entry, ok, err := j.Get("bundle-0")
+	if err != nil || !ok {
+		t.Fatalf("Get() = %v, %v", ok, err)
+	}
+	if entry.Outcome != OutcomeFailed || entry.Error != "failed" || len(entry.Results) != 1 || !entry.SentAt.Equal(start) {
+		t.Errorf("entry = %+v", entry)
+	}
+
+	// The index survives a reopen, rebuilt from the closed segments
+	j.Close()
+	j = newTestJournal(t, Config{Dir: dir, MaxSegmentSize: 256})
+	entries, err := j.GetBySignature("sig-bundle-0")
+	if err != nil || len(entries) != 1 || entries[0].Outcome != OutcomeFailed {
+		t.Errorf("GetBySignature() after reopen = %+v, %v", entries, err)
+	}
+}
+
+func TestJournalQuerySkipsSegments(t *testing.T) {
+	j := newTestJournal(t, Config{MaxSegmentSize: 256})
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		appendBundle(t, j, fmt.Sprintf("bundle-%d", i), start.Add(time.Duration(i)*time.Minute), OutcomeLanded)
+	}
+
+	segments, err := j.segments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) < 4 {
+		t.Fatalf("got %d segments, want one per bundle", len(segments))
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	// Only the segments holding a record of the bundle are read
+	var want []uint64
+	for _, seq := range segments {
+		if _, err = readSegment(j.segmentPath(seq), func(record *Record) bool {
+			if record.UUID == "bundle-1" {
+				want = append(want, seq)
+				return false
+			}
+			return true
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	selected, err := j.indexedSegments(segments, Query{UUID: "bundle-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(want) == 0 || len(want) == len(segments) || fmt.Sprint(selected) != fmt.Sprint(want) {
+		t.Errorf("query by UUID reads segments %v, want %v", selected, want)
+	}
+
+	selected, err = j.indexedSegments(segments, Query{Signature: "unknown"})
+	if err != nil || len(selected) != 0 {
+		t.Errorf("query by unknown signature reads %v, %v", selected, err)
+	}
+}
+
+func TestJournalCompact(t *testing.T) {
+	j := newTestJournal(t, Config{MaxSegmentSize: 256})
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		appendBundle(t, j, fmt.Sprintf("bundle-%d", i), start.Add(time.Duration(i)*time.Second), OutcomeLanded)
+	}
+	before, err := j.Query(Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = j.Compact(); err != nil {
+		t.Fatal(err)
+	}
+
+	closed, err := j.closedSegments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(closed) != 1 {
+		t.Errorf("got %d closed segments after compaction, want 1", len(closed))
+	}
+	after, err := j.Query(Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(after) != fmt.Sprint(before) {
+		t.Errorf("entries after compaction = %+v, want %+v", after, before)
+	}
+	if entries, err := j.GetBySignature("sig-bundle-0"); err != nil || len(entries) != 1 {
+		t.Errorf("GetBySignature() after compaction = %+v, %v", entries, err)
+	}
+
+	// Appends after a compaction are collapsed with the compacted records
+	if err = j.Append(&Record{Kind: RecordConfirmed, UUID: "bundle-0", Outcome: OutcomeFailed}); err != nil {
+		t.Fatal(err)
+	}
+	if entry, _, err := j.Get("bundle-0"); err != nil || entry.Outcome != OutcomeFailed || len(entry.Results) != 1 {
+		t.Errorf("Get() = %+v, %v", entry, err)
+	}
+}
+
+func TestJournalCompactDropsExpiredBundles(t *testing.T) {
+	j := newTestJournal(t, Config{MaxSegmentSize: 256, Retention: RetentionPolicy{MaxAge: time.Hour}})
+	appendBundle(t, j, "old", time.Now().Add(-2*time.Hour), OutcomeLanded)
+	appendBundle(t, j, "recent", time.Now(), OutcomeLanded)
+	appendBundle(t, j, "active", time.Now(), OutcomeLanded)
+
+	if err := j.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := j.Query(Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].UUID != "recent" || entries[1].UUID != "active" {
+		t.Errorf("entries = %+v", entries)
+	}
+	if _, ok, _ := j.Get("old"); ok {
+		t.Error("expired bundle kept by compaction")
+	}
+}
+
+func TestJournalClosed(t *testing.T) {
+	j := newTestJournal(t, Config{})
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := j.Append(&Record{Kind: RecordSent, UUID: "uuid"}); !errors.Is(err, ErrJournalClosed) {
+		t.Errorf("Append() = %v, want ErrJournalClosed", err)
+	}
+	if _, err := j.Query(Query{}); !errors.Is(err, ErrJournalClosed) {
+		t.Errorf("Query() = %v, want ErrJournalClosed", err)
+	}
+	if err := j.Compact(); !errors.Is(err, ErrJournalClosed) {
+		t.Errorf("Compact() = %v, want ErrJournalClosed", err)
+	}
+}
@@ -0,0 +1,243 @@
+package journal_pkg
+
+import (
+	"sort"
+	"time"
+)
+
+// Query selects journaled bundles. Empty fields match every bundle.
+type Query struct {
+	From      time.Time // Earliest send time, inclusive
+	To        time.Time // Latest send time, exclusive
+	UUID      string    // Bundle UUID
+	Signature string    // Signature of any of the bundle transactions
+	Outcomes  []Outcome // Outcomes of the bundle
+	Limit     int       // Maximum number of bundles returned, 0 for no limit
+}
+
+// Query returns the journaled bundles matching the query, oldest first.
+// Bundles without a send time are filtered and ordered by the time of their last event.
+func (j *Journal) Query(q Query) ([]Entry, error) {
+	entries, err := j.entries(q)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]Entry, 0)
+	for _, entry := range entries {
+		if q.matches(entry) {
+			matches = append(matches, *entry)
+		}
+	}
+
+	sort.SliceStable(matches, func(a, b int) bool { return matches[a].time().Before(matches[b].time()) })
+	if q.Limit > 0 && len(matches) > q.Limit {
+		matches = matches[:q.Limit]
+	}
+	return matches, nil
+}
+
+// Get returns the journaled bundle with the UUID.
+func (j *Journal) Get(uuid string) (Entry, bool, error) {
+	entries, err := j.Query(Query{UUID: uuid})
+	if err != nil || len(entries) == 0 {
+		return Entry{}, false, err
+	}
+	return entries[0], true, nil
+}
+
+// GetBySignature returns the journaled bundles containing the transaction signature, oldest first.
+func (j *Journal) GetBySignature(signature string) ([]Entry, error) {
+	return j.Query(Query{Signature: signature})
+}
+
+// segmentIndex lists the bundles recorded in a segment, so that queries by UUID or signature only read
+// the segments holding the bundles they select.
+type segmentIndex struct {
+	uuids      map[string]struct{} // UUIDs of the bundles with a record in the segment
+	signatures map[string][]string // UUIDs of the bundles by transaction signature
+}
+
+func newSegmentIndex() *segmentIndex {
+	return &segmentIndex{
+		uuids:      make(map[string]struct{}),
+		signatures: make(map[string][]string),
+	}
+}
+
+// add indexes a record.
+func (x *segmentIndex) add(record *Record) {
+	uuid, signatures := record.UUID, record.Signatures
+	if record.Kind == RecordCompacted && record.Entry != nil {
+		uuid, signatures = record.Entry.UUID, record.Entry.Signatures
+	}
+
+	x.uuids[uuid] = struct{}{}
+	for _, signature := range signatures {
+		if !containsString(x.signatures[signature], uuid) {
+			x.signatures[signature] = append(x.signatures[signature], uuid)
+		}
+	}
+}
+
+// entries collapses the records of each bundle the query may select, in the order bundles were first seen.
+// Queries by UUID or signature only read the segments the index lists the bundles in.
+func (j *Journal) entries(q Query) ([]*Entry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.closed {
+		return nil, ErrJournalClosed
+	}
+
+	segments, err := j.segments()
+	if err != nil {
+		return nil, err
+	}
+	if q.UUID != "" || q.Signature != "" {
+		if segments, err = j.indexedSegments(segments, q); err != nil {
+			return nil, err
+		}
+	}
+	return collapse(j, segments)
+}
+
+// indexedSegments returns the segments holding a record of the bundles with the query UUID or signature.
+// It must be called with mu held.
+func (j *Journal) indexedSegments(segments []uint64, q Query) ([]uint64, error) {
+	indexes := make([]*segmentIndex, len(segments))
+	for i, seq := range segments {
+		index, err := j.segmentIndex(seq)
+		if err != nil {
+			return nil, err
+		}
+		indexes[i] = index
+	}
+
+	j.indexMu.Lock()
+	defer j.indexMu.Unlock()
+
+	var uuids []string
+	if q.UUID != "" {
+		uuids = []string{q.UUID}
+	} else {
+		for _, index := range indexes {
+			for _, uuid := range index.signatures[q.Signature] {
+				if !containsString(uuids, uuid) {
+					uuids = append(uuids, uuid)
+				}
+			}
+		}
+	}
+
+	var selected []uint64
+	for i, seq := range segments {
+		for _, uuid := range uuids {
+			if _, ok := indexes[i].uuids[uuid]; ok {
+				selected = append(selected, seq)
+				break
+			}
+		}
+	}
+	return selected, nil
+}
+
+// segmentIndex returns the index of a segment, reading the segment if it was not indexed yet.
+// It must be called with mu held.
+func (j *Journal) segmentIndex(seq uint64) (*segmentIndex, error) {
+	j.indexMu.Lock()
+	index, ok := j.index[seq]
+	j.indexMu.Unlock()
+	if ok {
+		return index, nil
+	}
+
+	// Only closed segments are missing from the index, they do not change while mu is held
+	index = newSegmentIndex()
+	_, err := readSegment(j.segmentPath(seq), func(record *Record) bool {
+		index.add(record)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	j.setIndex(seq, index)
+	return index, nil
+}
+
+// setIndex sets the index of a segment, removing it if nil.
+func (j *Journal) setIndex(seq uint64, index *segmentIndex) {
+	j.indexMu.Lock()
+	defer j.indexMu.Unlock()
+
+	if index == nil {
+		delete(j.index, seq)
+		return
+	}
+	j.index[seq] = index
+}
+
+// collapse reads the segments in order and collapses the records of each bundle.
+func collapse(j *Journal, segments []uint64) ([]*Entry, error) {
+	var entries []*Entry
+	byUUID := make(map[string]*Entry)
+	for _, seq := range segments {
+		_, err := readSegment(j.segmentPath(seq), func(record *Record) bool {
+			uuid := record.UUID
+			if record.Kind == RecordCompacted && record.Entry != nil {
+				uuid = record.Entry.UUID
+			}
+
+			entry, ok := byUUID[uuid]
+			if !ok {
+				entry = &Entry{}
+				byUUID[uuid] = entry
+				entries = append(entries, entry)
+			}
+			entry.apply(record)
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
+
+// containsString reports whether the values contain s.
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether the bundle matches the query.
+func (q *Query) matches(entry *Entry) bool {
+	t := entry.time()
+	if !q.From.IsZero() && t.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !t.Before(q.To) {
+		return false
+	}
+	if q.UUID != "" && entry.UUID != q.UUID {
+		return false
+	}
+	if q.Signature != "" && !entry.hasSignature(q.Signature) {
+		return false
+	}
+	if len(q.Outcomes) > 0 {
+		for _, outcome := range q.Outcomes {
+			if entry.Outcome == outcome {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
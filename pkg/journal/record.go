@@ -0,0 +1,198 @@
+package journal_pkg
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+// RecordKind is the kind of event a journal record holds.
+type RecordKind string
+
+// Journal record kinds
+const (
+	RecordSent      RecordKind = "sent"      // Bundle sent to the block engine
+	RecordResult    RecordKind = "result"    // Bundle result received from the block engine
+	RecordConfirmed RecordKind = "confirmed" // Confirmation of the bundle transactions ended
+	RecordCompacted RecordKind = "compacted" // Every event of a bundle collapsed by compaction
+)
+
+// Outcome is the outcome of a journaled bundle.
+type Outcome string
+
+// Bundle outcomes
+const (
+	OutcomePending  Outcome = "pending"  // No outcome known yet
+	OutcomeLanded   Outcome = "landed"   // Bundle transactions landed
+	OutcomeFailed   Outcome = "failed"   // A bundle transaction landed with an error
+	OutcomeRejected Outcome = "rejected" // Bundle rejected by the block engine
+	OutcomeDropped  Outcome = "dropped"  // Bundle dropped after being accepted
+	OutcomeExpired  Outcome = "expired"  // Bundle blockhash expired before it landed
+)
+
+// Result is a bundle result reported by the block engine.
+type Result struct {
+	State     string    `json:"state"`               // accepted, rejected, processed, finalized or dropped
+	Slot      uint64    `json:"slot,omitempty"`      // Slot reported with the result, if any
+	Validator string    `json:"validator,omitempty"` // Identity of the validator, if any
+	Reason    string    `json:"reason,omitempty"`    // Reason of a rejection or a drop
+	At        time.Time `json:"at"`                  // Time the result was received
+}
+
+// SignatureStatus is the status of a bundle transaction signature.
+type SignatureStatus struct {
+	Signature          string `json:"signature"`                     // Transaction signature
+	Slot               uint64 `json:"slot,omitempty"`                // Slot the transaction landed in
+	ConfirmationStatus string `json:"confirmation_status,omitempty"` // processed, confirmed or finalized, empty if unknown
+	Err                string `json:"err,omitempty"`                 // Transaction error, if any
+}
+
+// Record is a single event appended to the journal.
+type Record struct {
+	Kind         RecordKind        `json:"kind"`                   // Kind of event
+	Time         time.Time         `json:"time"`                   // Time of the event
+	UUID         string            `json:"uuid"`                   // Bundle UUID
+	BundleID     string            `json:"bundle_id,omitempty"`    // Bundle ID derived from the signatures
+	Region       string            `json:"region,omitempty"`       // Location code of the block engine
+	TipLamports  uint64            `json:"tip_lamports,omitempty"` // Tip paid by the bundle
+	Transactions []string          `json:"transactions,omitempty"` // Base64 encoded bundle transactions
+	Signatures   []string          `json:"signatures,omitempty"`   // Bundle transaction signatures
+	Result       *Result           `json:"result,omitempty"`       // Bundle result of a result record
+	Statuses     []SignatureStatus `json:"statuses,omitempty"`     // Final signature statuses of a confirmed record
+	Outcome      Outcome           `json:"outcome,omitempty"`      // Outcome set by the event, if any
+	Error        string            `json:"error,omitempty"`        // Error reported with the event, if any
+	Entry        *Entry            `json:"entry,omitempty"`        // Collapsed bundle of a compacted record
+}
+
+// Entry is a journaled bundle with every event recorded for it.
+type Entry struct {
+	UUID         string            `json:"uuid"`                   // Bundle UUID
+	BundleID     string            `json:"bundle_id,omitempty"`    // Bundle ID derived from the signatures
+	Region       string            `json:"region,omitempty"`       // Location code of the block engine
+	TipLamports  uint64            `json:"tip_lamports,omitempty"` // Tip paid by the bundle
+	Transactions []string          `json:"transactions,omitempty"` // Base64 encoded bundle transactions
+	Signatures   []string          `json:"signatures,omitempty"`   // Bundle transaction signatures
+	SentAt       time.Time         `json:"sent_at"`                // Time the bundle was sent, zero if not sent by this client
+	UpdatedAt    time.Time         `json:"updated_at"`             // Time of the last event
+	Results      []Result          `json:"results,omitempty"`      // Bundle results, oldest first
+	Statuses     []SignatureStatus `json:"statuses,omitempty"`     // Final signature statuses
+	Outcome      Outcome           `json:"outcome"`                // Outcome of the bundle
+	Error        string            `json:"error,omitempty"`        // Last error reported for the bundle
+}
+
+// NewSentRecord creates the record of a bundle sent to the block engine, with its raw transactions.
+func NewSentRecord(
+	uuid string,
+	bundleID string,
+	region string,
+	tipLamports uint64,
+	transactions []*solana.Transaction,
+	sentAt time.Time,
+) (*Record, error) {
+	record := &Record{
+		Kind:        RecordSent,
+		Time:        sentAt,
+		UUID:        uuid,
+		BundleID:    bundleID,
+		Region:      region,
+		TipLamports: tipLamports,
+	}
+
+	for i, tx := range transactions {
+		data, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("could not encode transaction %d: %w", i, err)
+		}
+		record.Transactions = append(record.Transactions, base64.StdEncoding.EncodeToString(data))
+		if len(tx.Signatures) > 0 {
+			record.Signatures = append(record.Signatures, tx.Signatures[0].String())
+		}
+	}
+
+	return record, nil
+}
+
+// DecodeTransactions decodes the raw transactions of the bundle.
+func (e *Entry) DecodeTransactions() ([]*solana.Transaction, error) {
+	transactions := make([]*solana.Transaction, 0, len(e.Transactions))
+	for i, encoded := range e.Transactions {
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode transaction %d: %w", i, err)
+		}
+
+		tx := &solana.Transaction{}
+		if err = tx.UnmarshalWithDecoder(bin.NewBorshDecoder(data)); err != nil {
+			return nil, fmt.Errorf("could not decode transaction %d: %w", i, err)
+		}
+		transactions = append(transactions, tx)
+	}
+
+	return transactions, nil
+}
+
+// apply records an event on the entry. A compacted record replaces the entry as it holds every earlier event.
+func (e *Entry) apply(record *Record) {
+	if record.Kind == RecordCompacted {
+		if record.Entry != nil {
+			*e = *record.Entry
+		}
+		return
+	}
+
+	if e.UUID == "" {
+		e.UUID = record.UUID
+	}
+	if record.Time.After(e.UpdatedAt) {
+		e.UpdatedAt = record.Time
+	}
+	if e.Outcome == "" {
+		e.Outcome = OutcomePending
+	}
+
+	switch record.Kind {
+	case RecordSent:
+		e.BundleID = record.BundleID
+		e.Region = record.Region
+		e.TipLamports = record.TipLamports
+		e.Transactions = record.Transactions
+		e.Signatures = record.Signatures
+		e.SentAt = record.Time
+	case RecordResult:
+		if record.Result != nil {
+			e.Results = append(e.Results, *record.Result)
+		}
+	case RecordConfirmed:
+		if len(record.Statuses) > 0 {
+			e.Statuses = record.Statuses
+		}
+	}
+
+	if record.Outcome != "" {
+		e.Outcome = record.Outcome
+	}
+	if record.Error != "" {
+		e.Error = record.Error
+	}
+}
+
+// hasSignature reports whether the bundle contains a transaction with the signature.
+func (e *Entry) hasSignature(signature string) bool {
+	for _, s := range e.Signatures {
+		if s == signature {
+			return true
+		}
+	}
+	return false
+}
+
+// time returns the time the entry is ordered and filtered by, its send time if known.
+func (e *Entry) time() time.Time {
+	if !e.SentAt.IsZero() {
+		return e.SentAt
+	}
+	return e.UpdatedAt
+}
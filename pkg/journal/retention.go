@@ -0,0 +1,169 @@
+package journal_pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// Compact collapses the events of each bundle in the closed segments into a single compacted record,
+// dropping the bundles whose last event is older than the retention MaxAge. The closed segments are
+// replaced by one segment written atomically, the active segment is left untouched.
+func (j *Journal) Compact() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.closed {
+		return ErrJournalClosed
+	}
+
+	segments, err := j.closedSegments()
+	if err != nil || len(segments) == 0 {
+		return err
+	}
+
+	entries, err := collapse(j, segments)
+	if err != nil {
+		return err
+	}
+
+	var cutoff time.Time
+	if j.config.Retention.MaxAge > 0 {
+		cutoff = time.Now().Add(-j.config.Retention.MaxAge)
+	}
+	sort.SliceStable(entries, func(a, b int) bool { return entries[a].time().Before(entries[b].time()) })
+
+	// Write the compacted segment aside, then atomically replace the newest closed segment with it.
+	// Older segments left behind by a crash are harmless, a compacted record replaces their events.
+	last := segments[len(segments)-1]
+	tempPath := j.segmentPath(last) + tempExtension
+	if err = writeCompacted(tempPath, entries, cutoff); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	if err = os.Rename(tempPath, j.segmentPath(last)); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("could not replace journal segment: %w", err)
+	}
+	j.setIndex(last, nil)
+	for _, seq := range segments[:len(segments)-1] {
+		if err = os.Remove(j.segmentPath(seq)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("could not remove compacted journal segment: %w", err)
+		}
+		j.setIndex(seq, nil)
+	}
+
+	return syncDir(j.config.Dir)
+}
+
+// ApplyRetention deletes the closed segments older than the retention MaxAge, then the oldest closed
+// segments until the journal fits the retention MaxSize.
+func (j *Journal) ApplyRetention() error {
+	j.appendMu.Lock()
+	defer j.appendMu.Unlock()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.closed {
+		return ErrJournalClosed
+	}
+	return j.applyRetention()
+}
+
+// applyRetention applies the retention policy. It must be called with appendMu and mu held.
+func (j *Journal) applyRetention() error {
+	policy := j.config.Retention
+	if policy.MaxAge <= 0 && policy.MaxSize <= 0 {
+		return nil
+	}
+
+	segments, err := j.closedSegments()
+	if err != nil {
+		return err
+	}
+
+	size := j.activeSize
+	sizes := make([]int64, len(segments))
+	modTimes := make([]time.Time, len(segments))
+	for i, seq := range segments {
+		info, err := os.Stat(j.segmentPath(seq))
+		if err != nil {
+			return fmt.Errorf("could not stat journal segment: %w", err)
+		}
+		sizes[i] = info.Size()
+		modTimes[i] = info.ModTime()
+		size += info.Size()
+	}
+
+	removed := false
+	for i, seq := range segments {
+		expired := policy.MaxAge > 0 && time.Since(modTimes[i]) > policy.MaxAge
+		oversized := policy.MaxSize > 0 && size > policy.MaxSize
+		if !expired && !oversized {
+			break
+		}
+
+		if err = os.Remove(j.segmentPath(seq)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("could not remove journal segment: %w", err)
+		}
+		j.setIndex(seq, nil)
+		size -= sizes[i]
+		removed = true
+	}
+
+	if removed {
+		return syncDir(j.config.Dir)
+	}
+	return nil
+}
+
+// closedSegments returns the sequence numbers of the segments before the active one, oldest first.
+func (j *Journal) closedSegments() ([]uint64, error) {
+	segments, err := j.segments()
+	if err != nil {
+		return nil, err
+	}
+
+	closed := segments[:0]
+	for _, seq := range segments {
+		if seq < j.activeSeq {
+			closed = append(closed, seq)
+		}
+	}
+	return closed, nil
+}
+
+// writeCompacted writes a segment holding a compacted record per bundle updated after the cutoff.
+func writeCompacted(path string, entries []*Entry, cutoff time.Time) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("could not create compacted journal segment: %w", err)
+	}
+	defer file.Close()
+
+	for _, entry := range entries {
+		if !cutoff.IsZero() && entry.UpdatedAt.Before(cutoff) {
+			continue
+		}
+
+		data, err := json.Marshal(&Record{
+			Kind:  RecordCompacted,
+			Time:  entry.UpdatedAt,
+			UUID:  entry.UUID,
+			Entry: entry,
+		})
+		if err != nil {
+			return fmt.Errorf("could not encode compacted journal record: %w", err)
+		}
+		if _, err = file.Write(encodeLine(data)); err != nil {
+			return fmt.Errorf("could not write compacted journal record: %w", err)
+		}
+	}
+
+	if err = file.Sync(); err != nil {
+		return fmt.Errorf("could not sync compacted journal segment: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,189 @@
+package pkg
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/gagliardetto/solana-go"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Constants for encrypted keystore files
+const (
+	KeystoreVersion = 1         // Version of the keystore format
+	keystoreKDF     = "scrypt"  // Key derivation function of the passphrase
+	keystoreCipher  = "aes-gcm" // Cipher of the private key
+	keystoreScryptN = 1 << 15   // scrypt CPU/memory cost, as recommended by golang.org/x/crypto/scrypt
+	keystoreScryptR = 8         // scrypt block size
+	keystoreScryptP = 1         // scrypt parallelization
+	keystoreKeyLen  = 32        // Length of the derived AES-256 key
+	keystoreSaltLen = 32        // Length of the scrypt salt
+	keystoreMaxN    = 1 << 20   // Maximum scrypt CPU/memory cost accepted from a keystore file
+	keystoreMaxR    = 32        // Maximum scrypt block size accepted from a keystore file
+	keystoreMaxP    = 16        // Maximum scrypt parallelization accepted from a keystore file
+	keystoreMaxMem  = 1 << 30   // Maximum scrypt memory, 128*N*R bytes, accepted from a keystore file
+)
+
+// ErrKeystorePassphrase is returned when a keystore cannot be decrypted with the passphrase.
+var ErrKeystorePassphrase = errors.New("could not decrypt keystore, wrong passphrase or corrupted file")
+
+// Keystore is the JSON format of an encrypted keystore file. The private key is encrypted with AES-256-GCM
+// under a key derived from the passphrase with scrypt.
+type Keystore struct {
+	Version    int            `json:"version"`    // Version of the keystore format
+	PublicKey  string         `json:"public_key"` // Base58 public key of the encrypted private key
+	KDF        string         `json:"kdf"`        // Key derivation function, scrypt
+	KDFParams  KeystoreScrypt `json:"kdf_params"` // Parameters of the key derivation
+	Cipher     string         `json:"cipher"`     // Cipher of the private key, aes-gcm
+	Nonce      []byte         `json:"nonce"`      // GCM nonce
+	Ciphertext []byte         `json:"ciphertext"` // Encrypted private key, authenticated with the public key
+}
+
+// KeystoreScrypt holds the scrypt parameters of a keystore.
+type KeystoreScrypt struct {
+	N    int    `json:"n"`    // CPU/memory cost
+	R    int    `json:"r"`    // Block size
+	P    int    `json:"p"`    // Parallelization
+	Salt []byte `json:"salt"` // Random salt
+}
+
+// EncryptKeystore encrypts the private key with the passphrase.
+func EncryptKeystore(key solana.PrivateKey, passphrase []byte) (*Keystore, error) {
+	salt := make([]byte, keystoreSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	ks := &Keystore{
+		Version:   KeystoreVersion,
+		PublicKey: key.PublicKey().String(),
+		KDF:       keystoreKDF,
+		KDFParams: KeystoreScrypt{
+			N:    keystoreScryptN,
+			R:    keystoreScryptR,
+			P:    keystoreScryptP,
+			Salt: salt,
+		},
+		Cipher: keystoreCipher,
+	}
+
+	aead, err := ks.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	ks.Nonce = make([]byte, aead.NonceSize())
+	if _, err = rand.Read(ks.Nonce); err != nil {
+		return nil, err
+	}
+	ks.Ciphertext = aead.Seal(nil, ks.Nonce, key, []byte(ks.PublicKey))
+
+	return ks, nil
+}
+
+// Decrypt decrypts the private key of the keystore with the passphrase.
+// It returns ErrKeystorePassphrase if the passphrase is wrong or the keystore was tampered with.
+func (ks *Keystore) Decrypt(passphrase []byte) (solana.PrivateKey, error) {
+	if ks.Version != KeystoreVersion {
+		return nil, fmt.Errorf("unsupported keystore version %d", ks.Version)
+	}
+	if ks.KDF != keystoreKDF || ks.Cipher != keystoreCipher {
+		return nil, fmt.Errorf("unsupported keystore kdf %s or cipher %s", ks.KDF, ks.Cipher)
+	}
+
+	if err := ks.KDFParams.validate(); err != nil {
+		return nil, err
+	}
+
+	aead, err := ks.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if len(ks.Nonce) != aead.NonceSize() {
+		return nil, errors.New("keystore nonce has an invalid length")
+	}
+
+	plaintext, err := aead.Open(nil, ks.Nonce, ks.Ciphertext, []byte(ks.PublicKey))
+	if err != nil {
+		return nil, ErrKeystorePassphrase
+	}
+
+	key := solana.PrivateKey(plaintext)
+	if key.PublicKey().String() != ks.PublicKey {
+		return nil, ErrKeystorePassphrase
+	}
+	return key, nil
+}
+
+// validate checks the scrypt parameters read from a keystore file are within bounds, so a crafted file
+// cannot make the key derivation exhaust memory or CPU.
+func (p *KeystoreScrypt) validate() error {
+	if p.N < 2 || p.N > keystoreMaxN || p.R < 1 || p.R > keystoreMaxR || p.P < 1 || p.P > keystoreMaxP ||
+		128*int64(p.N)*int64(p.R) > keystoreMaxMem {
+		return fmt.Errorf("unsupported keystore scrypt parameters n=%d r=%d p=%d", p.N, p.R, p.P)
+	}
+	return nil
+}
+
+// aead derives the key of the keystore from the passphrase and returns its AES-GCM cipher.
+func (ks *Keystore) aead(passphrase []byte) (cipher.AEAD, error) {
+	derived, err := scrypt.Key(passphrase, ks.KDFParams.Salt, ks.KDFParams.N, ks.KDFParams.R, ks.KDFParams.P, keystoreKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("could not derive keystore key: %w", err)
+	}
+
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// WriteKeystoreFile encrypts the private key with the passphrase and writes the keystore to the file,
+// readable by the owner only.
+func WriteKeystoreFile(path string, key solana.PrivateKey, passphrase []byte) error {
+	ks, err := EncryptKeystore(key, passphrase)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(ks, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// ReadKeystoreFile reads an encrypted keystore file.
+func ReadKeystoreFile(path string) (*Keystore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ks := &Keystore{}
+	if err = json.Unmarshal(data, ks); err != nil {
+		return nil, fmt.Errorf("invalid keystore file %s: %w", path, err)
+	}
+	return ks, nil
+}
+
+// NewKeystoreSigner creates a Signer from an encrypted keystore file, decrypted with the passphrase.
+// The decrypted key is held in process memory.
+func NewKeystoreSigner(path string, passphrase []byte) (*MemorySigner, error) {
+	ks, err := ReadKeystoreFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ks.Decrypt(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return NewMemorySigner(key), nil
+}
@@ -0,0 +1,90 @@
+package pkg
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeystore(t *testing.T) {
+	key := newTestKey(t)
+	passphrase := []byte("correct horse battery staple")
+
+	ks, err := EncryptKeystore(key, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ks.PublicKey != key.PublicKey().String() {
+		t.Errorf("keystore public key = %s, want %s", ks.PublicKey, key.PublicKey())
+	}
+
+	decrypted, err := ks.Decrypt(passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted.String() != key.String() {
+		t.Error("decrypted key differs from the encrypted key")
+	}
+
+	if _, err = ks.Decrypt([]byte("wrong passphrase")); !errors.Is(err, ErrKeystorePassphrase) {
+		t.Errorf("Decrypt() with a wrong passphrase = %v, want %v", err, ErrKeystorePassphrase)
+	}
+
+	// The public key is authenticated with the ciphertext
+	tampered := *ks
+	tampered.PublicKey = newTestKey(t).PublicKey().String()
+	if _, err = tampered.Decrypt(passphrase); !errors.Is(err, ErrKeystorePassphrase) {
+		t.Errorf("Decrypt() of a tampered keystore = %v, want %v", err, ErrKeystorePassphrase)
+	}
+}
+
+func TestKeystoreScryptBounds(t *testing.T) {
+	ks, err := EncryptKeystore(newTestKey(t), []byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		n, r, p int
+	}{
+		{name: "cost too high", n: 1 << 30, r: 8, p: 1},
+		{name: "cost too low", n: 1, r: 8, p: 1},
+		{name: "block size too high", n: 1 << 10, r: 1 << 20, p: 1},
+		{name: "block size too low", n: 1 << 15, r: 0, p: 1},
+		{name: "parallelization too high", n: 1 << 15, r: 8, p: 1 << 20},
+		{name: "parallelization too low", n: 1 << 15, r: 8, p: 0},
+		{name: "memory too high", n: 1 << 20, r: 32, p: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			crafted := *ks
+			crafted.KDFParams.N, crafted.KDFParams.R, crafted.KDFParams.P = tt.n, tt.r, tt.p
+
+			_, err := crafted.Decrypt([]byte("passphrase"))
+			if err == nil || errors.Is(err, ErrKeystorePassphrase) {
+				t.Errorf("Decrypt() = %v, want the parameters rejected", err)
+			}
+		})
+	}
+}
+
+func TestKeystoreSigner(t *testing.T) {
+	key := newTestKey(t)
+	path := filepath.Join(t.TempDir(), "auth.keystore")
+	passphrase := []byte("passphrase")
+
+	if err := WriteKeystoreFile(path, key, passphrase); err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := NewKeystoreSigner(path, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSigner(t, signer, key)
+
+	if _, err = NewKeystoreSigner(path, []byte("wrong")); !errors.Is(err, ErrKeystorePassphrase) {
+		t.Errorf("NewKeystoreSigner() with a wrong passphrase = %v, want %v", err, ErrKeystorePassphrase)
+	}
+}
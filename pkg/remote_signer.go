@@ -0,0 +1,147 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// Constants for the remote signer HTTP API
+const (
+	RemoteSignerTimeout   = 10 * time.Second // Timeout of the requests of the default HTTP client
+	RemoteSignerPublicKey = "/public-key"    // Path returning the public key of the remote signer
+	RemoteSignerSign      = "/sign"          // Path signing a message
+	remoteSignerMaxBody   = 1 << 20          // Maximum size of a request or response body
+)
+
+// ErrRemoteSignature is returned when the remote signer returns a signature not matching its public key.
+var ErrRemoteSignature = errors.New("remote signer returned an invalid signature")
+
+// remoteSignerPublicKeyResponse is the response of the public key path.
+type remoteSignerPublicKeyResponse struct {
+	PublicKey string `json:"public_key"` // Base58 public key
+}
+
+// remoteSignerSignRequest is the request of the sign path.
+type remoteSignerSignRequest struct {
+	Message []byte `json:"message"` // Message to sign, base64 in JSON
+}
+
+// remoteSignerSignResponse is the response of the sign path.
+type remoteSignerSignResponse struct {
+	Signature string `json:"signature"` // Base58 signature
+}
+
+// RemoteSigner is a Signer whose key is held by a remote signer served over HTTP.
+// GET /public-key returns {"public_key": "<base58>"} and POST /sign with {"message": "<base64>"}
+// returns {"signature": "<base58>"}. signertest.NewRemoteSignerHandler serves this API in tests.
+type RemoteSigner struct {
+	URL        string           // Base URL of the remote signer
+	HTTPClient *http.Client     // HTTP client for the remote signer
+	Header     http.Header      // Headers sent with every request, e.g. an authorization token
+	publicKey  solana.PublicKey // Public key of the remote signer
+}
+
+// NewRemoteSigner creates a RemoteSigner for the remote signer at the base URL and fetches its public key.
+// The HTTP client defaults to one with RemoteSignerTimeout if nil.
+func NewRemoteSigner(ctx context.Context, url string, httpClient *http.Client, header http.Header) (*RemoteSigner, error) {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: RemoteSignerTimeout}
+	}
+
+	s := &RemoteSigner{
+		URL:        strings.TrimSuffix(url, "/"),
+		HTTPClient: httpClient,
+		Header:     header,
+	}
+
+	var resp remoteSignerPublicKeyResponse
+	if err := s.do(ctx, http.MethodGet, RemoteSignerPublicKey, nil, &resp); err != nil {
+		return nil, fmt.Errorf("could not get remote signer public key: %w", err)
+	}
+
+	publicKey, err := solana.PublicKeyFromBase58(resp.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote signer public key: %w", err)
+	}
+	s.publicKey = publicKey
+
+	return s, nil
+}
+
+// PublicKey returns the public key of the remote signer.
+func (s *RemoteSigner) PublicKey() solana.PublicKey {
+	return s.publicKey
+}
+
+// Sign signs the message with the remote signer, bounded by the timeout of the HTTP client.
+func (s *RemoteSigner) Sign(message []byte) (solana.Signature, error) {
+	return s.SignContext(context.Background(), message)
+}
+
+// SignContext signs the message with the remote signer.
+// The returned signature is verified against the public key of the remote signer.
+func (s *RemoteSigner) SignContext(ctx context.Context, message []byte) (solana.Signature, error) {
+	var resp remoteSignerSignResponse
+	if err := s.do(ctx, http.MethodPost, RemoteSignerSign, &remoteSignerSignRequest{Message: message}, &resp); err != nil {
+		return solana.Signature{}, fmt.Errorf("could not sign with remote signer: %w", err)
+	}
+
+	signature, err := solana.SignatureFromBase58(resp.Signature)
+	if err != nil {
+		return solana.Signature{}, fmt.Errorf("%w: %v", ErrRemoteSignature, err)
+	}
+	if !signature.Verify(s.publicKey, message) {
+		return solana.Signature{}, ErrRemoteSignature
+	}
+	return signature, nil
+}
+
+// do sends a request to the remote signer and decodes its JSON response.
+func (s *RemoteSigner) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.URL+path, reader)
+	if err != nil {
+		return err
+	}
+	for key, values := range s.Header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, remoteSignerMaxBody))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("remote signer returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	return json.Unmarshal(data, out)
+}
@@ -2,10 +2,18 @@ package pkg
 
 import (
 	"errors"
+	"fmt"
 
+	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
 )
 
+// Errors returned by ValidateSignatureStatusesCommitment
+var (
+	ErrSignatureStatusPending = errors.New("signature status has not reached the target commitment")
+	ErrTransactionFailed      = errors.New("transaction failed")
+)
+
 // CheckSignatureStatuses verifies if all signatures in the given status result are non-nil.
 // It returns true if all statuses are non-nil, otherwise returns false.
 func CheckSignatureStatuses(statuses *rpc.GetSignatureStatusesResult) bool {
@@ -28,3 +36,68 @@ func ValidateSignatureStatuses(statuses *rpc.GetSignatureStatusesResult) error {
 	}
 	return nil
 }
+
+// ValidateSignatureStatusesCommitment checks that every signature status reached the given commitment,
+// where finalized satisfies confirmed and confirmed satisfies processed. It returns an error wrapping
+// ErrTransactionFailed if failOnTransactionError is set and a status carries a transaction error,
+// or ErrSignatureStatusPending if a status is missing or below the commitment.
+func ValidateSignatureStatusesCommitment(
+	statuses *rpc.GetSignatureStatusesResult,
+	commitment rpc.ConfirmationStatusType,
+	failOnTransactionError bool,
+) error {
+	if failOnTransactionError {
+		for i, status := range statuses.Value {
+			if status != nil && status.Err != nil {
+				return fmt.Errorf("%w: signature %d: %v", ErrTransactionFailed, i, status.Err)
+			}
+		}
+	}
+
+	for i, status := range statuses.Value {
+		if status == nil || confirmationStatusRank(status.ConfirmationStatus) < confirmationStatusRank(commitment) {
+			return fmt.Errorf("%w: signature %d", ErrSignatureStatusPending, i)
+		}
+	}
+	return nil
+}
+
+// confirmationStatusRank orders confirmation statuses from the weakest to the strongest.
+func confirmationStatusRank(status rpc.ConfirmationStatusType) int {
+	switch status {
+	case rpc.ConfirmationStatusProcessed:
+		return 1
+	case rpc.ConfirmationStatusConfirmed:
+		return 2
+	case rpc.ConfirmationStatusFinalized:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// VerifyTransactionSignatures checks that the transaction carries one valid signature for every required signer.
+// It returns an error naming the first signer whose signature is missing or invalid.
+func VerifyTransactionSignatures(tx *solana.Transaction) error {
+	numSigners := int(tx.Message.Header.NumRequiredSignatures)
+	if len(tx.Signatures) != numSigners || len(tx.Message.AccountKeys) < numSigners {
+		return fmt.Errorf("transaction has %d signatures, %d required", len(tx.Signatures), numSigners)
+	}
+
+	message, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < numSigners; i++ {
+		signer := tx.Message.AccountKeys[i]
+		if tx.Signatures[i].IsZero() {
+			return fmt.Errorf("missing signature for signer %s", signer)
+		}
+		if !tx.Signatures[i].Verify(signer, message) {
+			return fmt.Errorf("invalid signature for signer %s", signer)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// ErrSignerKeyNotSet is returned when the environment variable holding a key is not set.
+var ErrSignerKeyNotSet = errors.New("signer key not set")
+
+// Signer holds a key able to sign messages, such as the block engine authentication challenge
+// or bundle transactions. The key itself may live outside the process, e.g. in a remote signer.
+// solana.PrivateKey implements Signer.
+type Signer interface {
+	// PublicKey returns the public key of the signer.
+	PublicKey() solana.PublicKey
+	// Sign signs the message with the key.
+	Sign(message []byte) (solana.Signature, error)
+}
+
+// MemorySigner is a Signer holding its private key in process memory.
+type MemorySigner struct {
+	key solana.PrivateKey // Private key
+}
+
+// NewMemorySigner creates a Signer from a private key held in process memory.
+func NewMemorySigner(key solana.PrivateKey) *MemorySigner {
+	return &MemorySigner{
+		key: key,
+	}
+}
+
+// NewKeygenFileSigner creates a Signer from a Solana CLI JSON keypair file, as written by solana-keygen.
+func NewKeygenFileSigner(path string) (*MemorySigner, error) {
+	key, err := solana.PrivateKeyFromSolanaKeygenFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read keypair file %s: %w", path, err)
+	}
+	return NewMemorySigner(key), nil
+}
+
+// NewEnvSigner creates a Signer from a base58 private key held in the environment variable.
+func NewEnvSigner(name string) (*MemorySigner, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return nil, fmt.Errorf("%w: environment variable %s", ErrSignerKeyNotSet, name)
+	}
+
+	key, err := solana.PrivateKeyFromBase58(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key in environment variable %s: %w", name, err)
+	}
+	return NewMemorySigner(key), nil
+}
+
+// PublicKey returns the public key of the signer.
+func (s *MemorySigner) PublicKey() solana.PublicKey {
+	return s.key.PublicKey()
+}
+
+// Sign signs the message with the private key.
+func (s *MemorySigner) Sign(message []byte) (solana.Signature, error) {
+	return s.key.Sign(message)
+}
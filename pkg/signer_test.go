@@ -0,0 +1,99 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// checkSigner checks the signer holds the key.
+func checkSigner(t *testing.T, signer Signer, key solana.PrivateKey) {
+	t.Helper()
+
+	if !signer.PublicKey().Equals(key.PublicKey()) {
+		t.Fatalf("signer public key = %s, want %s", signer.PublicKey(), key.PublicKey())
+	}
+	message := []byte("challenge")
+	signature, err := signer.Sign(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !signature.Verify(key.PublicKey(), message) {
+		t.Error("signature does not verify with the public key")
+	}
+}
+
+func TestKeygenFileSigner(t *testing.T) {
+	key := newTestKey(t)
+	dir := t.TempDir()
+
+	// solana-keygen writes the 64 key bytes as a JSON array
+	values := make([]int, len(key))
+	for i, b := range key {
+		values[i] = int(b)
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "id.json")
+	if err = os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := NewKeygenFileSigner(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSigner(t, signer, key)
+
+	if _, err = NewKeygenFileSigner(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("NewKeygenFileSigner() of a missing file succeeded")
+	}
+}
+
+func TestEnvSigner(t *testing.T) {
+	key := newTestKey(t)
+
+	tests := []struct {
+		name    string
+		value   *string
+		want    error
+		invalid bool
+	}{
+		{name: "base58 key", value: func() *string { v := key.String(); return &v }()},
+		{name: "not set", want: ErrSignerKeyNotSet},
+		{name: "empty", value: new(string), want: ErrSignerKeyNotSet},
+		{name: "invalid", value: func() *string { v := "not a key"; return &v }(), invalid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := "JITO_GO_TEST_SIGNER_KEY"
+			if tt.value != nil {
+				t.Setenv(name, *tt.value)
+			}
+
+			signer, err := NewEnvSigner(name)
+			switch {
+			case tt.invalid:
+				if err == nil || errors.Is(err, ErrSignerKeyNotSet) {
+					t.Errorf("NewEnvSigner() = %v, want an invalid key error", err)
+				}
+			case !errors.Is(err, tt.want):
+				t.Errorf("NewEnvSigner() = %v, want %v", err, tt.want)
+			case tt.want == nil:
+				checkSigner(t, signer, key)
+			}
+		})
+	}
+}
+
+func TestMemorySigner(t *testing.T) {
+	key := newTestKey(t)
+	checkSigner(t, NewMemorySigner(key), key)
+	checkSigner(t, key, key)
+}
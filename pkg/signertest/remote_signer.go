@@ -0,0 +1,66 @@
+package signertest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/Prophet-Solutions/jito-go/pkg"
+)
+
+// maxRequestBody is the maximum size of a sign request body.
+const maxRequestBody = 1 << 20
+
+// publicKeyResponse is the response of the public key path.
+type publicKeyResponse struct {
+	PublicKey string `json:"public_key"` // Base58 public key
+}
+
+// signRequest is the request of the sign path.
+type signRequest struct {
+	Message []byte `json:"message"` // Message to sign, base64 in JSON
+}
+
+// signResponse is the response of the sign path.
+type signResponse struct {
+	Signature string `json:"signature"` // Base58 signature
+}
+
+// NewRemoteSignerHandler returns an HTTP handler serving the remote signer API of pkg.RemoteSigner with the
+// signer. It stands in for a remote signer in tests, e.g. with httptest.NewServer, and does no authentication.
+func NewRemoteSignerHandler(signer pkg.Signer) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET "+pkg.RemoteSignerPublicKey, func(w http.ResponseWriter, r *http.Request) {
+		writeResponse(w, &publicKeyResponse{
+			PublicKey: signer.PublicKey().String(),
+		})
+	})
+
+	mux.HandleFunc("POST "+pkg.RemoteSignerSign, func(w http.ResponseWriter, r *http.Request) {
+		var req signRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestBody)).Decode(&req); err != nil {
+			http.Error(w, "invalid sign request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		signature, err := signer.Sign(req.Message)
+		if err != nil {
+			http.Error(w, "could not sign: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeResponse(w, &signResponse{
+			Signature: signature.String(),
+		})
+	})
+
+	return mux
+}
+
+// writeResponse writes a JSON response of the remote signer API.
+func writeResponse(w http.ResponseWriter, resp interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
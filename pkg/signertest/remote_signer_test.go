@@ -0,0 +1,87 @@
+package signertest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	"github.com/gagliardetto/solana-go"
+)
+
+// impostorSigner advertises a public key it does not sign with.
+type impostorSigner struct {
+	pkg.Signer                  // Signer actually signing
+	publicKey  solana.PublicKey // Public key advertised
+}
+
+// PublicKey returns the advertised public key.
+func (s impostorSigner) PublicKey() solana.PublicKey {
+	return s.publicKey
+}
+
+func newTestKey(t *testing.T) solana.PrivateKey {
+	t.Helper()
+
+	key, err := solana.NewRandomPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestRemoteSigner(t *testing.T) {
+	key := newTestKey(t)
+	var authorization string
+	handler := NewRemoteSignerHandler(pkg.NewMemorySigner(key))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	signer, err := pkg.NewRemoteSigner(context.Background(), server.URL+"/", nil, http.Header{"Authorization": {"Bearer token"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !signer.PublicKey().Equals(key.PublicKey()) {
+		t.Fatalf("remote public key = %s, want %s", signer.PublicKey(), key.PublicKey())
+	}
+
+	message := []byte("challenge")
+	signature, err := signer.Sign(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := key.Sign(message); signature != want {
+		t.Errorf("remote signature = %s, want %s", signature, want)
+	}
+	if authorization != "Bearer token" {
+		t.Errorf("authorization header = %q, want the configured header", authorization)
+	}
+}
+
+func TestRemoteSignerInvalidSignature(t *testing.T) {
+	impostor := impostorSigner{Signer: pkg.NewMemorySigner(newTestKey(t)), publicKey: newTestKey(t).PublicKey()}
+	server := httptest.NewServer(NewRemoteSignerHandler(impostor))
+	defer server.Close()
+
+	signer, err := pkg.NewRemoteSigner(context.Background(), server.URL, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = signer.Sign([]byte("challenge")); !errors.Is(err, pkg.ErrRemoteSignature) {
+		t.Errorf("Sign() = %v, want %v", err, pkg.ErrRemoteSignature)
+	}
+}
+
+func TestRemoteSignerUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	if _, err := pkg.NewRemoteSigner(context.Background(), server.URL, nil, nil); err == nil {
+		t.Error("NewRemoteSigner() of a server without the remote signer API succeeded")
+	}
+}
@@ -0,0 +1,40 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// DecompileInstructions rebuilds the instructions of a legacy transaction with their account metas,
+// so they can be recompiled into a new transaction, e.g. with an extra tip instruction.
+// Transactions using address lookup tables are not supported.
+func DecompileInstructions(tx *solana.Transaction) ([]solana.Instruction, error) {
+	if len(tx.Message.AddressTableLookups) > 0 {
+		return nil, errors.New("cannot decompile a transaction using address lookup tables")
+	}
+
+	keys := tx.Message.AccountKeys
+	numSigners := int(tx.Message.Header.NumRequiredSignatures)
+
+	instructions := make([]solana.Instruction, 0, len(tx.Message.Instructions))
+	for i, compiled := range tx.Message.Instructions {
+		if int(compiled.ProgramIDIndex) >= len(keys) {
+			return nil, fmt.Errorf("instruction %d has an invalid program index", i)
+		}
+
+		accounts := make(solana.AccountMetaSlice, 0, len(compiled.Accounts))
+		for _, index := range compiled.Accounts {
+			if int(index) >= len(keys) {
+				return nil, fmt.Errorf("instruction %d has an invalid account index", i)
+			}
+			writable := isWritableIndex(tx.Message.Header, len(keys), int(index))
+			accounts = append(accounts, solana.NewAccountMeta(keys[index], writable, int(index) < numSigners))
+		}
+
+		instructions = append(instructions, solana.NewInstruction(keys[compiled.ProgramIDIndex], accounts, compiled.Data))
+	}
+
+	return instructions, nil
+}
@@ -0,0 +1,46 @@
+package pkg
+
+import (
+	"errors"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// PartialSignTransaction signs the transaction with every provided signer that is a required signer.
+// Signatures of required signers without a provided signer are kept as they are, so a transaction
+// can be signed by several parties in turn.
+func PartialSignTransaction(tx *solana.Transaction, signers []Signer) error {
+	numSigners := int(tx.Message.Header.NumRequiredSignatures)
+	if len(tx.Message.AccountKeys) < numSigners {
+		return errors.New("transaction has fewer account keys than required signatures")
+	}
+
+	message, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	// Make sure there is one signature slot per required signer
+	if len(tx.Signatures) != numSigners {
+		signatures := make([]solana.Signature, numSigners)
+		copy(signatures, tx.Signatures)
+		tx.Signatures = signatures
+	}
+
+	for i := 0; i < numSigners; i++ {
+		for _, signer := range signers {
+			if !signer.PublicKey().Equals(tx.Message.AccountKeys[i]) {
+				continue
+			}
+
+			sig, err := signer.Sign(message)
+			if err != nil {
+				return err
+			}
+			tx.Signatures[i] = sig
+			break
+		}
+	}
+
+	return nil
+}
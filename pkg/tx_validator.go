@@ -2,8 +2,33 @@ package pkg
 
 import "github.com/gagliardetto/solana-go"
 
-// ValidateTransaction makes sure the bytes length of your transaction < 1232.
+// ValidateTransaction makes sure the serialized length of your transaction <= MaxTransactionSize.
 // If your transaction is bigger, Jito will return an error.
 func ValidateTransaction(tx *solana.Transaction) bool {
-	return len([]byte(tx.String())) <= 1232
+	size, err := SerializedTransactionSize(tx)
+	return err == nil && size <= MaxTransactionSize
+}
+
+// SerializedTransactionSize returns the wire size of the transaction once signed by all required signers.
+// The size does not depend on the signature values, so it can be computed before signing.
+func SerializedTransactionSize(tx *solana.Transaction) (int, error) {
+	message, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+
+	numSigners := int(tx.Message.Header.NumRequiredSignatures)
+	return compactU16Len(numSigners) + numSigners*solana.SignatureLength + len(message), nil
+}
+
+// compactU16Len returns the number of bytes used by the compact-u16 encoding of n.
+func compactU16Len(n int) int {
+	switch {
+	case n < 0x80:
+		return 1
+	case n < 0x4000:
+		return 2
+	default:
+		return 3
+	}
 }
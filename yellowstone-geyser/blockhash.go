@@ -0,0 +1,42 @@
+package yellowstone_geyser
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	pb "github.com/Prophet-Solutions/yellowstone-geyser-protos/geyser"
+	"github.com/gagliardetto/solana-go"
+)
+
+// BlockhashSource is a pkg.BlockhashSource backed by the Geyser GetLatestBlockhash method.
+type BlockhashSource struct {
+	Client     *GeyserClient      // Geyser client
+	Commitment pb.CommitmentLevel // Commitment of the blockhash
+}
+
+// NewBlockhashSource creates a BlockhashSource fetching blockhashes from the Geyser client at the given commitment.
+func (gc *GeyserClient) NewBlockhashSource(commitment pb.CommitmentLevel) *BlockhashSource {
+	return &BlockhashSource{
+		Client:     gc,
+		Commitment: commitment,
+	}
+}
+
+// LatestBlockhash fetches the latest blockhash from the Geyser service.
+func (s *BlockhashSource) LatestBlockhash(ctx context.Context) (*pkg.LatestBlockhash, error) {
+	resp, err := s.Client.GetLatestBlockhash(ctx, &s.Commitment)
+	if err != nil {
+		return nil, fmt.Errorf("could not get latest blockhash: %w", err)
+	}
+
+	blockhash, err := solana.HashFromBase58(resp.GetBlockhash())
+	if err != nil {
+		return nil, fmt.Errorf("invalid blockhash %s: %w", resp.GetBlockhash(), err)
+	}
+
+	return &pkg.LatestBlockhash{
+		Blockhash:            blockhash,
+		LastValidBlockHeight: resp.GetLastValidBlockHeight(),
+	}, nil
+}
@@ -16,8 +16,11 @@ func NewClient(
 	grpcAddr string,
 	opts ...grpc.DialOption,
 ) (*GeyserClient, error) {
+	// Event bus for connection and stream events, shared if given with pkg.WithEventBus.
+	bus := pkg.EventBusFromDialOptions(opts)
+
 	// Establish the gRPC connection using the provided context, address, and options.
-	conn, err := pkg.CreateGRPCConnection(ctx, nil, grpcAddr, opts...)
+	conn, err := pkg.CreateGRPCConnection(ctx, bus, grpcAddr, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -38,12 +41,13 @@ func NewClient(
 		Client:   geyserClient,
 		Streams:  sync.Map{},
 		DefaultStreamClient: &StreamClient{
+			Name:            "default",
 			SubscribeClient: subscribe,
 			Ctx:             ctx,
 			UpdateCh:        make(chan *pb.SubscribeUpdate), // Unbuffered channel to prevent overflowing
-			ErrCh:           make(chan error, 10),
+			Events:          bus,
 		},
-		ErrCh: make(chan error, 10),
+		Events: bus,
 	}, nil
 }
 
@@ -54,6 +58,7 @@ func (c *GeyserClient) NewSubscribeClient(ctx context.Context, clientName string
 	}
 
 	streamClient := &StreamClient{
+		Name:            clientName,
 		Ctx:             ctx,
 		SubscribeClient: stream,
 		SubscribeRequest: &pb.SubscribeRequest{
@@ -67,7 +72,7 @@ func (c *GeyserClient) NewSubscribeClient(ctx context.Context, clientName string
 			AccountsDataSlice:  make([]*pb.SubscribeRequestAccountsDataSlice, 0),
 		},
 		UpdateCh: make(chan *pb.SubscribeUpdate), // Unbuffered channel to prevent overflowing
-		ErrCh:    make(chan error, 10),
+		Events:   c.Events,
 	}
 
 	c.Streams.Store(clientName, streamClient)
@@ -76,19 +81,22 @@ func (c *GeyserClient) NewSubscribeClient(ctx context.Context, clientName string
 	return nil
 }
 
-// listen starts listening for responses and errors.
+// listen receives responses until the stream ends, then publishes the error that ended it and closes UpdateCh.
 func (s *StreamClient) listen() {
+	defer close(s.UpdateCh)
 	for {
+		recv, err := s.SubscribeClient.Recv()
+		if err != nil {
+			if s.Ctx.Err() == nil {
+				s.Events.Publish(pkg.Event{Kind: pkg.EventStreamError, Source: "geyser/" + s.Name, Err: err})
+			}
+			return
+		}
+
 		select {
+		case s.UpdateCh <- recv:
 		case <-s.Ctx.Done():
 			return
-		default:
-			recv, err := s.SubscribeClient.Recv()
-			if err != nil {
-				s.ErrCh <- err
-			} else {
-				s.UpdateCh <- recv
-			}
 		}
 	}
 }
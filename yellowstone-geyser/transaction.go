@@ -0,0 +1,111 @@
+package yellowstone_geyser
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Prophet-Solutions/jito-go/pkg"
+	pb "github.com/Prophet-Solutions/yellowstone-geyser-protos/geyser"
+	"github.com/gagliardetto/solana-go"
+)
+
+// ConvertTransactionUpdateToTransaction converts a transaction update of a Geyser subscription to a Solana transaction.
+func ConvertTransactionUpdateToTransaction(update *pb.SubscribeUpdateTransaction) (*solana.Transaction, error) {
+	tx := update.GetTransaction().GetTransaction()
+	msg := tx.GetMessage()
+	if msg == nil {
+		return nil, errors.New("transaction update has no message")
+	}
+
+	signatures := make([]solana.Signature, 0, len(tx.GetSignatures()))
+	for i, signature := range tx.GetSignatures() {
+		if len(signature) != solana.SignatureLength {
+			return nil, fmt.Errorf("signature %d has an invalid length", i)
+		}
+		signatures = append(signatures, solana.SignatureFromBytes(signature))
+	}
+
+	accountKeys, err := convertPublicKeys(msg.GetAccountKeys())
+	if err != nil {
+		return nil, fmt.Errorf("invalid account key: %w", err)
+	}
+	if len(msg.GetRecentBlockhash()) != solana.PublicKeyLength {
+		return nil, errors.New("recent blockhash has an invalid length")
+	}
+
+	instructions := make([]solana.CompiledInstruction, 0, len(msg.GetInstructions()))
+	for _, instruction := range msg.GetInstructions() {
+		accounts := make([]uint16, 0, len(instruction.GetAccounts()))
+		for _, index := range instruction.GetAccounts() {
+			accounts = append(accounts, uint16(index))
+		}
+		instructions = append(instructions, solana.CompiledInstruction{
+			ProgramIDIndex: uint16(instruction.GetProgramIdIndex()),
+			Accounts:       accounts,
+			Data:           instruction.GetData(),
+		})
+	}
+
+	lookups := make(solana.MessageAddressTableLookupSlice, 0, len(msg.GetAddressTableLookups()))
+	for i, lookup := range msg.GetAddressTableLookups() {
+		if len(lookup.GetAccountKey()) != solana.PublicKeyLength {
+			return nil, fmt.Errorf("address table lookup %d has an invalid account key", i)
+		}
+		lookups = append(lookups, solana.MessageAddressTableLookup{
+			AccountKey:      solana.PublicKeyFromBytes(lookup.GetAccountKey()),
+			WritableIndexes: lookup.GetWritableIndexes(),
+			ReadonlyIndexes: lookup.GetReadonlyIndexes(),
+		})
+	}
+
+	header := msg.GetHeader()
+	message := solana.Message{
+		AccountKeys: accountKeys,
+		Header: solana.MessageHeader{
+			NumRequiredSignatures:       uint8(header.GetNumRequiredSignatures()),
+			NumReadonlySignedAccounts:   uint8(header.GetNumReadonlySignedAccounts()),
+			NumReadonlyUnsignedAccounts: uint8(header.GetNumReadonlyUnsignedAccounts()),
+		},
+		RecentBlockhash:     solana.HashFromBytes(msg.GetRecentBlockhash()),
+		Instructions:        instructions,
+		AddressTableLookups: lookups,
+	}
+	if msg.GetVersioned() {
+		message.SetVersion(solana.MessageVersionV0)
+	}
+
+	return &solana.Transaction{
+		Signatures: signatures,
+		Message:    message,
+	}, nil
+}
+
+// NewBackrunTarget creates a backrun target from a transaction update of a Geyser subscription.
+// The writable accounts the transaction loaded from address lookup tables are included.
+func NewBackrunTarget(update *pb.SubscribeUpdateTransaction) (*pkg.BackrunTarget, error) {
+	tx, err := ConvertTransactionUpdateToTransaction(update)
+	if err != nil {
+		return nil, err
+	}
+
+	loaded, err := convertPublicKeys(update.GetTransaction().GetMeta().GetLoadedWritableAddresses())
+	if err != nil {
+		return nil, fmt.Errorf("invalid loaded writable address: %w", err)
+	}
+
+	target := pkg.NewBackrunTarget(tx)
+	target.Writable = append(target.Writable, loaded...)
+	return target, nil
+}
+
+// convertPublicKeys converts raw public keys to Solana public keys.
+func convertPublicKeys(keys [][]byte) (solana.PublicKeySlice, error) {
+	pubkeys := make(solana.PublicKeySlice, 0, len(keys))
+	for i, key := range keys {
+		if len(key) != solana.PublicKeyLength {
+			return nil, fmt.Errorf("key %d has an invalid length", i)
+		}
+		pubkeys = append(pubkeys, solana.PublicKeyFromBytes(key))
+	}
+	return pubkeys, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync"
 
+	"github.com/Prophet-Solutions/jito-go/pkg"
 	pb "github.com/Prophet-Solutions/yellowstone-geyser-protos/geyser"
 	"google.golang.org/grpc"
 )
@@ -16,13 +17,14 @@ type GeyserClient struct {
 	Client              pb.GeyserClient  // Geyser client from protobuf
 	Streams             sync.Map         // Active stream clients
 	DefaultStreamClient *StreamClient    // Default stream client
-	ErrCh               chan error       // Channel for errors
+	Events              *pkg.EventBus    // Connection and stream events
 }
 
 type StreamClient struct {
+	Name             string                    // Name of the stream client, the source of its events
 	Ctx              context.Context           // Context for cancellation and deadlines
 	SubscribeClient  pb.Geyser_SubscribeClient // Geyser subscribe client
 	SubscribeRequest *pb.SubscribeRequest      // Subscribe request
 	UpdateCh         chan *pb.SubscribeUpdate  // Channel for updates
-	ErrCh            chan error                // Channel for errors
+	Events           *pkg.EventBus             // Bus the error ending the stream is published on
 }